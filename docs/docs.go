@@ -15,187 +15,228 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/app/callback": {
+        "/app/v1/access/denials": {
             "get": {
-                "description": "OAuth2 Callback für ESI, speichert Token und setzt Cookie",
+                "description": "Abgewiesene und in Quarantäne genommene Logins, neueste zuerst.",
                 "produces": [
-                    "text/html"
+                    "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Access"
                 ],
-                "summary": "ESI Callback",
+                "summary": "Abgewiesene Logins",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Authorization Code",
-                        "name": "code",
-                        "in": "query",
-                        "required": true
+                        "type": "boolean",
+                        "description": "nur ungeprüfte",
+                        "name": "pending",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max. Einträge (Standard 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "302": {
-                        "description": "Redirect to home",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.LoginDenial"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "DB error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/app/express/mail": {
+        "/app/v1/access/denials/{id}/review": {
             "post": {
-                "description": "Verwendet einen fest konfigurierten Service-Char (ENV), sendet Mail an eine Ziel-Corp (ENV).",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "Mail"
+                    "Access"
                 ],
-                "summary": "EVE-Mail für EXPRESS senden (Service-Char -\u003e Ziel-Corp)",
+                "summary": "Abweisung als geprüft markieren",
                 "parameters": [
                     {
-                        "description": "Express Daten",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/structs.ExpressMailRequest"
-                        }
+                        "type": "integer",
+                        "description": "Denial ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "mail_id",
+                    "204": {
+                        "description": "No Content",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "integer"
-                            }
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid id",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
-                    "502": {
-                        "description": "Bad Gateway",
+                    "500": {
+                        "description": "DB error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/app/login": {
+        "/app/v1/access/policy": {
             "get": {
-                "description": "Leitet zum ESI-Login um",
+                "description": "Aktive Allow-List/Quarantäne-Einstellungen (nur lesend, kommt aus der Umgebung).",
                 "produces": [
-                    "text/html"
+                    "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Access"
                 ],
-                "summary": "Login redirect",
+                "summary": "Zugangs-Policy",
                 "responses": {
-                    "302": {
-                        "description": "Redirect",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/access.Policy"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/app/logout": {
+        "/app/v1/account": {
             "get": {
-                "description": "Löscht den Auth-Cookie und entfernt das Token aus dem Speicher",
+                "description": "Main, Alts und aktiver Char; je Char, ob ein ESI-Token gespeichert ist.",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Account"
                 ],
-                "summary": "Logout",
+                "summary": "Eigener Account",
                 "responses": {
-                    "204": {
-                        "description": "No Content",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.Account"
+                        }
+                    },
+                    "401": {
+                        "description": "Not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No account",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/app/mail": {
+        "/app/v1/account/active": {
             "post": {
-                "description": "Sendet eine EVE-Mail über ESI. Empfänger nutzt kein CSPA, daher wird ` + "`" + `approved_cost` + "`" + ` immer 0 gesendet.",
+                "description": "Z.B. zum Mail-Versand mit einem Alt; der Char braucht einen gespeicherten ESI-Token.",
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "Mail"
+                    "Account"
                 ],
-                "summary": "EVE-Mail senden (ohne CSPA)",
+                "summary": "Aktiven Char wechseln",
                 "parameters": [
                     {
-                        "description": "Mail-Daten",
-                        "name": "mail",
+                        "description": "Char",
+                        "name": "char",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/structs.SendMailRequest"
+                            "$ref": "#/definitions/structs.SwitchCharReq"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "204": {
+                        "description": "No Content",
                         "schema": {
-                            "$ref": "#/definitions/structs.MailIDResponse"
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Not logged in",
                         "schema": {
                             "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Character not linked to your account",
                         "schema": {
                             "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
-                    "502": {
-                        "description": "Bad Gateway",
+                    "409": {
+                        "description": "No token for character",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
                         "schema": {
                             "$ref": "#/definitions/structs.ErrorResponse"
                         }
@@ -203,495 +244,3665 @@ const docTemplate = `{
                 }
             }
         },
-        "/app/me": {
-            "get": {
-                "description": "Gibt Charakter-ID und Name zurück (aus Cookie)",
-                "produces": [
-                    "application/json"
-                ],
+        "/app/v1/account/characters/{charID}": {
+            "delete": {
+                "description": "Löst einen Alt aus dem Account; war er aktiv, wird der Main wieder aktiv.",
                 "tags": [
-                    "Auth"
+                    "Account"
+                ],
+                "summary": "Alt lösen",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Character ID",
+                        "name": "charID",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "User Info",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content",
                         "schema": {
-                            "$ref": "#/definitions/structs.VerifyResponse"
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid charID",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Not logged in",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Character not linked to your account",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Main character cannot be unlinked",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "DB error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/app/ping": {
+        "/app/v1/account/link": {
             "get": {
-                "description": "Gibt \"pong\" zurück",
-                "produces": [
-                    "text/plain"
-                ],
+                "description": "Zweiter SSO-Login; der neue Char wird Alt des eingeloggten Accounts. Ergebnis per Redirect (?link=ok|self|taken|has_alts|failed).",
                 "tags": [
-                    "System"
+                    "Account"
                 ],
-                "summary": "Healthcheck",
+                "summary": "Char verknüpfen",
                 "responses": {
-                    "200": {
-                        "description": "pong",
+                    "302": {
+                        "description": "Redirect",
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "401": {
+                        "description": "Not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit (RateLimit-*, Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/app/role": {
-            "get": {
-                "description": "Gibt die Rolle des eingeloggten Benutzers zurück",
-                "produces": [
-                    "application/json"
-                ],
+        "/app/v1/affiliations/refresh": {
+            "post": {
+                "description": "Läuft im Hintergrund; höchstens ein Lauf gleichzeitig.",
                 "tags": [
-                    "Auth"
+                    "Admin"
                 ],
-                "summary": "Benutzerrolle abrufen",
+                "summary": "Alle Zugehörigkeiten aktualisieren",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "type": "string"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
                     "403": {
                         "description": "Forbidden",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "DB error",
+                    "409": {
+                        "description": "Refresh already running",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/app/routes": {
+        "/app/v1/backups": {
             "get": {
-                "description": "Gibt alle verfügbaren Transport-Routen zurück",
+                "description": "Archive im Backup-Verzeichnis (BACKUP_DIR), neueste zuerst. Einspielen nur per CLI (backup restore).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Routes"
+                    "Admin"
                 ],
-                "summary": "Get all active routes",
+                "summary": "Backups auflisten",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/structs.Route"
+                                "$ref": "#/definitions/structs.BackupInfo"
                             }
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
                     }
                 }
             },
             "post": {
-                "description": "Erstellt eine neue Transport-Route",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Exportiert die Fachtabellen (ohne OAuth-/API-Tokens) als JSON-Archiv nach BACKUP_DIR und räumt\nältere Archive ab (BACKUP_KEEP).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Routes"
-                ],
-                "summary": "Neue Route anlegen",
-                "parameters": [
-                    {
-                        "description": "Neue Route",
-                        "name": "route",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/structs.Route"
-                        }
-                    }
+                    "Admin"
                 ],
+                "summary": "Backup anlegen",
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/structs.Route"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid JSON",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.BackupInfo"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
                     "403": {
                         "description": "Forbidden",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "DB Insert error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/app/routes/{id}": {
-            "put": {
-                "description": "Aktualisiert eine bestehende Transport-Route anhand der ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+        "/app/v1/callback": {
+            "get": {
+                "description": "OAuth2 Callback: speichert Token, setzt Cookie, löst Corp/Alliance via Affiliation auf.\nMit Link-State (/account/link) wird der Char stattdessen als Alt an den eingeloggten Account gehängt.",
                 "tags": [
-                    "Routes"
+                    "Auth"
                 ],
-                "summary": "Route aktualisieren",
+                "summary": "ESI Callback",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Route ID",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Authorization Code",
+                        "name": "code",
+                        "in": "query",
                         "required": true
                     },
                     {
-                        "description": "Route-Daten",
-                        "name": "route",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/structs.Route"
-                        }
+                        "type": "string",
+                        "description": "State (login oder link:\u003cnonce\u003e)",
+                        "name": "state",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/structs.Route"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid JSON",
+                    "302": {
+                        "description": "Redirect to home",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "429": {
+                        "description": "Rate limit (RateLimit-*, Retry-After)",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "DB Update error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Löscht eine Transport-Route anhand der ID",
+            }
+        },
+        "/app/v1/corps": {
+            "get": {
+                "description": "Bekannte Corps nach Name oder Ticker (max. 100), z.B. für Whitelists und Raten.",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Routes"
+                    "Admin"
                 ],
-                "summary": "Route löschen",
+                "summary": "Corps suchen",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Route ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Suchbegriff (Name, Ticker)",
+                        "name": "q",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Deleted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.CorpOption"
+                            }
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
                     "403": {
                         "description": "Forbidden",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "DB Delete error",
+                        "description": "DB error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/app/users": {
-            "get": {
-                "description": "Gibt eine Liste aller Benutzer mit deren Rollen zurück (nur Admin).",
+        "/app/v1/express/mail": {
+            "post": {
+                "description": "Sendet als Service-Char (ENV) an die diensthabenden Provider der Route, sonst an die Ziel-Corp/Alliance (ENV).\nDer Reward wird serverseitig wie bei /quote berechnet; ein abweichender reward_isk ergibt 422.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin"
+                    "Mail"
+                ],
+                "summary": "EVE-Mail für EXPRESS senden",
+                "parameters": [
+                    {
+                        "description": "Express Daten",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.ExpressMailRequest"
+                        }
+                    }
                 ],
-                "summary": "Alle Benutzer abrufen",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "mail_id, dispatch, recipients, reward_isk",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/structs.User"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "422": {
+                        "description": "Unprocessable Entity",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit (RateLimit-*, Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "DB error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/app/users/{charID}/role": {
-            "put": {
-                "description": "Setzt die Rolle eines Benutzers anhand der charID (nur Admin).",
-                "consumes": [
-                    "application/json"
-                ],
+        "/app/v1/express/token-status": {
+            "get": {
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Admin"
+                    "Mail"
                 ],
-                "summary": "Rolle eines Benutzers ändern",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Character ID",
-                        "name": "charID",
-                        "in": "path",
-                        "required": true
+                "summary": "Token-Status des Service-Chars",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
-                    {
-                        "description": "Neue Rolle",
-                        "name": "role",
-                        "in": "body",
-                        "required": true,
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/structs.UpdateRoleReq"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     }
+                }
+            }
+        },
+        "/app/v1/healthz": {
+            "get": {
+                "description": "Prozess läuft und bedient Requests – keine Abhängigkeiten",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
                 ],
+                "summary": "Liveness",
                 "responses": {
-                    "204": {
-                        "description": "No Content",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.HealthReport"
                         }
-                    },
-                    "400": {
-                        "description": "Invalid JSON or role",
+                    }
+                }
+            }
+        },
+        "/app/v1/login": {
+            "get": {
+                "description": "Leitet zum ESI-Login um",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Login redirect",
+                "responses": {
+                    "302": {
+                        "description": "Redirect",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "429": {
+                        "description": "Rate limit (RateLimit-*, Retry-After)",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/app/v1/logins/suspicious": {
+            "get": {
+                "description": "IPs mit Logins mehrerer Accounts und Chars mit vielen IPs im Zeitfenster.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Auffällige Logins",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Zeitfenster in Stunden (Standard 24)",
+                        "name": "hours",
+                        "in": "query"
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    {
+                        "type": "integer",
+                        "description": "ab wie vielen Accounts pro IP (Standard 3)",
+                        "name": "min_accounts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ab wie vielen IPs pro Char (Standard 5)",
+                        "name": "min_ips",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.SuspiciousLogin"
+                            }
                         }
                     },
                     "500": {
                         "description": "DB error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/structs.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "structs.ErrorResponse": {
-            "type": "object",
+        },
+        "/app/v1/logout": {
+            "get": {
+                "description": "Löscht den Auth-Cookie und entfernt das Token aus dem Speicher",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Logout",
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/mail": {
+            "post": {
+                "description": "Sendet eine EVE-Mail über ESI als eingeloggter Char. Empfänger nutzt kein CSPA, daher wird ` + "`" + `approved_cost` + "`" + ` immer 0 gesendet.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mail"
+                ],
+                "summary": "EVE-Mail senden (ohne CSPA)",
+                "parameters": [
+                    {
+                        "description": "Mail-Daten",
+                        "name": "mail",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.SendMailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/structs.MailIDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit (RateLimit-*, Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/me": {
+            "get": {
+                "description": "Charakter-ID und Name aus dem Cookie – leichtgewichtig, nur Verify (keine ESI-Polllawine)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "User Info",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.VerifyResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/permissions": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Bekannte Rechte abrufen",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/ping": {
+            "get": {
+                "description": "Gibt \"pong\" zurück",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Healthcheck",
+                "responses": {
+                    "200": {
+                        "description": "pong",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/promos": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pricing"
+                ],
+                "summary": "Promo-Codes auflisten",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.PromoCode"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "POST mit code im Body, PUT mit code im Pfad (überschreibt den Body). Codes sind case-insensitiv.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pricing"
+                ],
+                "summary": "Promo-Code anlegen/ändern",
+                "parameters": [
+                    {
+                        "description": "Promo-Code",
+                        "name": "promo",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.PromoCode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.PromoCode"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid promo code",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/promos/{code}": {
+            "put": {
+                "description": "POST mit code im Body, PUT mit code im Pfad (überschreibt den Body). Codes sind case-insensitiv.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pricing"
+                ],
+                "summary": "Promo-Code anlegen/ändern",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Promo-Code (nur PUT)",
+                        "name": "code",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Promo-Code",
+                        "name": "promo",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.PromoCode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.PromoCode"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid promo code",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Pricing"
+                ],
+                "summary": "Promo-Code löschen",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Promo-Code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/providers": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Providers"
+                ],
+                "summary": "Hauler-Profile auflisten",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.ProviderProfile"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/providers/available": {
+            "get": {
+                "description": "Wer kann diese Lane gerade fliegen: im Dienst, Route im Profil, genug Kapazität, Recht providers.profile.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Providers"
+                ],
+                "summary": "Verfügbare Hauler",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Route ID (unter /app/: routeId)",
+                        "name": "route_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Volumen in m³",
+                        "name": "volume",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.ProviderProfile"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/providers/me": {
+            "get": {
+                "description": "Ohne gespeichertes Profil kommen Defaults (freighter, UTC), damit das Frontend ein Formular füllen kann.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Providers"
+                ],
+                "summary": "Eigenes Hauler-Profil",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ProviderProfile"
+                        }
+                    },
+                    "401": {
+                        "description": "Not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Schiffsklasse, Kapazität, Zeitzone, Notizen und Routen. Im Dienst nur mit max_m3 \u003e 0.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Providers"
+                ],
+                "summary": "Eigenes Hauler-Profil speichern",
+                "parameters": [
+                    {
+                        "description": "Profil",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.ProviderProfile"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ProviderProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid profile",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/providers/me/duty": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Providers"
+                ],
+                "summary": "Dienst an/aus",
+                "parameters": [
+                    {
+                        "description": "Dienststatus",
+                        "name": "duty",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.DutyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "No capacity set",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No provider profile",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/quote": {
+            "post": {
+                "description": "Preis für den Aufrufer inkl. Corp-/Alliance-Rate und Promo-Code",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pricing"
+                ],
+                "summary": "Preis berechnen",
+                "parameters": [
+                    {
+                        "description": "Anfrage",
+                        "name": "quote",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.QuoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.QuoteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Promo code not valid",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit (RateLimit-*, Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/rates": {
+            "get": {
+                "description": "Corp-/Alliance-Raten (Festpreis pro m³ oder Rabatt), global oder je Route.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pricing"
+                ],
+                "summary": "Raten auflisten",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.RouteRate"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Genau eine von corp_id/alliance_id; price_per_m3 und/oder discount_pct. Pro Ziel (Corp/Alliance, Route) gibt es eine Rate, eine bestehende wird ersetzt.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pricing"
+                ],
+                "summary": "Rate anlegen/ersetzen",
+                "parameters": [
+                    {
+                        "description": "Rate",
+                        "name": "rate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.RouteRate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.RouteRate"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rate",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/rates/{id}": {
+            "delete": {
+                "tags": [
+                    "Pricing"
+                ],
+                "summary": "Rate löschen",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rate ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/readyz": {
+            "get": {
+                "description": "Prüft DB-Verbindung, Schema-Version und den Service-Token (EXPRESS_SENDER_CHAR_ID).\nEin fehlgeschlagener kritischer Check liefert 503; der Service-Token ist nur mit READY_REQUIRE_SERVICE_TOKEN=true kritisch.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Readiness",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.HealthReport"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/structs.HealthReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/role": {
+            "get": {
+                "description": "Primäre Rolle, alle Rollen, effektive Rechte und Quarantäne-Status des eingeloggten Chars",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Rollen und Rechte abrufen",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.RoleResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/role-rules": {
+            "get": {
+                "description": "Regeln vergeben beim Login Rollen nach Corp, Alliance oder als Default.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Roles"
+                ],
+                "summary": "Rollen-Regeln auflisten",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.RoleRule"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "match_type corp/alliance (mit match_id) oder default. Nur Rollen, deren Rechte der Aufrufer selbst hat.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Roles"
+                ],
+                "summary": "Rollen-Regel anlegen",
+                "parameters": [
+                    {
+                        "description": "Regel",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.RoleRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/structs.RoleRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rule or role",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role grants permissions you do not have",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/role-rules/{id}": {
+            "delete": {
+                "tags": [
+                    "Roles"
+                ],
+                "summary": "Rollen-Regel löschen",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Regel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid id",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/roles": {
+            "get": {
+                "description": "Alle Rollen mit ihren Rechten.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Rollen abrufen",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.Role"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/roles/{name}": {
+            "put": {
+                "description": "Setzt Beschreibung und Rechte einer Rolle. \"admin\" ist unveränderlich.\nVergeben und ändern lassen sich nur Rechte, die der Aufrufer selbst hat.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Rolle anlegen oder ändern",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rollenname",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rolle",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.Role"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid role",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Permissions the caller does not have",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Löscht eine selbst definierte Rolle samt Zuweisungen.",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Rolle löschen",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rollenname",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Builtin role",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Permissions the caller does not have",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/routes": {
+            "get": {
+                "description": "Gibt alle für den Aufrufer sichtbaren Transport-Routen zurück",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routes"
+                ],
+                "summary": "Get all active routes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.Route"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Login required (ALLOW_ANONYMOUS_ROUTES=false)",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Erstellt eine neue Transport-Route",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routes"
+                ],
+                "summary": "Neue Route anlegen",
+                "parameters": [
+                    {
+                        "description": "Neue Route",
+                        "name": "route",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.Route"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/structs.Route"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/routes/export": {
+            "get": {
+                "description": "Alle Routen inkl. Sichtbarkeit, Mindestpreis und Whitelist-Corps (IDs + Ticker) als JSON oder CSV",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Routes"
+                ],
+                "summary": "Routen exportieren",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "json (Default) oder csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.RouteExport"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/routes/import": {
+            "post": {
+                "description": "Gleicht die Routen mit einer CSV- oder JSON-Datei (Format wie Export) ab. Zuordnung per ID, sonst per Von/Nach.\ndry_run=true liefert nur den Plan (creates/updates/deletes); sonst wird alles in einer Transaktion angewendet.\nprune=true löscht Routen, die in der Datei fehlen. Jede Zeile wird wie beim Anlegen/Ändern validiert;\nbei Fehlern wird nichts geändert (422 mit Plan und Zeilenfehlern).",
+                "consumes": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routes"
+                ],
+                "summary": "Routen importieren",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "json oder csv (Default: aus Content-Type)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Nur planen, nichts ändern",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Fehlende Routen löschen",
+                        "name": "prune",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Routen",
+                        "name": "routes",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.RouteExport"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.RouteImportPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/structs.RouteImportPlan"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/routes/{id}": {
+            "put": {
+                "description": "Aktualisiert eine bestehende Transport-Route anhand der ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Routes"
+                ],
+                "summary": "Route aktualisieren",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Route ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Route",
+                        "name": "route",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.Route"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.Route"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Löscht eine Transport-Route anhand der ID",
+                "tags": [
+                    "Routes"
+                ],
+                "summary": "Route löschen",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Route ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/tokens": {
+            "get": {
+                "description": "Listet die API-Tokens des eingeloggten Chars (ohne Klartext)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tokens"
+                ],
+                "summary": "Eigene API-Tokens",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.APIToken"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Der Klartext-Token wird nur in dieser Antwort geliefert; Nutzung per \"Authorization: Bearer \u003ctoken\u003e\".\nratePerMin höchstens API_TOKEN_RATE_PER_MIN (Standard 60), mit users.manage bis 6000.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tokens"
+                ],
+                "summary": "API-Token anlegen",
+                "parameters": [
+                    {
+                        "description": "Name, Scopes, Limits",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.CreateAPITokenReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/structs.CreatedAPIToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/tokens/{id}": {
+            "delete": {
+                "tags": [
+                    "Tokens"
+                ],
+                "summary": "API-Token widerrufen",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/users": {
+            "get": {
+                "description": "Paginierte Benutzerliste inkl. Corp/Alliance; Suche über Name, Corp- oder Alliance-Ticker.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Benutzer suchen",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Suchbegriff (Name, Corp-/Alliance-Ticker)",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "nur Benutzer mit dieser Rolle",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "nur gesperrte (true) bzw. nicht gesperrte (false)",
+                        "name": "banned",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name | corp | alliance | role | last_login | last_seen | created",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc | desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Seite (ab 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Einträge pro Seite (max. 200)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.UserPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/users/inactive": {
+            "get": {
+                "description": "Benutzer ohne Aktivität (last_seen, last_login, created) seit ` + "`" + `days` + "`" + ` Tagen.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Inaktive Benutzer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tage ohne Aktivität (Standard 30)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max. Einträge (Standard 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.UserListItem"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid days",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/users/{charID}": {
+            "get": {
+                "description": "Letzter Login, Token-Status, verknüpfte Chars und eingelöste Promo-Codes eines Benutzers.\nAufträge werden serverseitig nicht gespeichert (keine orders-Tabelle); eingelöste Promo-Codes sind die einzige Auftragsspur.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Benutzer-Details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Character ID",
+                        "name": "charID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.UserDetail"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid charID",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/users/{charID}/affiliation/refresh": {
+            "post": {
+                "description": "Holt die Zugehörigkeit per ESI; verstößt sie gegen die Zugangs-Policy, geht der Char in Quarantäne.",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Corp/Alliance eines Benutzers aktualisieren",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Character ID",
+                        "name": "charID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid charID",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Refresh failed",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/users/{charID}/ban": {
+            "post": {
+                "description": "Sperrt Login und API-Zugriff eines Chars (bei einem Main für den ganzen Account).",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Benutzer sperren",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Character ID",
+                        "name": "charID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Grund",
+                        "name": "ban",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/structs.BanReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid charID",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "User has permissions you do not have",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Sperre aufheben",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Character ID",
+                        "name": "charID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid charID",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "User has permissions you do not have",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/users/{charID}/logins": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Login-Historie eines Benutzers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Character ID",
+                        "name": "charID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max. Einträge (Standard 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.LoginEvent"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid charID",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/users/{charID}/release": {
+            "post": {
+                "description": "Der Char bleibt trotz Allow-List zugelassen. Nur für Benutzer, deren Rechte der Aufrufer selbst hat.",
+                "tags": [
+                    "Access"
+                ],
+                "summary": "Quarantäne aufheben",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Character ID",
+                        "name": "charID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid charID",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "User has permissions you do not have",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/users/{charID}/role": {
+            "put": {
+                "description": "Setzt die Rolle eines Benutzers anhand der charID (Recht users.manage; nur Rollen, deren Rechte man selbst hat).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Rolle eines Benutzers ändern",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Character ID",
+                        "name": "charID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Neue Rolle",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.UpdateRoleReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or role",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/users/{charID}/role-changes": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Roles"
+                ],
+                "summary": "Rollen-Änderungen eines Benutzers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Character ID",
+                        "name": "charID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max. Einträge",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.RoleChange"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid charID",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/users/{charID}/roles": {
+            "get": {
+                "description": "Zuweisungen samt Herkunft (manual/rule/default).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Roles"
+                ],
+                "summary": "Rollen-Zuweisungen eines Benutzers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Character ID",
+                        "name": "charID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/structs.RoleAssignment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid charID",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Ersetzt die Rollen eines Benutzers anhand der charID (Recht users.manage; nur Rollen, deren Rechte man selbst hat).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Alle Rollen eines Benutzers setzen",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Character ID",
+                        "name": "charID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Neue Rollen",
+                        "name": "roles",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/structs.UpdateUserRolesReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or role",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/app/v1/users/{charID}/roles/manual": {
+            "delete": {
+                "description": "Hebt die manuelle Überschreibung auf, danach greifen wieder die Regeln.",
+                "tags": [
+                    "Roles"
+                ],
+                "summary": "Manuelle Rollen aufheben",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Character ID",
+                        "name": "charID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid charID",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "User has permissions you do not have",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "DB error",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "access.Policy": {
+            "type": "object",
+            "properties": {
+                "allow_anonymous_routes": {
+                    "type": "boolean"
+                },
+                "allowed_alliances": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "allowed_corps": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "deny_action": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.APIToken": {
+            "type": "object",
+            "properties": {
+                "char_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "rate_per_min": {
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "structs.Account": {
+            "type": "object",
+            "properties": {
+                "active_char_id": {
+                    "type": "integer"
+                },
+                "characters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structs.AccountCharacter"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "main_char_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "structs.AccountCharacter": {
+            "type": "object",
+            "properties": {
+                "char_id": {
+                    "type": "integer"
+                },
+                "corp_name": {
+                    "type": "string"
+                },
+                "has_token": {
+                    "type": "boolean"
+                },
+                "is_main": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.BackupInfo": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "structs.BanReq": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.CorpOption": {
+            "type": "object",
+            "properties": {
+                "corp_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "ticker": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.CreateAPITokenReq": {
+            "type": "object",
+            "properties": {
+                "expires_in_days": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rate_per_min": {
+                    "type": "integer"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "structs.CreatedAPIToken": {
+            "type": "object",
+            "properties": {
+                "char_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "rate_per_min": {
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.DutyReq": {
+            "type": "object",
+            "properties": {
+                "on_duty": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "structs.ErrorBody": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "bad_request"
+                },
+                "details": {},
+                "message": {
+                    "type": "string",
+                    "example": "Invalid JSON"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "c0ffee-000042"
+                }
+            }
+        },
+        "structs.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/structs.ErrorBody"
+                }
+            }
+        },
+        "structs.ExpressMailRequest": {
+            "type": "object",
+            "properties": {
+                "collateral_isk": {
+                    "description": "0..20B",
+                    "type": "integer"
+                },
+                "customer_char_id": {
+                    "description": "optional: Wer hat ausgelöst?",
+                    "type": "integer"
+                },
+                "customer_char_name": {
+                    "type": "string"
+                },
+                "express": {
+                    "description": "true",
+                    "type": "boolean"
+                },
+                "notes": {
+                    "description": "optional",
+                    "type": "string"
+                },
+                "promo_code": {
+                    "description": "optional: Promo-Code wird beim Senden eingelöst",
+                    "type": "string"
+                },
+                "reward_isk": {
+                    "description": "optional, muss sonst dem Server-Quote entsprechen",
+                    "type": "integer"
+                },
+                "route": {
+                    "description": "\"Amarr ↔ K-6K16\"",
+                    "type": "string"
+                },
+                "route_id": {
+                    "description": "Preis kommt aus der Route",
+                    "type": "string"
+                },
+                "volume_m3": {
+                    "description": "z.B. 165000",
+                    "type": "integer"
+                }
+            }
+        },
+        "structs.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "critical": {
+                    "type": "boolean"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.HealthReport": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/structs.HealthCheck"
+                    }
+                },
+                "status": {
+                    "description": "ok | degraded | fail",
+                    "type": "string"
+                }
+            }
+        },
+        "structs.LoginDenial": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "\"reject\" | \"quarantine\"",
+                    "type": "string"
+                },
+                "alliance_id": {
+                    "type": "integer"
+                },
+                "char_id": {
+                    "type": "integer"
+                },
+                "char_name": {
+                    "type": "string"
+                },
+                "corp_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reviewed_at": {
+                    "type": "string"
+                },
+                "reviewed_by": {
+                    "type": "integer"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.LoginEvent": {
+            "type": "object",
+            "properties": {
+                "char_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "login | link",
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.MailIDResponse": {
+            "type": "object",
             "properties": {
-                "error": {
+                "mail_id": {
+                    "type": "integer",
+                    "example": 399492427
+                }
+            }
+        },
+        "structs.MailRecipient": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1234546456
+                },
+                "type": {
                     "type": "string",
-                    "example": "Invalid JSON"
+                    "enum": [
+                        "character",
+                        "corporation",
+                        "alliance",
+                        "mailing_list"
+                    ],
+                    "example": "character"
+                }
+            }
+        },
+        "structs.PromoCode": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "discount_pct": {
+                    "type": "number"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "max_uses": {
+                    "description": "nil = unbegrenzt",
+                    "type": "integer"
+                },
+                "route_id": {
+                    "description": "nil = alle Routen",
+                    "type": "string"
+                },
+                "uses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "structs.PromoRedemption": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "redeemed_at": {
+                    "type": "string"
+                },
+                "route": {
+                    "type": "string"
+                },
+                "route_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.ProviderProfile": {
+            "type": "object",
+            "properties": {
+                "char_id": {
+                    "type": "integer"
+                },
+                "max_m3": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "on_duty": {
+                    "type": "boolean"
+                },
+                "route_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ship_class": {
+                    "description": "siehe AllowedShipClasses",
+                    "type": "string"
+                },
+                "timezone": {
+                    "description": "IANA, z.B. \"Europe/Berlin\"",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.QuoteRequest": {
+            "type": "object",
+            "properties": {
+                "collateral_isk": {
+                    "type": "integer"
+                },
+                "express": {
+                    "type": "boolean"
+                },
+                "promo_code": {
+                    "type": "string"
+                },
+                "route_id": {
+                    "type": "string"
+                },
+                "volume_m3": {
+                    "type": "integer"
+                }
+            }
+        },
+        "structs.QuoteResponse": {
+            "type": "object",
+            "properties": {
+                "base_price_per_m3": {
+                    "type": "number"
+                },
+                "collateral_fee": {
+                    "type": "integer"
+                },
+                "express": {
+                    "type": "boolean"
+                },
+                "min_applied": {
+                    "type": "boolean"
+                },
+                "min_price": {
+                    "type": "number"
+                },
+                "price_per_m3": {
+                    "type": "number"
+                },
+                "promo_code": {
+                    "type": "string"
+                },
+                "promo_discount_pct": {
+                    "type": "number"
+                },
+                "rate_discount_pct": {
+                    "type": "number"
+                },
+                "rate_source": {
+                    "description": "\"corp\" | \"alliance\"",
+                    "type": "string"
+                },
+                "route_id": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "volume_fee": {
+                    "type": "integer"
+                }
+            }
+        },
+        "structs.Role": {
+            "type": "object",
+            "properties": {
+                "builtin": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "structs.RoleAssignment": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.RoleChange": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "changed_by": {
+                    "type": "integer"
+                },
+                "char_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "new_roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "old_roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.RoleResponse": {
+            "type": "object",
+            "properties": {
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "quarantined": {
+                    "type": "boolean"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "structs.RoleRule": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "match_id": {
+                    "type": "integer"
+                },
+                "match_type": {
+                    "description": "\"corp\" | \"alliance\" | \"default\"",
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.Route": {
+            "type": "object",
+            "properties": {
+                "allowed_corps": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "min_price": {
+                    "type": "number"
+                },
+                "no_collateral": {
+                    "type": "boolean"
+                },
+                "price_per_m3": {
+                    "type": "number"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "description": "\"all\" | \"whitelist\"",
+                    "type": "string"
+                }
+            }
+        },
+        "structs.RouteChange": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "before": {
+                    "$ref": "#/definitions/structs.RouteExport"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "route": {
+                    "$ref": "#/definitions/structs.RouteExport"
                 }
             }
         },
-        "structs.ExpressMailRequest": {
+        "structs.RouteExport": {
             "type": "object",
             "properties": {
-                "collateral_isk": {
-                    "description": "0..20B",
-                    "type": "integer"
+                "allowed_corp_tickers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "customer_char_id": {
-                    "description": "optional: Wer hat ausgelöst?",
-                    "type": "integer"
+                "allowed_corps": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
                 },
-                "customer_char_name": {
+                "from": {
                     "type": "string"
                 },
-                "express": {
-                    "description": "true",
-                    "type": "boolean"
-                },
-                "notes": {
-                    "description": "optional",
+                "id": {
                     "type": "string"
                 },
-                "reward_isk": {
-                    "description": "z.B. 826500000",
-                    "type": "integer"
+                "min_price": {
+                    "type": "number"
                 },
-                "route": {
-                    "description": "\"Amarr ↔ K-6K16\"",
+                "no_collateral": {
+                    "type": "boolean"
+                },
+                "price_per_m3": {
+                    "type": "number"
+                },
+                "to": {
                     "type": "string"
                 },
-                "volume_m3": {
-                    "description": "z.B. 165000",
-                    "type": "integer"
+                "visibility": {
+                    "description": "\"all\" | \"whitelist\"",
+                    "type": "string"
                 }
             }
         },
-        "structs.MailIDResponse": {
+        "structs.RouteImportError": {
             "type": "object",
             "properties": {
-                "mail_id": {
-                    "type": "integer",
-                    "example": 399492427
+                "line": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
                 }
             }
         },
-        "structs.MailRecipient": {
+        "structs.RouteImportPlan": {
             "type": "object",
             "properties": {
-                "id": {
-                    "type": "integer",
-                    "example": 1234546456
+                "applied": {
+                    "type": "boolean"
                 },
-                "type": {
-                    "type": "string",
-                    "enum": [
-                        "character",
-                        "corporation",
-                        "alliance",
-                        "mailing_list"
-                    ],
-                    "example": "character"
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structs.RouteChange"
+                    }
+                },
+                "creates": {
+                    "type": "integer"
+                },
+                "deletes": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structs.RouteImportError"
+                    }
+                },
+                "unchanged": {
+                    "type": "integer"
+                },
+                "updates": {
+                    "type": "integer"
                 }
             }
         },
-        "structs.Route": {
+        "structs.RouteRate": {
             "type": "object",
             "properties": {
-                "allowedCorps": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
+                "alliance_id": {
+                    "type": "integer"
                 },
-                "from": {
+                "corp_id": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
+                "discount_pct": {
+                    "description": "prozentualer Rabatt (0..100]",
+                    "type": "number"
+                },
                 "id": {
                     "type": "string"
                 },
-                "noCollateral": {
-                    "type": "boolean"
-                },
-                "pricePerM3": {
+                "min_price": {
+                    "description": "optional abweichender Mindestpreis",
                     "type": "number"
                 },
-                "to": {
+                "note": {
                     "type": "string"
                 },
-                "visibility": {
-                    "description": "\"all\" | \"whitelist\"",
+                "price_per_m3": {
+                    "description": "Override des Routenpreises",
+                    "type": "number"
+                },
+                "route_id": {
                     "type": "string"
                 }
             }
@@ -699,7 +3910,7 @@ const docTemplate = `{
         "structs.SendMailRequest": {
             "type": "object",
             "properties": {
-                "autoApproveCspa": {
+                "auto_approve_cspa": {
                     "type": "boolean",
                     "example": false
                 },
@@ -719,6 +3930,66 @@ const docTemplate = `{
                 }
             }
         },
+        "structs.SuspiciousLogin": {
+            "type": "object",
+            "properties": {
+                "char_id": {
+                    "type": "integer"
+                },
+                "char_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "first_seen": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "ips": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "last_seen": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.SwitchCharReq": {
+            "type": "object",
+            "properties": {
+                "char_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "structs.TokenStatus": {
+            "type": "object",
+            "properties": {
+                "expiry": {
+                    "type": "string"
+                },
+                "has_token": {
+                    "type": "boolean"
+                },
+                "refreshable": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
         "structs.UpdateRoleReq": {
             "type": "object",
             "properties": {
@@ -727,34 +3998,195 @@ const docTemplate = `{
                 }
             }
         },
-        "structs.User": {
-            "description": "Darstellung eines Users für die Admin-API.",
+        "structs.UpdateUserRolesReq": {
+            "type": "object",
+            "properties": {
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "structs.UserDetail": {
+            "type": "object",
+            "properties": {
+                "alliance_id": {
+                    "type": "integer"
+                },
+                "alliance_name": {
+                    "type": "string"
+                },
+                "alliance_ticker": {
+                    "type": "string"
+                },
+                "banned_at": {
+                    "type": "string"
+                },
+                "banned_by": {
+                    "type": "integer"
+                },
+                "banned_reason": {
+                    "type": "string"
+                },
+                "char_id": {
+                    "type": "string"
+                },
+                "characters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structs.AccountCharacter"
+                    }
+                },
+                "corp_id": {
+                    "type": "integer"
+                },
+                "corp_name": {
+                    "type": "string"
+                },
+                "corp_ticker": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "last_login_at": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "main_char_id": {
+                    "description": "Main-Char des Accounts (bei Alts ungleich CharID)",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quarantined": {
+                    "type": "boolean"
+                },
+                "redemptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structs.PromoRedemption"
+                    }
+                },
+                "role": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token": {
+                    "$ref": "#/definitions/structs.TokenStatus"
+                }
+            }
+        },
+        "structs.UserListItem": {
             "type": "object",
             "properties": {
+                "alliance_id": {
+                    "type": "integer"
+                },
+                "alliance_name": {
+                    "type": "string"
+                },
+                "alliance_ticker": {
+                    "type": "string"
+                },
+                "banned_at": {
+                    "type": "string"
+                },
+                "banned_reason": {
+                    "type": "string"
+                },
                 "char_id": {
                     "type": "string"
                 },
+                "corp_id": {
+                    "type": "integer"
+                },
+                "corp_name": {
+                    "type": "string"
+                },
+                "corp_ticker": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "last_login_at": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "main_char_id": {
+                    "description": "Main-Char des Accounts (bei Alts ungleich CharID)",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
+                "quarantined": {
+                    "type": "boolean"
+                },
                 "role": {
                     "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "structs.UserPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structs.UserListItem"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
         "structs.VerifyResponse": {
             "type": "object",
             "properties": {
-                "CharacterID": {
+                "character_id": {
                     "type": "integer",
                     "example": 12345678
                 },
-                "CharacterName": {
+                "character_name": {
                     "type": "string",
                     "example": "Pilot McFly"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "BearerToken": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
@@ -765,7 +4197,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/",
 	Schemes:          []string{"http"},
 	Title:            "Speedliner API",
-	Description:      "REST API für Speedliner",
+	Description:      "REST API für Speedliner. Stabile Pfade unter /app/v1 mit snake_case-Feldnamen und\nFehler-Envelope {\"error\": {code, message, details, request_id}}; /app/* ist deprecated.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
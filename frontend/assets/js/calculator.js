@@ -9,6 +9,7 @@ const routeMeta = document.getElementById("routeMeta");
 
 const result = document.getElementById("result");
 const volumeInput = document.getElementById("volume");
+const promoInput = document.getElementById("promoCode");
 
 let userInteracted = false;
 
@@ -68,6 +69,13 @@ collateralInput.addEventListener("input", () => {
     calculator();
 });
 
+let promoTimer;
+promoInput?.addEventListener("input", () => {
+    userInteracted = true;
+    clearTimeout(promoTimer);
+    promoTimer = setTimeout(calculator, 300);
+});
+
 export function calculator() {
     const selectedRouteId = routeSelect.value;
     const route = routeData[selectedRouteId];
@@ -149,9 +157,10 @@ export function calculator() {
 
     showResult(resultHtml);
 
-    // Quote speichern
+    // Quote speichern (lokale Vorschau, refreshServerQuote ersetzt den Betrag)
     const routeLabel = routeSelect.options[routeSelect.selectedIndex]?.text?.split(" — ")[0] ?? "-";
     lastQuote = {
+        routeId: selectedRouteId,
         routeLabel,
         volume,
         collateral: hideCollateral ? 0 : (Number.isFinite(collateral) ? collateral : 0),
@@ -160,11 +169,69 @@ export function calculator() {
         minPrice,
         finalTotal,
         expressOn,
+        promoCode: (promoInput?.value || "").trim(),
+        serverTotal: null,
         days: expressOn ? 1 : 3
     };
 
     updateExpressUI();
     maybeOpenExpressModal();
+    refreshServerQuote();
+}
+
+// ----- Server-Quote (Corp-/Alliance-Rate, Promo-Code) -----
+let quoteSeq = 0;
+
+function serverQuoteHtml(q, data) {
+    const iskFmt = new Intl.NumberFormat("de-DE");
+    const notes = [];
+    if (q.expressOn) notes.push("+100% Express");
+    if (data.rate_source) {
+        notes.push(`${data.rate_source === "corp" ? "Corp" : "Alliance"} rate` +
+            (data.rate_discount_pct ? ` −${data.rate_discount_pct}%` : ""));
+    }
+    if (data.promo_code) notes.push(`Promo ${data.promo_code} −${data.promo_discount_pct}%`);
+    if (data.min_applied) notes.push(`Minimum price active (${iskFmt.format(data.min_price)} ISK)`);
+
+    return `Reward${q.expressOn ? " (Express)" : ""}: <span class="value">${iskFmt.format(q.finalTotal)} ISK</span>` +
+        (notes.length ? `<br><small>${notes.join(" · ")}</small>` : "");
+}
+
+// holt den verbindlichen Preis vom Server; veraltete Antworten (Eingabe inzwischen geändert) werden verworfen
+async function refreshServerQuote() {
+    const q = lastQuote;
+    if (!q) return null;
+    const seq = ++quoteSeq;
+
+    try {
+        const res = await fetch("/app/v1/quote", {
+            method: "POST",
+            headers: {"Content-Type": "application/json"},
+            credentials: "include",
+            body: JSON.stringify({
+                route_id: q.routeId,
+                volume_m3: q.volume,
+                collateral_isk: q.collateral,
+                express: q.expressOn,
+                promo_code: q.promoCode || undefined,
+            }),
+        });
+        if (seq !== quoteSeq || q !== lastQuote) return null;
+
+        if (!res.ok) {
+            if (res.status === 422) showResult("Promo code not valid for this route.", true);
+            return null;
+        }
+        const data = await res.json();
+        q.finalTotal = Number(data.total);
+        q.serverTotal = q.finalTotal;
+        showResult(serverQuoteHtml(q, data));
+        updateExpressUI();
+        return q;
+    } catch (e) {
+        console.error("Quote error:", e);
+        return null;
+    }
 }
 
 export function setRoutesData(routes) {
@@ -425,7 +492,8 @@ function buildExpressPayload(meInfo) {
     const routeLabel = routeSelect.options[routeSelect.selectedIndex]?.text ?? "-";
     const routeStr = routeLabelToArrow(routeLabel);
 
-    const reward = Number(lastQuote?.finalTotal ?? 0);
+    // 0 = Server rechnet selbst; sonst muss der Betrag zum Server-Quote passen
+    const reward = Number(lastQuote?.serverTotal ?? 0);
 
     const vol = Number(
         lastQuote?.volume ??
@@ -440,12 +508,14 @@ function buildExpressPayload(meInfo) {
     return {
         express: true,
         route: routeStr,
+        route_id: routeSelect.value,
         rewardISK: reward,
         reward_isk: reward,
         volumeM3: vol,
         volume_m3: vol,
         collateralISK: coll,
         collateral_isk: coll,
+        promo_code: lastQuote?.promoCode || undefined,
         notes: "EXPRESS Contract kommt demnächst vom Piloten:",
         customer_char_id: 10000,
         customer_char_name: meInfo?.CharacterName,
@@ -482,6 +552,8 @@ export async function sendExpressOnce() {
 
     try {
         let me = await fetchMe();
+        // Reward frisch vom Server, damit er zur Prüfung in /express/mail passt
+        await refreshServerQuote();
         const payload = buildExpressPayload(me);
 
         const res = await fetch("/app/express/mail", {
@@ -48,7 +48,7 @@ if (ta) {
 }
 
 
-window.copyContractName = copyContractName;
+document.getElementById("copyIcon")?.addEventListener("click", copyContractName);
 loadRoutes();
 loadUser();
 
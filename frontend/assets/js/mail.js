@@ -148,7 +148,9 @@ export function initMailModal({
             });
             if (!res.ok){
                 const txt = await res.text().catch(()=> "");
-                throw new Error(`${res.status} ${res.statusText} ${txt || ""}`.trim());
+                let msg = txt;
+                try { msg = JSON.parse(txt).error.message || txt; } catch (_) {}
+                throw new Error(`${res.status} ${res.statusText} ${msg || ""}`.trim());
             }
 
             // Cooldown starten
@@ -13,6 +13,7 @@ document.addEventListener("DOMContentLoaded", () => {
     initWhitelistUI();
     fetchRoutes();
 
+    document.getElementById("addRouteBtn").addEventListener("click", () => showRouteForm());
     document.getElementById("routeForm").addEventListener("submit", async (e) => {
         e.preventDefault();
         await saveRoute();
@@ -44,15 +45,17 @@ function renderRoutes(routes) {
             ? '<span class="badge" title="Nur ausgewählte Corps">Whitelist</span>'
             : '<span class="badge" title="Öffentlich">All</span>'}
         ${route.noCollateral ? '<span class="badge" title="Für diese Route ist keine Sicherheit nötig.">No collateral</span>' : ''}
-        <button onclick="editRoute('${route.id}')" title="Bearbeiten">
+        <button data-action="edit" title="Bearbeiten">
           <i class="fa-solid fa-pen-to-square"></i>
         </button>
-        <button onclick="deleteRoute('${route.id}')" title="Löschen">
+        <button data-action="delete" title="Löschen">
           <i class="fa-solid fa-trash"></i>
         </button>
       </td>
     `;
         tr.dataset.route = JSON.stringify(route);
+        tr.querySelector('[data-action="edit"]').addEventListener("click", () => editRoute(tr));
+        tr.querySelector('[data-action="delete"]').addEventListener("click", () => deleteRoute(route.id));
         tbody.appendChild(tr);
     });
 }
@@ -70,8 +73,7 @@ function showRouteForm(editing = false) {
     }
 }
 
-function editRoute(id) {
-    const row = document.querySelector(`tr td button[onclick="editRoute('${id}')"]`).closest("tr");
+function editRoute(row) {
     const route = JSON.parse(row.dataset.route);
 
     document.getElementById("routeId").value = route.id;
@@ -161,7 +163,7 @@ async function checkAccess() {
         const res = await fetch('/app/role', { credentials: 'include' });
         if (!res.ok) { window.location.href = '/'; return; }
         const data = await res.json();
-        if (!(data.role === 'admin' || data.role === 'provider')) {
+        if (!(data.permissions || []).includes('routes.edit')) {
             window.location.href = '/';
         }
     } catch (err) {
@@ -288,8 +290,3 @@ function debounce(fn, wait = 300) {
         t = setTimeout(() => fn(...args), wait);
     };
 }
-
-// Expose globally for onclick handlers
-window.showRouteForm = showRouteForm;
-window.editRoute = editRoute;
-window.deleteRoute = deleteRoute;
@@ -23,6 +23,10 @@ export async function loadUser() {
           <a href="#" id="providerPanelBtn" style="display: none;">
             <i class="fa-solid fa-truck"></i> Provider
           </a>
+          <div id="accountChars"></div>
+          <a href="/app/account/link" id="linkCharBtn">
+            <i class="fa-solid fa-link"></i> Link alt
+          </a>
           <a href="#" id="logoutLink">
             <i class="fa-solid fa-right-from-bracket"></i> Logout
           </a>
@@ -33,11 +37,13 @@ export async function loadUser() {
 
         const roleRes = await fetch("/app/role");
         if (roleRes.ok) {
-            const { role } = await roleRes.json();
-            if (["admin"].includes(role)) document.getElementById("adminPanelBtn").style.display = "block";
-            if (["admin", "provider"].includes(role)) document.getElementById("providerPanelBtn").style.display = "block";
+            const { permissions = [] } = await roleRes.json();
+            if (permissions.includes("users.manage")) document.getElementById("adminPanelBtn").style.display = "block";
+            if (permissions.includes("routes.edit")) document.getElementById("providerPanelBtn").style.display = "block";
         }
 
+        await loadAccountChars(data.CharacterID);
+
         const userMenu = document.getElementById("userMenu");
         const dropdown = document.getElementById("logoutDropdown");
 
@@ -66,6 +72,35 @@ export async function loadUser() {
     }
 }
 
+// Alts des Accounts im Dropdown: Klick wechselt den aktiven Char (z.B. für Mails)
+async function loadAccountChars(activeId) {
+    const box = document.getElementById("accountChars");
+    if (!box) return;
+    const res = await fetch("/app/account");
+    if (!res.ok) return;
+    const { characters = [] } = await res.json();
+    const others = characters.filter(c => c.charId !== activeId);
+    box.innerHTML = others.map(c => `
+          <a href="#" class="switch-char" data-char-id="${c.charId}" title="${c.hasToken ? "" : "No token, log in again"}">
+            <i class="fa-solid fa-user${c.isMain ? "-tie" : ""}"></i> ${c.name}
+          </a>`).join("");
+    box.querySelectorAll(".switch-char").forEach(a => a.addEventListener("click", async (e) => {
+        e.preventDefault();
+        const r = await fetch("/app/account/active", {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify({ charId: Number(a.dataset.charId) })
+        });
+        if (r.ok) window.location.reload();
+        else {
+            const txt = await r.text().catch(() => "");
+            let msg = txt;
+            try { msg = JSON.parse(txt).error.message || txt; } catch (_) {}
+            alert(msg);
+        }
+    }));
+}
+
 export async function logoutUser() {
     try {
         await fetch("/app/logout");
@@ -9,17 +9,17 @@ try {
   const res = await fetch("/app/role", { credentials: "include" });
   if (!res.ok) redirectHome();
 
-  const { role } = await res.json();
-  if (role !== "admin") redirectHome();
+  const { permissions = [] } = await res.json();
+  if (!permissions.includes("users.manage")) redirectHome();
 } catch {
   redirectHome();
 }
 
 
 async function loadUsers() {
-  const res = await fetch("/app/users", { credentials: "include" });
+  const res = await fetch("/app/users?per_page=200", { credentials: "include" });
   if (!res.ok) return;
-  const users = await res.json();
+  const { items: users = [] } = await res.json();
 
   const tbody = document.querySelector("#userTable tbody");
   tbody.innerHTML = "";
@@ -0,0 +1,16 @@
+// Package frontend bettet die Web-Oberfläche (HTML, JS, CSS, Bilder) ins Binary ein.
+// Vorkomprimierte Varianten unter assets/ (*.br, *.gz, siehe Dockerfile) werden mit eingebettet.
+package frontend
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.html assets
+var files embed.FS
+
+// FS liefert die eingebetteten Dateien (Pfade relativ zu frontend/).
+func FS() fs.FS {
+	return files
+}
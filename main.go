@@ -1,61 +1,26 @@
 // @title          Speedliner API
 // @version        1.0
-// @description    REST API für Speedliner
+// @description    REST API für Speedliner. Stabile Pfade unter /app/v1 mit snake_case-Feldnamen und
+// @description    Fehler-Envelope {"error": {code, message, details, request_id}}; /app/* ist deprecated.
 // @host           localhost:8080
 // @BasePath       /
 // @schemes        http
+// @securityDefinitions.apikey BearerToken
+// @in                         header
+// @name                       Authorization
 package main
 
 import (
 	"log"
-	"net/http"
 	"os"
 	_ "speedliner-server/docs"
-	"speedliner-server/src/db"
-	"speedliner-server/src/middleware"
-	"speedliner-server/src/router"
-	"speedliner-server/src/utils"
-	"speedliner-server/src/utils/esiauth"
-
-	httpSwagger "github.com/swaggo/http-swagger"
+	"speedliner-server/src/commands"
+	_ "time/tzdata" // Zeitzonen der Hauler-Profile auch im Alpine-Image
 )
 
-const DefaultAppPort = "8080"
-
+// Ohne Unterbefehl: serve. Admin-Befehle siehe "./server help".
 func main() {
-	utils.LoadEnv()
-	initializeLoggerOrExit("app.log")
-
-	r := router.NewRouter()
-
-	if err := db.InitDB(); err != nil {
+	if err := commands.App().Run(os.Args); err != nil {
 		log.Fatal(err)
 	}
-	if db.Pool == nil {
-		log.Fatal("DB pool not initialized")
-	}
-	// <-- hier Store an PGX-Pool hängen
-	esiauth.InitStore(esiauth.NewPGXTokenStore(db.Pool))
-
-	appPort := os.Getenv("APP_PORT")
-	if appPort == "" {
-		appPort = DefaultAppPort
-	}
-
-	if os.Getenv("APP_ENV") != "production" {
-		r.Handle("/swagger/*", httpSwagger.WrapHandler)
-	}
-
-	handler := middleware.LoggerMiddleware(middleware.NoCacheMiddleware(middleware.RateLimit(r)))
-
-	log.Println("🚀 Server läuft auf Port " + appPort)
-	log.Println(":" + appPort)
-	log.Fatal(http.ListenAndServe(":"+appPort, handler))
-}
-
-func initializeLoggerOrExit(logFile string) {
-	err := middleware.InitializeLogger(logFile)
-	if err != nil {
-		log.Fatalf("Fehler beim Initialisieren des Loggings: %v", err)
-	}
 }
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+	"speedliner-server/src/db"
+	"speedliner-server/src/utils/users"
+
+	"github.com/urfave/cli/v2"
+)
+
+func affiliationCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "affiliation",
+		Usage: "Corp/Alliance-Zugehörigkeit von ESI nachziehen",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "refresh",
+				Usage:     "Einen Char oder alle User aktualisieren (inkl. Rollen-Regeln und Allow-List)",
+				ArgsUsage: "[charID]",
+				Action:    affiliationRefresh,
+			},
+		},
+	}
+}
+
+func affiliationRefresh(c *cli.Context) error {
+	if _, err := connect(c); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if c.NArg() > 0 {
+		charID, err := parseCharID(c.Args().First())
+		if err != nil {
+			return err
+		}
+		if err := users.RefreshAffiliation(c.Context, charID); err != nil {
+			return err
+		}
+		fmt.Printf("char %d refreshed\n", charID)
+		return nil
+	}
+	if err := users.RefreshAllAffiliations(c.Context); err != nil {
+		return err
+	}
+	fmt.Println("all users refreshed")
+	return nil
+}
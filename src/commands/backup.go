@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"speedliner-server/src/db"
+	"speedliner-server/src/utils/backup"
+	"speedliner-server/src/utils/structs"
+
+	"github.com/urfave/cli/v2"
+)
+
+func backupCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "backup",
+		Usage: "JSON-Backups der Fachtabellen (ohne OAuth-/API-Tokens) anlegen, auflisten, einspielen",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "create",
+				Usage:  "Backup nach BACKUP_DIR schreiben",
+				Action: backupCreate,
+			},
+			{
+				Name:   "list",
+				Usage:  "Archive in BACKUP_DIR auflisten",
+				Action: backupList,
+			},
+			{
+				Name:      "restore",
+				Usage:     "Archiv prüfen und mit --yes einspielen (Name aus \"backup list\", Pfad oder \"-\")",
+				ArgsUsage: "<archiv>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "yes", Usage: "wirklich einspielen (sonst nur prüfen)"},
+					&cli.BoolFlag{Name: "prune", Usage: "Zeilen löschen, die im Archiv fehlen (vollständiger Stand)"},
+				},
+				Action: backupRestore,
+			},
+		},
+	}
+}
+
+func backupCreate(c *cli.Context) error {
+	cfg, err := connect(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	info, err := backup.Create(c.Context, cfg.Backup.Dir, cfg.Backup.Keep)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s (%d bytes)\n", info.Name, info.Size)
+	return nil
+}
+
+func backupList(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	list, err := backup.List(cfg.Backup.Dir)
+	if err != nil {
+		return err
+	}
+	for _, b := range list {
+		fmt.Printf("%s  %10d  %s\n", b.CreatedAt.Format("2006-01-02 15:04:05"), b.Size, b.Name)
+	}
+	return nil
+}
+
+func backupRestore(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.ShowSubcommandHelp(c)
+	}
+	cfg, err := connect(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Pfad oder Name im Backup-Verzeichnis
+	path := c.Args().First()
+	if _, err := os.Stat(path); path != "-" && errors.Is(err, os.ErrNotExist) {
+		if path, err = backup.Path(cfg.Backup.Dir, path); err != nil {
+			return fmt.Errorf("%s: %w", c.Args().First(), err)
+		}
+	}
+	a, err := backup.Read(path)
+	if err != nil {
+		return err
+	}
+	if err := db.CheckBackup(c.Context, a); err != nil {
+		return err
+	}
+	printArchive(a)
+	if !c.Bool("yes") {
+		fmt.Println("archive is valid; run again with --yes to restore")
+		return nil
+	}
+
+	res, err := db.RestoreBackup(c.Context, a, c.Bool("prune"))
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(res.Tables))
+	for n := range res.Tables {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fmt.Println("restored, rows now:")
+	for _, n := range names {
+		fmt.Printf("  %-20s %d\n", n, res.Tables[n])
+	}
+	return nil
+}
+
+func printArchive(a *structs.BackupArchive) {
+	fmt.Printf("archive from %s, schema version %d\n", a.CreatedAt.Format("2006-01-02 15:04:05 MST"), a.SchemaVersion)
+	for _, t := range a.Tables {
+		var rows []json.RawMessage
+		_ = json.Unmarshal(t.Rows, &rows)
+		fmt.Printf("  %-20s %d\n", t.Name, len(rows))
+	}
+}
@@ -0,0 +1,75 @@
+// Package commands: Unterbefehle des Server-Binaries (serve, migrate, user, route, token, affiliation, backup).
+package commands
+
+import (
+	"fmt"
+	"speedliner-server/src/config"
+	"speedliner-server/src/db"
+	"speedliner-server/src/utils/access"
+	"speedliner-server/src/utils/esi"
+	"speedliner-server/src/utils/esiauth"
+	"strconv"
+
+	"github.com/urfave/cli/v2"
+)
+
+// App baut die CLI; ohne Unterbefehl startet der Server (Docker-ENTRYPOINT bleibt ./server).
+func App() *cli.App {
+	return &cli.App{
+		Name:           "speedliner-server",
+		Usage:          "Speedliner API-Server und Admin-Werkzeuge",
+		DefaultCommand: "serve",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Konfigurationsdatei (YAML/TOML), sonst CONFIG_FILE",
+				EnvVars: []string{"CONFIG_FILE"},
+			},
+		},
+		Commands: []*cli.Command{
+			serveCommand(),
+			migrateCommand(),
+			userCommand(),
+			routeCommand(),
+			tokenCommand(),
+			affiliationCommand(),
+			backupCommand(),
+		},
+	}
+}
+
+// loadConfig liest die Konfiguration über --config.
+func loadConfig(c *cli.Context) (*config.Config, error) {
+	cfg, err := config.Load(c.String("config"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// connect: Konfiguration + DB (inkl. Schema) + Token-Store – Basis aller Admin-Befehle.
+func connect(c *cli.Context) (*config.Config, error) {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.InitDB(cfg.Database); err != nil {
+		return nil, err
+	}
+	if err := access.Configure(cfg.Access); err != nil {
+		return nil, err
+	}
+	esiauth.Configure(cfg.OAuth)
+	esi.Configure(cfg.ESI)
+	esiauth.InitStore(esiauth.NewPGXTokenStore(db.Pool))
+	return cfg, nil
+}
+
+func parseCharID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid charID %q", s)
+	}
+	return id, nil
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"fmt"
+	"speedliner-server/src/db"
+
+	"github.com/urfave/cli/v2"
+)
+
+func migrateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Schema anlegen/aktualisieren und Version ausgeben",
+		Action: func(c *cli.Context) error {
+			cfg, err := loadConfig(c)
+			if err != nil {
+				return err
+			}
+			// InitDB führt ensureSchema aus (idempotent)
+			if err := db.InitDB(cfg.Database); err != nil {
+				return err
+			}
+			defer db.Close()
+			v, err := db.AppliedSchemaVersion(c.Context)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("schema version %d (binary expects %d)\n", v, db.SchemaVersion)
+			return nil
+		},
+	}
+}
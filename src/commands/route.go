@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"speedliner-server/src/db"
+	"speedliner-server/src/utils/routeio"
+	"speedliner-server/src/utils/structs"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+func routeCommand() *cli.Command {
+	formatFlag := &cli.StringFlag{Name: "format", Aliases: []string{"f"}, Usage: "json|csv (Default: aus Dateiendung, sonst json)"}
+	return &cli.Command{
+		Name:  "route",
+		Usage: "Transport-Routen exportieren/importieren (JSON/CSV)",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Alle Routen inkl. Whitelist-Corps ausgeben",
+				ArgsUsage: "[datei]",
+				Flags:     []cli.Flag{formatFlag},
+				Action:    routeExport,
+			},
+			{
+				Name:      "import",
+				Usage:     "Routen mit Datei abgleichen (\"-\" = stdin); Zuordnung per ID, sonst per Von/Nach",
+				ArgsUsage: "<datei>",
+				Flags: []cli.Flag{
+					formatFlag,
+					&cli.BoolFlag{Name: "dry-run", Usage: "nur Plan anzeigen"},
+					&cli.BoolFlag{Name: "prune", Usage: "Routen löschen, die in der Datei fehlen"},
+				},
+				Action: routeImport,
+			},
+		},
+	}
+}
+
+// routeFileFormat: --format, sonst Dateiendung, sonst JSON.
+func routeFileFormat(c *cli.Context, name string) string {
+	if f := strings.ToLower(c.String("format")); f != "" {
+		return f
+	}
+	if strings.EqualFold(filepath.Ext(name), ".csv") {
+		return "csv"
+	}
+	return "json"
+}
+
+func routeExport(c *cli.Context) error {
+	if _, err := connect(c); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	routes, err := db.ExportRoutes(c.Context)
+	if err != nil {
+		return err
+	}
+	name := c.Args().First()
+	out := io.Writer(os.Stdout)
+	if name != "" && name != "-" {
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if routeFileFormat(c, name) == "csv" {
+		return routeio.WriteCSV(out, routes)
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(routes)
+}
+
+func routeImport(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.ShowSubcommandHelp(c)
+	}
+	name := c.Args().First()
+	in := io.Reader(os.Stdin)
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	var rows []structs.RouteImportRow
+	var err error
+	if routeFileFormat(c, name) == "csv" {
+		rows, err = routeio.ReadCSV(in)
+	} else {
+		rows, err = routeio.ReadJSON(in)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", routeFileFormat(c, name), err)
+	}
+	if _, err := connect(c); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	plan, err := db.ImportRoutes(c.Context, rows, c.Bool("prune"), c.Bool("dry-run"))
+	if err != nil {
+		return err
+	}
+	for _, ch := range plan.Changes {
+		if ch.Action == "unchanged" {
+			continue
+		}
+		fmt.Printf("%-7s %s → %s", ch.Action, ch.Route.From, ch.Route.To)
+		if ch.Line > 0 {
+			fmt.Printf(" (line %d)", ch.Line)
+		}
+		fmt.Println()
+	}
+	for _, e := range plan.Errors {
+		fmt.Fprintf(os.Stderr, "line %d: %s\n", e.Line, e.Message)
+	}
+	fmt.Printf("%d create, %d update, %d delete, %d unchanged\n", plan.Creates, plan.Updates, plan.Deletes, plan.Unchanged)
+	switch {
+	case len(plan.Errors) > 0:
+		return fmt.Errorf("%d invalid rows, nothing imported", len(plan.Errors))
+	case plan.DryRun:
+		fmt.Println("dry run, nothing changed")
+	}
+	return nil
+}
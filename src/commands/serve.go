@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"speedliner-server/frontend"
+	"speedliner-server/src/config"
+	"speedliner-server/src/db"
+	"speedliner-server/src/handler"
+	"speedliner-server/src/middleware"
+	"speedliner-server/src/repo"
+	"speedliner-server/src/router"
+	"speedliner-server/src/utils/access"
+	"speedliner-server/src/utils/apitokens"
+	"speedliner-server/src/utils/backup"
+	"speedliner-server/src/utils/esi"
+	"speedliner-server/src/utils/esiauth"
+	"speedliner-server/src/utils/metrics"
+	"speedliner-server/src/utils/static"
+	"speedliner-server/src/utils/users"
+	"strconv"
+	"syscall"
+	"time"
+
+	httpSwagger "github.com/swaggo/http-swagger"
+	"github.com/urfave/cli/v2"
+)
+
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "HTTP-Server starten (Standard ohne Unterbefehl)",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "frontend-dir",
+				Usage: "Frontend live aus diesem Verzeichnis statt eingebettet (Entwicklung, ohne Caching), sonst FRONTEND_DIR",
+			},
+		},
+		Action: serve,
+	}
+}
+
+// serve startet den API-Server und läuft bis SIGTERM/SIGINT.
+func serve(c *cli.Context) error {
+	// Konfiguration: Defaults → --config/CONFIG_FILE (YAML/TOML) → .env (optional) → ENV
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	if err := middleware.InitializeLogger(cfg.App.LogFile, cfg.Log); err != nil {
+		return fmt.Errorf("Fehler beim Initialisieren des Loggings: %w", err)
+	}
+	log.Printf("Konfiguration:\n%s", cfg.Redacted())
+
+	// SIGTERM/SIGINT beenden ctx: Hintergrundjobs stoppen, Server drainen
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := db.InitDB(cfg.Database); err != nil {
+		return err
+	}
+	// Datenhaltung für Handler, Rechteprüfung und ESI-Tokens
+	repos := repo.Postgres(db.Pool)
+	users.InitRepository(repos.Users)
+	esiauth.InitStore(repos.Tokens)
+	r := router.NewRouter(handler.New(repos), frontendHandler(c, cfg.Frontend), cfg.Middleware)
+
+	// Zugangs-Policy (ACCESS_MODE, ACCESS_ALLOWED_CORPS, ...)
+	if err := access.Configure(cfg.Access); err != nil {
+		return err
+	}
+	// Client-IP nur über vertrauenswürdige Proxies (TRUSTED_PROXIES)
+	proxies, _ := cfg.HTTP.TrustedProxyPrefixes() // in Validate geprüft
+	middleware.SetTrustedProxies(proxies)
+	// Security-Header, CORS für /app/ und Secure-Cookies in production
+	middleware.ConfigureSecurity(cfg.Security, cfg.CORS, cfg.App.Production())
+	// Rate-Limits (RATE_LIMIT_STORE, RATE_LIMIT_<GRUPPE>)
+	if err := middleware.ConfigureRateLimits(cfg.RateLimit); err != nil {
+		return err
+	}
+	apitokens.SetDefaultRatePerMin(cfg.APITokens.RatePerMin)
+	esiauth.Configure(cfg.OAuth)
+	esi.Configure(cfg.ESI)
+	handler.SetConfig(cfg)
+
+	// Corp/Alliance regelmäßig nachziehen (Rollen-Regeln), AFFILIATION_REFRESH_INTERVAL, 0 = aus
+	waitAffiliation := users.StartAffiliationRefresher(ctx, cfg.Affiliation.RefreshInterval.Std())
+	waitCleanup := middleware.StartCleanup(ctx)
+	// Automatische Backups (BACKUP_INTERVAL, 0 = aus)
+	waitBackup := backup.StartScheduler(ctx, cfg.Backup)
+	handler.SetBackgroundContext(ctx)
+	// Hintergrundjobs enden mit ctx; vor db.Close() auf sie warten
+	waitJobs := func() {
+		stop()
+		waitAffiliation()
+		waitCleanup()
+		waitBackup()
+		handler.WaitBackground()
+	}
+
+	appPort := strconv.Itoa(cfg.App.Port)
+
+	if !cfg.App.Production() {
+		r.Handle("/swagger/*", httpSwagger.WrapHandler)
+	}
+
+	// Prometheus: eigener interner Port (METRICS_ADDR, z.B. :9100) oder /metrics mit METRICS_TOKEN
+	metrics.RegisterPool(db.Pool)
+	http.DefaultTransport = metrics.Transport(http.DefaultTransport) // ESI-Aufrufe zählen
+	var metricsSrv *http.Server
+	if addr := cfg.Metrics.Addr; addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler(cfg.Metrics.Token))
+		metricsSrv = &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			log.Println("📈 Metrics auf " + addr)
+			if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("metrics listener: %v", err)
+			}
+		}()
+	} else if tok := cfg.Metrics.Token; tok != "" {
+		r.Handle("/metrics", metrics.Handler(tok))
+	}
+
+	srv := &http.Server{
+		Addr:              ":" + appPort,
+		Handler:           r,
+		ReadTimeout:       cfg.HTTP.ReadTimeout.Std(),
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout.Std(),
+		WriteTimeout:      cfg.HTTP.WriteTimeout.Std(),
+		IdleTimeout:       cfg.HTTP.IdleTimeout.Std(),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Println("🚀 Server läuft auf Port " + appPort)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			waitJobs()
+			db.Close()
+			return err
+		}
+	case <-ctx.Done():
+		stop() // zweites Signal beendet sofort
+		log.Println("⏳ Shutdown: laufende Requests werden abgeschlossen")
+	}
+
+	// Laufende Requests (z.B. Mail-Versand) abarbeiten lassen, neue ablehnen
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout.Std())
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP shutdown: %v", err)
+	}
+	if metricsSrv != nil {
+		_ = metricsSrv.Shutdown(shutdownCtx)
+	}
+	waitJobs()
+	db.Close()
+	log.Println("👋 Server beendet")
+	return nil
+}
+
+// frontendHandler: eingebettetes Frontend oder – mit --frontend-dir/FRONTEND_DIR – live von der Platte.
+func frontendHandler(c *cli.Context, cfg config.Frontend) http.Handler {
+	dir := cfg.Dir
+	if c.IsSet("frontend-dir") {
+		dir = c.String("frontend-dir")
+	}
+	if dir == "" {
+		return static.Handler(frontend.FS(), false)
+	}
+	log.Printf("🛠  Frontend live aus %s (ohne Caching)", dir)
+	return static.Handler(os.DirFS(dir), true)
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+	"speedliner-server/src/db"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func tokenCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "token",
+		Usage: "ESI-Tokens prüfen",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "status",
+				Usage:     "Token-Status eines Chars (Standard: Service-Char EXPRESS_SENDER_CHAR_ID)",
+				ArgsUsage: "[charID]",
+				Action:    tokenStatus,
+			},
+		},
+	}
+}
+
+func tokenStatus(c *cli.Context) error {
+	cfg, err := connect(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	charID := cfg.Express.SenderCharID
+	if c.NArg() > 0 {
+		if charID, err = parseCharID(c.Args().First()); err != nil {
+			return err
+		}
+	}
+	if charID == 0 {
+		return fmt.Errorf("no charID given and EXPRESS_SENDER_CHAR_ID not set")
+	}
+	ts, err := db.GetTokenStatus(c.Context, charID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("char:        %d\n", charID)
+	fmt.Printf("has token:   %v\n", ts.HasToken)
+	if !ts.HasToken {
+		return nil
+	}
+	fmt.Printf("refreshable: %v\n", ts.Refreshable)
+	if ts.Expiry != nil {
+		state := "valid"
+		if time.Now().After(*ts.Expiry) {
+			state = "expired (refreshed on next use)"
+		}
+		fmt.Printf("access exp.: %s (%s)\n", ts.Expiry.UTC().Format(time.RFC3339), state)
+	}
+	if ts.UpdatedAt != nil {
+		fmt.Printf("updated:     %s (%s ago)\n", ts.UpdatedAt.UTC().Format(time.RFC3339), time.Since(*ts.UpdatedAt).Round(time.Minute))
+	}
+	return nil
+}
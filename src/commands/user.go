@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"fmt"
+	"speedliner-server/src/db"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+func userCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "user",
+		Usage: "Benutzer verwalten",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "set-role",
+				Usage:     "Rollen eines Chars setzen (ersetzt alle, z.B. ersten Admin anlegen)",
+				ArgsUsage: "<charID> <role>[,<role>...]",
+				Action:    userSetRole,
+			},
+			{
+				Name:      "show",
+				Usage:     "Rollen und Status eines Chars anzeigen",
+				ArgsUsage: "<charID>",
+				Action:    userShow,
+			},
+		},
+	}
+}
+
+func userSetRole(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.ShowSubcommandHelp(c)
+	}
+	charID, err := parseCharID(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	if _, err := connect(c); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	user, err := db.GetUserDetail(c.Context, charID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("char %d unknown – log in once via SSO first", charID)
+	}
+	roles := splitArgs(c.Args().Get(1))
+	for _, role := range roles {
+		ok, err := db.RoleExists(c.Context, role)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("unknown role %q", role)
+		}
+	}
+	if err := db.SetUserRolesAs(c.Context, charID, roles, "cli"); err != nil {
+		return err
+	}
+	fmt.Printf("%s (%d): roles set to %s\n", user.Name, charID, strings.Join(roles, ", "))
+	return nil
+}
+
+func userShow(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.ShowSubcommandHelp(c)
+	}
+	charID, err := parseCharID(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	if _, err := connect(c); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	user, err := db.GetUserDetail(c.Context, charID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("char %d unknown", charID)
+	}
+	roles, err := db.GetUserRoleNames(c.Context, charID)
+	if err != nil {
+		return err
+	}
+	perms, err := db.GetUserPermissions(c.Context, charID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("char:        %s (%s)\n", user.Name, user.CharID)
+	fmt.Printf("roles:       %s\n", strings.Join(roles, ", "))
+	fmt.Printf("permissions: %s\n", strings.Join(perms, ", "))
+	fmt.Printf("quarantined: %v, banned: %v\n", user.Quarantined, user.BannedAt != nil)
+	return nil
+}
+
+func splitArgs(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
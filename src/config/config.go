@@ -0,0 +1,293 @@
+// Package config: typisierte Konfiguration aus Defaults, optionaler YAML/TOML-Datei,
+// optionaler .env und Umgebungsvariablen (in dieser Reihenfolge, spätere gewinnen).
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Duration akzeptiert "30s", "6h" usw. in ENV, YAML und TOML.
+type Duration time.Duration
+
+func (d Duration) Std() time.Duration { return time.Duration(d) }
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d Duration) MarshalText() ([]byte, error) { return []byte(d.String()), nil }
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(strings.TrimSpace(string(b)))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+type Config struct {
+	App         App         `yaml:"app"         toml:"app"`
+	Database    Database    `yaml:"database"    toml:"database"`
+	OAuth       OAuth       `yaml:"oauth"       toml:"oauth"`
+	ESI         ESI         `yaml:"esi"         toml:"esi"`
+	Express     Express     `yaml:"express"     toml:"express"`
+	Access      Access      `yaml:"access"      toml:"access"`
+	HTTP        HTTP        `yaml:"http"        toml:"http"`
+	RateLimit   RateLimit   `yaml:"rate_limit"  toml:"rate_limit"`
+	Log         Log         `yaml:"log"         toml:"log"`
+	Metrics     Metrics     `yaml:"metrics"     toml:"metrics"`
+	APITokens   APITokens   `yaml:"api_tokens"  toml:"api_tokens"`
+	Affiliation Affiliation `yaml:"affiliation" toml:"affiliation"`
+	Ready       Ready       `yaml:"ready"       toml:"ready"`
+	Backup      Backup      `yaml:"backup"      toml:"backup"`
+	Frontend    Frontend    `yaml:"frontend"    toml:"frontend"`
+	Middleware  Middleware  `yaml:"middleware"  toml:"middleware"`
+	Security    Security    `yaml:"security"    toml:"security"`
+	CORS        CORS        `yaml:"cors"        toml:"cors"`
+}
+
+type App struct {
+	Env     string `env:"APP_ENV"  yaml:"env"      toml:"env"`
+	Port    int    `env:"APP_PORT" yaml:"port"     toml:"port"`
+	LogFile string `env:"LOG_FILE" yaml:"log_file" toml:"log_file"`
+}
+
+func (a App) Production() bool { return a.Env == "production" }
+
+// Database: QueryTimeout begrenzt jede db-Funktion (0 = nur Request-Context),
+// Statements ab SlowQueryThreshold werden mit Request-ID geloggt (0 = aus).
+type Database struct {
+	URL                string   `env:"DATABASE_URL"            yaml:"url"                  toml:"url" secret:"url"`
+	QueryTimeout       Duration `env:"DB_QUERY_TIMEOUT"        yaml:"query_timeout"        toml:"query_timeout"`
+	SlowQueryThreshold Duration `env:"DB_SLOW_QUERY_THRESHOLD" yaml:"slow_query_threshold" toml:"slow_query_threshold"`
+}
+
+type OAuth struct {
+	ClientID     string `env:"OAUTH_CLIENT_ID"     yaml:"client_id"     toml:"client_id"`
+	ClientSecret string `env:"OAUTH_CLIENT_SECRET" yaml:"client_secret" toml:"client_secret" secret:"true"`
+	RedirectURL  string `env:"OAUTH_REDIRECT_URL"  yaml:"redirect_url"  toml:"redirect_url"`
+	// EVE-SSO-Endpunkte; nur für Tests/Mocks ändern
+	AuthURL  string `env:"OAUTH_AUTH_URL"  yaml:"auth_url"  toml:"auth_url"`
+	TokenURL string `env:"OAUTH_TOKEN_URL" yaml:"token_url" toml:"token_url"`
+}
+
+// ESI: Basis-URL der ESI-API (ohne abschließenden Slash); nur für Tests/Mocks ändern.
+type ESI struct {
+	BaseURL string `env:"ESI_BASE_URL" yaml:"base_url" toml:"base_url"`
+}
+
+// Express: Service-Char und Fallback-Empfänger (Corp/Alliance) der EXPRESS-Mails.
+type Express struct {
+	Enabled      bool   `env:"EXPRESS_ENABLED"        yaml:"enabled"        toml:"enabled"`
+	SenderCharID int64  `env:"EXPRESS_SENDER_CHAR_ID" yaml:"sender_char_id" toml:"sender_char_id"`
+	TargetType   string `env:"EXPRESS_TARGET_TYPE"    yaml:"target_type"    toml:"target_type"`
+	TargetID     int64  `env:"EXPRESS_TARGET_CORP_ID" yaml:"target_id"      toml:"target_id"`
+}
+
+type Access struct {
+	Mode                 string  `env:"ACCESS_MODE"              yaml:"mode"                   toml:"mode"`
+	DenyAction           string  `env:"ACCESS_DENY_ACTION"       yaml:"deny_action"            toml:"deny_action"`
+	AllowedCorps         []int64 `env:"ACCESS_ALLOWED_CORPS"     yaml:"allowed_corps"          toml:"allowed_corps"`
+	AllowedAlliances     []int64 `env:"ACCESS_ALLOWED_ALLIANCES" yaml:"allowed_alliances"      toml:"allowed_alliances"`
+	AllowAnonymousRoutes bool    `env:"ALLOW_ANONYMOUS_ROUTES"   yaml:"allow_anonymous_routes" toml:"allow_anonymous_routes"`
+}
+
+type HTTP struct {
+	ReadTimeout       Duration `env:"HTTP_READ_TIMEOUT"        yaml:"read_timeout"        toml:"read_timeout"`
+	ReadHeaderTimeout Duration `env:"HTTP_READ_HEADER_TIMEOUT" yaml:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout      Duration `env:"HTTP_WRITE_TIMEOUT"       yaml:"write_timeout"       toml:"write_timeout"`
+	IdleTimeout       Duration `env:"HTTP_IDLE_TIMEOUT"        yaml:"idle_timeout"        toml:"idle_timeout"`
+	ShutdownTimeout   Duration `env:"HTTP_SHUTDOWN_TIMEOUT"    yaml:"shutdown_timeout"    toml:"shutdown_timeout"`
+	TrustedProxies    []string `env:"TRUSTED_PROXIES"          yaml:"trusted_proxies"     toml:"trusted_proxies"`
+}
+
+// RateLimit: Policies als "<n>/<dauer>" oder "off" (siehe ParseRateSpec).
+type RateLimit struct {
+	Store   string `env:"RATE_LIMIT_STORE"   yaml:"store"   toml:"store"`
+	Default string `env:"RATE_LIMIT_DEFAULT" yaml:"default" toml:"default"`
+	Login   string `env:"RATE_LIMIT_LOGIN"   yaml:"login"   toml:"login"`
+	Mail    string `env:"RATE_LIMIT_MAIL"    yaml:"mail"    toml:"mail"`
+	Express string `env:"RATE_LIMIT_EXPRESS" yaml:"express" toml:"express"`
+	Quote   string `env:"RATE_LIMIT_QUOTE"   yaml:"quote"   toml:"quote"`
+}
+
+// Policies: Gruppenname → Spezifikation.
+func (r RateLimit) Policies() map[string]string {
+	return map[string]string{
+		"default": r.Default, "login": r.Login, "mail": r.Mail, "express": r.Express, "quote": r.Quote,
+	}
+}
+
+type Log struct {
+	Level         string `env:"LOG_LEVEL"           yaml:"level"           toml:"level"`
+	AssetSkipFast bool   `env:"LOG_ASSET_SKIP_FAST" yaml:"asset_skip_fast" toml:"asset_skip_fast"`
+	AssetSampleN  int    `env:"LOG_ASSET_SAMPLE_N"  yaml:"asset_sample_n"  toml:"asset_sample_n"`
+	TwoXXSampleN  int    `env:"LOG_2XX_SAMPLE_N"    yaml:"2xx_sample_n"    toml:"2xx_sample_n"`
+	UARefMaxLen   int    `env:"LOG_UA_REF_MAXLEN"   yaml:"ua_ref_maxlen"   toml:"ua_ref_maxlen"`
+}
+
+type Metrics struct {
+	Addr  string `env:"METRICS_ADDR"  yaml:"addr"  toml:"addr"`
+	Token string `env:"METRICS_TOKEN" yaml:"token" toml:"token" secret:"true"`
+}
+
+type APITokens struct {
+	RatePerMin int `env:"API_TOKEN_RATE_PER_MIN" yaml:"rate_per_min" toml:"rate_per_min"`
+}
+
+type Affiliation struct {
+	RefreshInterval Duration `env:"AFFILIATION_REFRESH_INTERVAL" yaml:"refresh_interval" toml:"refresh_interval"`
+}
+
+type Ready struct {
+	RequireServiceToken bool `env:"READY_REQUIRE_SERVICE_TOKEN" yaml:"require_service_token" toml:"require_service_token"`
+}
+
+// Backup: JSON-Archive der Fachtabellen; Interval 0 = kein automatisches Backup, Keep 0 = alle behalten.
+type Backup struct {
+	Dir      string   `env:"BACKUP_DIR"      yaml:"dir"      toml:"dir"`
+	Interval Duration `env:"BACKUP_INTERVAL" yaml:"interval" toml:"interval"`
+	Keep     int      `env:"BACKUP_KEEP"     yaml:"keep"     toml:"keep"`
+}
+
+// Frontend: Dir leer = ins Binary eingebettete Dateien; gesetzt = live von der Platte lesen,
+// ohne Caching (nur für die Entwicklung).
+type Frontend struct {
+	Dir string `env:"FRONTEND_DIR" yaml:"dir" toml:"dir"`
+}
+
+// Middleware: Stufen der globalen Middleware-Kette einzeln abschaltbar (z.B. Compress hinter
+// einem Proxy, der selbst komprimiert). Reihenfolge fest, siehe middleware.Pipeline.
+type Middleware struct {
+	Recover         bool `env:"MIDDLEWARE_RECOVER"          yaml:"recover"          toml:"recover"`
+	RequestID       bool `env:"MIDDLEWARE_REQUEST_ID"       yaml:"request_id"       toml:"request_id"`
+	RealIP          bool `env:"MIDDLEWARE_REAL_IP"          yaml:"real_ip"          toml:"real_ip"`
+	Logging         bool `env:"MIDDLEWARE_LOGGING"          yaml:"logging"          toml:"logging"`
+	SecurityHeaders bool `env:"MIDDLEWARE_SECURITY_HEADERS" yaml:"security_headers" toml:"security_headers"`
+	CORS            bool `env:"MIDDLEWARE_CORS"             yaml:"cors"             toml:"cors"`
+	RateLimit       bool `env:"MIDDLEWARE_RATE_LIMIT"       yaml:"rate_limit"       toml:"rate_limit"`
+	Compress        bool `env:"MIDDLEWARE_COMPRESS"         yaml:"compress"         toml:"compress"`
+}
+
+// Security: Response-Header aller Antworten. CSP muss zum Frontend passen (ES-Module von 'self',
+// Font Awesome von cdnjs, Portraits von images.evetech.net); leer = Header weglassen.
+// HSTS nur mit APP_ENV=production (dort auch Secure-Cookies); HSTSMaxAge 0 = aus.
+type Security struct {
+	CSP            string   `env:"SECURITY_CSP"             yaml:"csp"             toml:"csp"`
+	FrameOptions   string   `env:"SECURITY_FRAME_OPTIONS"   yaml:"frame_options"   toml:"frame_options"`
+	ReferrerPolicy string   `env:"SECURITY_REFERRER_POLICY" yaml:"referrer_policy" toml:"referrer_policy"`
+	HSTSMaxAge     Duration `env:"SECURITY_HSTS_MAX_AGE"    yaml:"hsts_max_age"    toml:"hsts_max_age"`
+}
+
+// DefaultCSP: passend zu frontend/ (keine Inline-Skripte; Inline-Styles kommen noch vor).
+const DefaultCSP = "default-src 'self'; script-src 'self'; " +
+	"style-src 'self' 'unsafe-inline' https://cdnjs.cloudflare.com; font-src 'self' https://cdnjs.cloudflare.com; " +
+	"img-src 'self' data: https://images.evetech.net; connect-src 'self'; object-src 'none'; " +
+	"base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
+// CORS für /app/ (Bots, Spreadsheets). AllowedOrigins leer = keine Cross-Origin-Zugriffe, "*" = alle.
+// Ohne Credentials: Integrationen authentifizieren sich per API-Token (Authorization: Bearer).
+type CORS struct {
+	AllowedOrigins []string `env:"CORS_ALLOWED_ORIGINS" yaml:"allowed_origins" toml:"allowed_origins"`
+	MaxAge         Duration `env:"CORS_MAX_AGE"         yaml:"max_age"         toml:"max_age"`
+}
+
+// Default: Werte ohne jede Konfiguration (entspricht dem bisherigen Verhalten).
+func Default() Config {
+	return Config{
+		App: App{Env: "development", Port: 8080, LogFile: "app.log"},
+		Database: Database{
+			QueryTimeout:       Duration(10 * time.Second),
+			SlowQueryThreshold: Duration(500 * time.Millisecond),
+		},
+		OAuth: OAuth{
+			RedirectURL: "http://localhost:8080/app/callback",
+			AuthURL:     "https://login.eveonline.com/v2/oauth/authorize",
+			TokenURL:    "https://login.eveonline.com/v2/oauth/token",
+		},
+		ESI:     ESI{BaseURL: "https://esi.evetech.net"},
+		Express: Express{Enabled: true, TargetType: "corporation"},
+		Access:  Access{Mode: "open", DenyAction: "reject", AllowAnonymousRoutes: true},
+		HTTP: HTTP{
+			ReadTimeout:       Duration(15 * time.Second),
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			WriteTimeout:      Duration(30 * time.Second),
+			IdleTimeout:       Duration(120 * time.Second),
+			ShutdownTimeout:   Duration(25 * time.Second),
+		},
+		RateLimit: RateLimit{
+			Store: "memory", Default: "300/1m", Login: "20/1m", Mail: "5/10m", Express: "3/10m", Quote: "60/1m",
+		},
+		Log:         Log{Level: "info", AssetSkipFast: true, UARefMaxLen: 120},
+		APITokens:   APITokens{RatePerMin: 60},
+		Affiliation: Affiliation{RefreshInterval: Duration(6 * time.Hour)},
+		Backup:      Backup{Dir: "backups", Keep: 14},
+		Middleware: Middleware{
+			Recover: true, RequestID: true, RealIP: true, Logging: true,
+			SecurityHeaders: true, CORS: true, RateLimit: true, Compress: true,
+		},
+		Security: Security{
+			CSP:            DefaultCSP,
+			FrameOptions:   "DENY",
+			ReferrerPolicy: "strict-origin-when-cross-origin",
+			HSTSMaxAge:     Duration(180 * 24 * time.Hour),
+		},
+		CORS: CORS{MaxAge: Duration(10 * time.Minute)},
+	}
+}
+
+// Load baut die Konfiguration: Defaults → Datei (path bzw. CONFIG_FILE, .yaml/.yml/.toml) →
+// .env (optional, überschreibt keine gesetzten Variablen) → Umgebung. Danach Validate.
+func Load(path string) (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf(".env: %w", err)
+	}
+	cfg := Default()
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := loadFile(&cfg, path); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	case ".toml":
+		if err := toml.NewDecoder(bytes.NewReader(b)).DisallowUnknownFields().Decode(cfg); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported format (use .yaml, .yml or .toml)", path)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// applyEnv überschreibt alle Felder mit env-Tag, deren Variable gesetzt ist.
+func applyEnv(cfg *Config) error {
+	return walk(reflect.ValueOf(cfg).Elem(), func(f reflect.StructField, v reflect.Value) error {
+		raw, ok := os.LookupEnv(f.Tag.Get("env"))
+		if !ok {
+			return nil
+		}
+		if err := setFromString(v, strings.TrimSpace(raw)); err != nil {
+			return fmt.Errorf("%s: %w", f.Tag.Get("env"), err)
+		}
+		return nil
+	})
+}
+
+// walk besucht rekursiv alle Blattfelder mit env-Tag.
+func walk(v reflect.Value, fn func(reflect.StructField, reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if f.Tag.Get("env") == "" {
+			if fv.Kind() == reflect.Struct {
+				if err := walk(fv, fn); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := fn(f, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func setFromString(v reflect.Value, s string) error {
+	if v.Addr().Type().Implements(textUnmarshaler) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		if s == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		if s == "" {
+			v.SetInt(0)
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetInt(n)
+	case reflect.Slice:
+		parts := splitList(s)
+		out := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			if err := setFromString(out.Index(i), p); err != nil {
+				return err
+			}
+		}
+		v.Set(out)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// splitList: kommagetrennt, leere Einträge fallen weg.
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Redacted liefert die effektive Konfiguration als ENV-Zeilen, Geheimnisse maskiert.
+func (c *Config) Redacted() string {
+	var lines []string
+	_ = walk(reflect.ValueOf(c).Elem(), func(f reflect.StructField, v reflect.Value) error {
+		lines = append(lines, f.Tag.Get("env")+"="+redact(f.Tag.Get("secret"), format(v)))
+		return nil
+	})
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func format(v reflect.Value) string {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	if v.Kind() == reflect.Slice {
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func redact(kind, s string) string {
+	if s == "" || kind == "" {
+		return s
+	}
+	if kind == "url" {
+		if u, err := url.Parse(s); err == nil && u.User != nil {
+			if _, has := u.User.Password(); has {
+				u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			}
+			return u.String()
+		}
+		return s
+	}
+	return "<redacted>"
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate prüft alle Werte und meldet sämtliche Fehler auf einmal.
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, a ...any) { errs = append(errs, fmt.Errorf(format, a...)) }
+
+	if c.Database.URL == "" {
+		add("DATABASE_URL is required")
+	}
+	if c.OAuth.ClientID == "" || c.OAuth.ClientSecret == "" {
+		add("OAUTH_CLIENT_ID and OAUTH_CLIENT_SECRET are required")
+	}
+	for name, v := range map[string]string{
+		"OAUTH_AUTH_URL": c.OAuth.AuthURL, "OAUTH_TOKEN_URL": c.OAuth.TokenURL, "ESI_BASE_URL": c.ESI.BaseURL,
+	} {
+		if u, err := url.Parse(v); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("%s must be an absolute http(s) URL, got %q", name, v)
+		}
+	}
+	c.ESI.BaseURL = strings.TrimRight(c.ESI.BaseURL, "/")
+	if c.App.Port <= 0 || c.App.Port > 65535 {
+		add("APP_PORT: invalid port %d", c.App.Port)
+	}
+
+	if c.Express.Enabled {
+		if c.Express.SenderCharID <= 0 {
+			add("EXPRESS_SENDER_CHAR_ID is required (or EXPRESS_ENABLED=false)")
+		}
+		if c.Express.TargetID <= 0 {
+			add("EXPRESS_TARGET_CORP_ID is required (or EXPRESS_ENABLED=false)")
+		}
+		if c.Express.TargetType != "corporation" && c.Express.TargetType != "alliance" {
+			add("EXPRESS_TARGET_TYPE must be corporation or alliance, got %q", c.Express.TargetType)
+		}
+	}
+
+	c.Access.Mode = strings.ToLower(c.Access.Mode)
+	c.Access.DenyAction = strings.ToLower(c.Access.DenyAction)
+	if c.Access.Mode != "open" && c.Access.Mode != "allowlist" {
+		add("ACCESS_MODE must be open or allowlist, got %q", c.Access.Mode)
+	}
+	if c.Access.DenyAction != "reject" && c.Access.DenyAction != "quarantine" {
+		add("ACCESS_DENY_ACTION must be reject or quarantine, got %q", c.Access.DenyAction)
+	}
+
+	for _, id := range append(append([]int64{}, c.Access.AllowedCorps...), c.Access.AllowedAlliances...) {
+		if id <= 0 {
+			add("ACCESS_ALLOWED_CORPS/ACCESS_ALLOWED_ALLIANCES: invalid id %d", id)
+		}
+	}
+	if _, err := c.HTTP.TrustedProxyPrefixes(); err != nil {
+		errs = append(errs, err)
+	}
+	for name, d := range map[string]Duration{
+		"HTTP_READ_TIMEOUT": c.HTTP.ReadTimeout, "HTTP_READ_HEADER_TIMEOUT": c.HTTP.ReadHeaderTimeout,
+		"HTTP_WRITE_TIMEOUT": c.HTTP.WriteTimeout, "HTTP_IDLE_TIMEOUT": c.HTTP.IdleTimeout,
+		"HTTP_SHUTDOWN_TIMEOUT": c.HTTP.ShutdownTimeout, "AFFILIATION_REFRESH_INTERVAL": c.Affiliation.RefreshInterval,
+		"BACKUP_INTERVAL": c.Backup.Interval, "DB_QUERY_TIMEOUT": c.Database.QueryTimeout,
+		"DB_SLOW_QUERY_THRESHOLD": c.Database.SlowQueryThreshold, "SECURITY_HSTS_MAX_AGE": c.Security.HSTSMaxAge,
+		"CORS_MAX_AGE": c.CORS.MaxAge,
+	} {
+		if d < 0 {
+			add("%s must not be negative", name)
+		}
+	}
+
+	c.RateLimit.Store = strings.ToLower(c.RateLimit.Store)
+	switch c.RateLimit.Store {
+	case "memory", "postgres":
+	case "pg":
+		c.RateLimit.Store = "postgres"
+	default:
+		add("RATE_LIMIT_STORE must be memory or postgres, got %q", c.RateLimit.Store)
+	}
+	for name, spec := range c.RateLimit.Policies() {
+		if _, _, err := ParseRateSpec(spec); err != nil {
+			add("RATE_LIMIT_%s: %v", strings.ToUpper(name), err)
+		}
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		add("LOG_LEVEL must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	if c.APITokens.RatePerMin <= 0 {
+		add("API_TOKEN_RATE_PER_MIN must be > 0")
+	}
+	if c.Backup.Dir == "" {
+		add("BACKUP_DIR must not be empty")
+	}
+	if c.Backup.Keep < 0 {
+		add("BACKUP_KEEP must not be negative")
+	}
+	c.Security.FrameOptions = strings.ToUpper(c.Security.FrameOptions)
+	switch c.Security.FrameOptions {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		add("SECURITY_FRAME_OPTIONS must be DENY, SAMEORIGIN or empty, got %q", c.Security.FrameOptions)
+	}
+	for i, o := range c.CORS.AllowedOrigins {
+		if o == "*" {
+			continue
+		}
+		// Origin = scheme://host[:port], ohne Pfad und ohne Slash am Ende
+		u, err := url.Parse(o)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") ||
+			u.RawQuery != "" || u.User != nil {
+			add("CORS_ALLOWED_ORIGINS: invalid origin %q (expected scheme://host[:port] or *)", o)
+			continue
+		}
+		c.CORS.AllowedOrigins[i] = strings.ToLower(u.Scheme + "://" + u.Host)
+	}
+	if c.Frontend.Dir != "" {
+		if fi, err := os.Stat(c.Frontend.Dir); err != nil || !fi.IsDir() {
+			add("FRONTEND_DIR: %q is not a directory", c.Frontend.Dir)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ParseRateSpec: "<n>/<dauer>" (z.B. 5/10m) oder ""/off/0 = aus (limit 0).
+func ParseRateSpec(v string) (int, time.Duration, error) {
+	v = strings.TrimSpace(v)
+	if v == "" || v == "off" || v == "0" {
+		return 0, 0, nil
+	}
+	n, d, ok := strings.Cut(v, "/")
+	limit, err := strconv.Atoi(strings.TrimSpace(n))
+	if !ok || err != nil || limit < 0 {
+		return 0, 0, fmt.Errorf("invalid value %q (expected <n>/<duration>)", v)
+	}
+	window, err := time.ParseDuration(strings.TrimSpace(d))
+	if err != nil || window < time.Second {
+		return 0, 0, fmt.Errorf("invalid window %q", d)
+	}
+	return limit, window, nil
+}
+
+// TrustedProxyPrefixes: CIDRs oder einzelne IPs → Prefixe.
+func (h HTTP) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, s := range h.TrustedProxies {
+		if strings.Contains(s, "/") {
+			p, err := netip.ParsePrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES: invalid CIDR %q", s)
+			}
+			out = append(out, p.Masked())
+			continue
+		}
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES: invalid IP %q", s)
+		}
+		a = a.Unmap()
+		out = append(out, netip.PrefixFrom(a, a.BitLen()))
+	}
+	return out, nil
+}
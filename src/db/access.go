@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"speedliner-server/src/utils/structs"
+)
+
+func RecordLoginDenial(ctx context.Context, d structs.LoginDenial) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := Pool.Exec(ctx, `
+		INSERT INTO login_denials (char_id, char_name, corp_id, alliance_id, action, reason, ip, user_agent)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
+		d.CharID, d.CharName, d.CorpID, d.AllianceID, d.Action, d.Reason, d.IP, d.UserAgent)
+	if err != nil {
+		return fmt.Errorf("RecordLoginDenial error: %w", err)
+	}
+	return nil
+}
+
+func ListLoginDenials(ctx context.Context, onlyPending bool, limit int) ([]structs.LoginDenial, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := Pool.Query(ctx, `
+		SELECT id, char_id, char_name, corp_id, alliance_id, action, reason, ip, user_agent,
+		       created_at, reviewed_at, reviewed_by
+		FROM login_denials
+		WHERE NOT $1 OR reviewed_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT $2`, onlyPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListLoginDenials error: %w", err)
+	}
+	defer rows.Close()
+
+	var list []structs.LoginDenial
+	for rows.Next() {
+		var d structs.LoginDenial
+		if err := rows.Scan(&d.ID, &d.CharID, &d.CharName, &d.CorpID, &d.AllianceID, &d.Action,
+			&d.Reason, &d.IP, &d.UserAgent, &d.CreatedAt, &d.ReviewedAt, &d.ReviewedBy); err != nil {
+			return nil, fmt.Errorf("ListLoginDenials scan error: %w", err)
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+func MarkLoginDenialReviewed(ctx context.Context, id, reviewedBy int64) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := Pool.Exec(ctx, `
+		UPDATE login_denials SET reviewed_at=now(), reviewed_by=$2 WHERE id=$1`, id, reviewedBy)
+	return err
+}
+
+// SetUserQuarantined setzt/entfernt die Quarantäne; changed=false, wenn der Status schon so war.
+func SetUserQuarantined(ctx context.Context, charID int64, quarantined bool) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tag, err := Pool.Exec(ctx, `
+		UPDATE users SET quarantined=$2 WHERE char_id=$1 AND quarantined <> $2`, charID, quarantined)
+	if err != nil {
+		return false, fmt.Errorf("SetUserQuarantined error: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReleaseUser hebt die Quarantäne auf und nimmt den Char von der Allow-List-Prüfung aus.
+func ReleaseUser(ctx context.Context, charID int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tag, err := Pool.Exec(ctx, `
+		UPDATE users SET quarantined=false, access_exempt=true WHERE char_id=$1`, charID)
+	if err != nil {
+		return false, fmt.Errorf("ReleaseUser error: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// IsUserAccessExempt: true, wenn ein Admin den Char explizit freigegeben hat.
+func IsUserAccessExempt(ctx context.Context, charID int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var ok bool
+	err := Pool.QueryRow(ctx,
+		`SELECT COALESCE((SELECT access_exempt FROM users WHERE char_id=$1), false)`, charID).Scan(&ok)
+	return ok, err
+}
+
+func IsUserQuarantined(ctx context.Context, charID int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var q bool
+	err := Pool.QueryRow(ctx,
+		`SELECT COALESCE((SELECT quarantined FROM users WHERE char_id=$1), false)`, charID).Scan(&q)
+	return q, err
+}
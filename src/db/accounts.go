@@ -0,0 +1,218 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"speedliner-server/src/utils/structs"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrCharLinkedElsewhere = errors.New("character already belongs to another account")
+	ErrCharHasAlts         = errors.New("character is main of an account with linked alts")
+	ErrCannotUnlinkMain    = errors.New("main character cannot be unlinked")
+	ErrCharNotInAccount    = errors.New("character not in account")
+)
+
+// ensureAccountSQL: Char ohne Account bekommt einen eigenen (Char = Main).
+const ensureAccountSQL = `
+	WITH a AS (
+		INSERT INTO accounts (main_char_id)
+		SELECT $1 WHERE EXISTS (SELECT 1 FROM users WHERE char_id = $1 AND account_id IS NULL)
+		ON CONFLICT (main_char_id) DO UPDATE SET main_char_id = EXCLUDED.main_char_id
+		RETURNING id
+	)
+	UPDATE users SET account_id = (SELECT id FROM a)
+	WHERE char_id = $1 AND account_id IS NULL AND EXISTS (SELECT 1 FROM a)`
+
+// MainCharID liefert den Main-Char des Accounts (ohne Account: den Char selbst).
+func MainCharID(ctx context.Context, charID int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var main int64
+	err := Pool.QueryRow(ctx, `
+		SELECT COALESCE(a.main_char_id, u.char_id)
+		FROM users u
+		LEFT JOIN accounts a ON a.id = u.account_id
+		WHERE u.char_id = $1`, charID).Scan(&main)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return charID, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("MainCharID error: %w", err)
+	}
+	return main, nil
+}
+
+// GetAccountForChar liefert den Account eines Chars samt aller Chars (Main zuerst).
+func GetAccountForChar(ctx context.Context, charID int64) (*structs.Account, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var acc structs.Account
+	err := Pool.QueryRow(ctx, `
+		SELECT a.id, a.main_char_id
+		FROM users u
+		JOIN accounts a ON a.id = u.account_id
+		WHERE u.char_id = $1`, charID).Scan(&acc.ID, &acc.MainCharID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetAccountForChar error: %w", err)
+	}
+
+	rows, err := Pool.Query(ctx, `
+		SELECT u.char_id, u.name, c.name
+		FROM users u
+		LEFT JOIN corps c ON c.corp_id = u.corp_id
+		WHERE u.account_id = $1
+		ORDER BY (u.char_id = $2) DESC, u.name`, acc.ID, acc.MainCharID)
+	if err != nil {
+		return nil, fmt.Errorf("GetAccountForChar chars error: %w", err)
+	}
+	defer rows.Close()
+
+	acc.ActiveCharID = charID
+	acc.Characters = []structs.AccountCharacter{}
+	for rows.Next() {
+		var c structs.AccountCharacter
+		if err := rows.Scan(&c.CharID, &c.Name, &c.CorpName); err != nil {
+			return nil, fmt.Errorf("GetAccountForChar scan error: %w", err)
+		}
+		c.IsMain = c.CharID == acc.MainCharID
+		acc.Characters = append(acc.Characters, c)
+	}
+	return &acc, rows.Err()
+}
+
+// SameAccount: true, wenn beide Chars zum selben Account gehören.
+func SameAccount(ctx context.Context, charA, charB int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var ok bool
+	err := Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM users a JOIN users b ON b.account_id = a.account_id
+			WHERE a.char_id = $1 AND b.char_id = $2 AND a.account_id IS NOT NULL
+		)`, charA, charB).Scan(&ok)
+	return ok, err
+}
+
+// LinkCharacter hängt altID als Alt an den Account von charID. Der Alt verliert seinen
+// eigenen (leeren) Account samt Rollen und erbt die Rechte des Accounts.
+func LinkCharacter(ctx context.Context, charID, altID int64) (err error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	var accountID int64
+	if err = tx.QueryRow(ctx, `SELECT account_id FROM users WHERE char_id=$1 AND account_id IS NOT NULL`, charID).
+		Scan(&accountID); err != nil {
+		return fmt.Errorf("LinkCharacter account error: %w", err)
+	}
+
+	var altAccount *int64
+	var altIsMain bool
+	var altMembers int
+	if err = tx.QueryRow(ctx, `
+		SELECT u.account_id,
+		       COALESCE(a.main_char_id = u.char_id, false),
+		       (SELECT count(*) FROM users m WHERE m.account_id = u.account_id)
+		FROM users u
+		LEFT JOIN accounts a ON a.id = u.account_id
+		WHERE u.char_id = $1`, altID).Scan(&altAccount, &altIsMain, &altMembers); err != nil {
+		return fmt.Errorf("LinkCharacter alt error: %w", err)
+	}
+	if altAccount != nil && *altAccount == accountID {
+		return nil // schon verknüpft
+	}
+	if altAccount != nil && !altIsMain {
+		return ErrCharLinkedElsewhere
+	}
+	if altIsMain && altMembers > 1 {
+		return ErrCharHasAlts
+	}
+
+	var oldRoles []string
+	if err = tx.QueryRow(ctx, `
+		SELECT COALESCE(array_agg(role ORDER BY role), '{}') FROM user_roles WHERE char_id=$1`, altID).
+		Scan(&oldRoles); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, `UPDATE users SET account_id=$2 WHERE char_id=$1`, altID, accountID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM accounts WHERE main_char_id=$1`, altID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM user_roles WHERE char_id=$1`, altID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, `
+		UPDATE users SET role = (SELECT role FROM users WHERE char_id=$2) WHERE char_id=$1`, altID, charID); err != nil {
+		return err
+	}
+	if len(oldRoles) > 0 {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO role_changes (char_id, old_roles, new_roles, source, reason, changed_by)
+			VALUES ($1,$2,'{}',$3,$4,$5)`, altID, oldRoles, structs.RoleSourceManual,
+			fmt.Sprintf("linked as alt to account %d", accountID), charID)
+	}
+	return err
+}
+
+// UnlinkCharacter löst einen Alt aus dem Account; er bekommt einen eigenen Account mit Standardrolle.
+func UnlinkCharacter(ctx context.Context, charID, altID int64) (err error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	var altIsMain bool
+	if err = tx.QueryRow(ctx, `
+		SELECT COALESCE(acc.main_char_id = b.char_id, false)
+		FROM users a
+		JOIN users b ON b.account_id = a.account_id
+		LEFT JOIN accounts acc ON acc.id = b.account_id
+		WHERE a.char_id = $1 AND b.char_id = $2`, charID, altID).Scan(&altIsMain); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCharNotInAccount
+		}
+		return err
+	}
+	if altIsMain {
+		return ErrCannotUnlinkMain
+	}
+
+	if _, err = tx.Exec(ctx, `UPDATE users SET account_id=NULL, role='user' WHERE char_id=$1`, altID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, ensureAccountSQL, altID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO user_roles (char_id, role, source, reason)
+		VALUES ($1, 'user', 'default', 'unlinked from account') ON CONFLICT DO NOTHING`, altID)
+	return err
+}
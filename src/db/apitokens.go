@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"speedliner-server/src/utils/structs"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const apiTokenCols = `id::text, char_id, name, prefix, scopes, rate_per_min, created_at, last_used_at, expires_at, revoked_at`
+
+func scanAPIToken(row pgx.Row) (structs.APIToken, error) {
+	var t structs.APIToken
+	err := row.Scan(&t.ID, &t.CharID, &t.Name, &t.Prefix, &t.Scopes, &t.RatePerMin,
+		&t.CreatedAt, &t.LastUsedAt, &t.ExpiresAt, &t.RevokedAt)
+	return t, err
+}
+
+func InsertAPIToken(ctx context.Context, t structs.APIToken, hash []byte) (structs.APIToken, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	out, err := scanAPIToken(Pool.QueryRow(ctx, `
+		INSERT INTO api_tokens (char_id, name, prefix, token_hash, scopes, rate_per_min, expires_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING `+apiTokenCols,
+		t.CharID, t.Name, t.Prefix, hash, t.Scopes, t.RatePerMin, t.ExpiresAt))
+	if err != nil {
+		return t, fmt.Errorf("InsertAPIToken error: %w", err)
+	}
+	return out, nil
+}
+
+func ListAPITokens(ctx context.Context, charID int64) ([]structs.APIToken, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx, `
+		SELECT `+apiTokenCols+`
+		FROM api_tokens
+		WHERE char_id = $1
+		ORDER BY created_at DESC`, charID)
+	if err != nil {
+		return nil, fmt.Errorf("ListAPITokens error: %w", err)
+	}
+	defer rows.Close()
+
+	list := []structs.APIToken{}
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListAPITokens scan error: %w", err)
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// RevokeAPIToken widerruft einen Token des Chars. false = nicht gefunden.
+func RevokeAPIToken(ctx context.Context, id string, charID int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tag, err := Pool.Exec(ctx, `
+		UPDATE api_tokens SET revoked_at = now()
+		WHERE id::text = $1 AND char_id = $2 AND revoked_at IS NULL`, id, charID)
+	if err != nil {
+		return false, fmt.Errorf("RevokeAPIToken error: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetActiveAPIToken sucht einen gültigen (nicht widerrufenen, nicht abgelaufenen) Token. nil = keiner.
+func GetActiveAPIToken(ctx context.Context, hash []byte) (*structs.APIToken, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	t, err := scanAPIToken(Pool.QueryRow(ctx, `
+		SELECT `+apiTokenCols+`
+		FROM api_tokens
+		WHERE token_hash = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > now())`, hash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetActiveAPIToken error: %w", err)
+	}
+	return &t, nil
+}
+
+func TouchAPIToken(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := Pool.Exec(ctx, `UPDATE api_tokens SET last_used_at = now() WHERE id::text = $1`, id)
+	return err
+}
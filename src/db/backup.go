@@ -0,0 +1,350 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"speedliner-server/src/utils/structs"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// backupTable: gesicherte Tabelle. Reihenfolge = Wiederherstellungsreihenfolge (Fremdschlüssel zuerst).
+type backupTable struct {
+	name     string
+	optional bool              // nur sichern, wenn vorhanden
+	deferred []string          // Spalten mit zyklischem FK, werden erst nach allen Tabellen gesetzt
+	fix      map[string]string // Spalte -> Ausdruck beim Einspielen (Alt-Archive an neuere Constraints anpassen)
+	unique   *uniqueKey        // weiterer Unique-Schlüssel neben dem PK
+}
+
+// uniqueKey: je Schlüssel (NULL = NULL) gewinnt im Archiv die erste Zeile nach order; DB-Zeilen mit
+// demselben Schlüssel, aber anderem PK, weichen der Archivzeile.
+type uniqueKey struct {
+	cols  []string
+	order string
+}
+
+var backupTables = []backupTable{
+	{name: "alliances"},
+	{name: "corps"},
+	{name: "users", deferred: []string{"account_id"}}, // accounts.main_char_id → users
+	{name: "accounts"},
+	{name: "roles"},
+	{name: "role_permissions"},
+	{name: "user_roles"},
+	{name: "role_rules"},
+	{name: "routes"},
+	{name: "route_visibility"},
+	{name: "route_rates", unique: &uniqueKey{ // route_rates_target_uq (Schema 2), jüngste Rate gewinnt
+		cols: []string{"route_id", "corp_id", "alliance_id"}, order: "created_at DESC, id::text DESC"}},
+	{name: "promo_codes"},
+	{name: "promo_redemptions"},
+	{name: "provider_profiles", fix: map[string]string{ // provider_profiles_duty_chk (Schema 2)
+		"on_duty": "on_duty AND max_m3 > 0"}},
+	{name: "provider_routes"},
+	{name: "orders", optional: true},
+}
+
+// backupExcluded: Zugangsdaten landen nie in einem Archiv und werden nie eingespielt.
+var backupExcluded = map[string]bool{"oauth_tokens": true, "api_tokens": true}
+
+var ErrBackupIncompatible = errors.New("backup incompatible")
+
+type tableMeta struct {
+	cols   []string
+	pk     []string
+	serial map[string]string // Spalte → Sequenz
+}
+
+func loadTableMeta(ctx context.Context, tx pgx.Tx, table string) (*tableMeta, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT
+		    a.attname,
+		    i.indrelid IS NOT NULL,
+		    COALESCE(pg_get_serial_sequence($1, a.attname::text), '')
+		FROM
+		    pg_attribute a
+		LEFT JOIN
+		    pg_index i ON i.indrelid = a.attrelid AND i.indisprimary AND a.attnum = ANY(i.indkey)
+		WHERE
+		    a.attrelid = to_regclass($1)
+		  AND a.attnum > 0
+		  AND NOT a.attisdropped
+		  AND a.attgenerated = ''
+		ORDER BY
+		    a.attnum`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	m := &tableMeta{serial: map[string]string{}}
+	for rows.Next() {
+		var col, seq string
+		var isPK bool
+		if err := rows.Scan(&col, &isPK, &seq); err != nil {
+			return nil, err
+		}
+		m.cols = append(m.cols, col)
+		if isPK {
+			m.pk = append(m.pk, col)
+		}
+		if seq != "" {
+			m.serial[col] = seq
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(m.cols) == 0 {
+		return nil, nil
+	}
+	if len(m.pk) == 0 {
+		return nil, fmt.Errorf("table %s has no primary key", table)
+	}
+	return m, nil
+}
+
+func quoteIdents(names []string, prefix string) string {
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = prefix + pgx.Identifier{n}.Sanitize()
+	}
+	return strings.Join(out, ", ")
+}
+
+// DumpBackup exportiert alle Fachtabellen konsistent (ein Snapshot) als Archiv.
+func DumpBackup(ctx context.Context) (*structs.BackupArchive, error) {
+	tx, err := Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("DumpBackup error: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	a := &structs.BackupArchive{
+		Format:  structs.BackupFormat,
+		Version: structs.BackupFormatVersion,
+	}
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(max(version), 0), now() FROM schema_migrations`).
+		Scan(&a.SchemaVersion, &a.CreatedAt); err != nil {
+		return nil, fmt.Errorf("DumpBackup error: %w", err)
+	}
+	for _, t := range backupTables {
+		meta, err := loadTableMeta(ctx, tx, t.name)
+		if err != nil {
+			return nil, fmt.Errorf("DumpBackup error (%s): %w", t.name, err)
+		}
+		if meta == nil {
+			if t.optional {
+				continue
+			}
+			return nil, fmt.Errorf("DumpBackup error: table %s missing", t.name)
+		}
+		var rows string
+		if err := tx.QueryRow(ctx, fmt.Sprintf(
+			`SELECT COALESCE(json_agg(row_to_json(t) ORDER BY %s), '[]'::json)::text FROM %s t`,
+			quoteIdents(meta.pk, "t."), pgx.Identifier{t.name}.Sanitize(),
+		)).Scan(&rows); err != nil {
+			return nil, fmt.Errorf("DumpBackup error (%s): %w", t.name, err)
+		}
+		a.Tables = append(a.Tables, structs.BackupTable{Name: t.name, Rows: []byte(rows)})
+	}
+	return a, nil
+}
+
+// CheckBackup prüft Format, Archiv-Version, Tabellen und ob die Schema-Version zu Binary und DB passt.
+// Archive älterer Schema-Versionen ab minRestoreSchemaVersion werden beim Einspielen angepasst.
+func CheckBackup(ctx context.Context, a *structs.BackupArchive) error {
+	if a.Format != structs.BackupFormat {
+		return fmt.Errorf("%w: unknown format %q", ErrBackupIncompatible, a.Format)
+	}
+	if a.Version < 1 || a.Version > structs.BackupFormatVersion {
+		return fmt.Errorf("%w: archive version %d not supported (max %d)", ErrBackupIncompatible, a.Version, structs.BackupFormatVersion)
+	}
+	if a.SchemaVersion < minRestoreSchemaVersion || a.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("%w: archive schema version %d, this build supports %d..%d",
+			ErrBackupIncompatible, a.SchemaVersion, minRestoreSchemaVersion, SchemaVersion)
+	}
+	applied, err := AppliedSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if applied != SchemaVersion {
+		return fmt.Errorf("%w: database is at schema version %d, this build expects %d", ErrBackupIncompatible, applied, SchemaVersion)
+	}
+	known := map[string]bool{}
+	for _, t := range backupTables {
+		known[t.name] = true
+	}
+	seen := map[string]bool{}
+	for _, t := range a.Tables {
+		switch {
+		case backupExcluded[t.Name]:
+			return fmt.Errorf("%w: archive contains excluded table %s", ErrBackupIncompatible, t.Name)
+		case !known[t.Name]:
+			return fmt.Errorf("%w: unknown table %s", ErrBackupIncompatible, t.Name)
+		case seen[t.Name]:
+			return fmt.Errorf("%w: table %s listed twice", ErrBackupIncompatible, t.Name)
+		}
+		seen[t.Name] = true
+	}
+	return nil
+}
+
+// RestoreBackup spielt das Archiv in einer Transaktion ein: Zeilen werden per Primärschlüssel
+// eingefügt bzw. überschrieben. prune löscht zusätzlich Zeilen der gesicherten Tabellen, die im
+// Archiv fehlen (kaskadiert z.B. auf Tokens/Logins gelöschter Chars). Tabellen außerhalb des
+// Archivs (oauth_tokens, api_tokens, Logs) bleiben sonst unverändert.
+func RestoreBackup(ctx context.Context, a *structs.BackupArchive, prune bool) (res structs.RestoreResult, err error) {
+	if err = CheckBackup(ctx, a); err != nil {
+		return res, err
+	}
+	data := map[string][]byte{}
+	for _, t := range a.Tables {
+		data[t.Name] = t.Rows
+	}
+
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return res, fmt.Errorf("RestoreBackup error: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	var tables []backupTable
+	metas := map[string]*tableMeta{}
+	for _, t := range backupTables {
+		if _, ok := data[t.name]; !ok {
+			continue
+		}
+		meta, err := loadTableMeta(ctx, tx, t.name)
+		if err != nil {
+			return res, fmt.Errorf("RestoreBackup error (%s): %w", t.name, err)
+		}
+		if meta == nil {
+			return res, fmt.Errorf("%w: table %s does not exist", ErrBackupIncompatible, t.name)
+		}
+		tables = append(tables, t)
+		metas[t.name] = meta
+	}
+
+	if prune {
+		// abhängige Tabellen zuerst
+		for i := len(tables) - 1; i >= 0; i-- {
+			t, meta := tables[i], metas[tables[i].name]
+			ident := pgx.Identifier{t.name}.Sanitize()
+			pk := quoteIdents(meta.pk, "")
+			if _, err = tx.Exec(ctx, fmt.Sprintf(
+				`DELETE FROM %s WHERE (%s) NOT IN (SELECT %s FROM json_populate_recordset(NULL::%s, $1::json))`,
+				ident, pk, pk, ident), string(data[t.name])); err != nil {
+				return res, fmt.Errorf("RestoreBackup error (prune %s): %w", t.name, err)
+			}
+		}
+	}
+
+	for _, t := range tables {
+		meta := metas[t.name]
+		ident := pgx.Identifier{t.name}.Sanitize()
+		src := fmt.Sprintf(`json_populate_recordset(NULL::%s, $1::json)`, ident)
+		if u := t.unique; u != nil {
+			key := quoteIdents(u.cols, "")
+			src = fmt.Sprintf(`(SELECT DISTINCT ON (%s) * FROM %s ORDER BY %s, %s) j`, key, src, key, u.order)
+			var match []string
+			for _, c := range u.cols {
+				q := pgx.Identifier{c}.Sanitize()
+				match = append(match, "t."+q+" IS NOT DISTINCT FROM j."+q)
+			}
+			if _, err = tx.Exec(ctx, fmt.Sprintf(
+				`DELETE FROM %s t USING %s WHERE %s AND (%s) IS DISTINCT FROM (%s)`,
+				ident, src, strings.Join(match, " AND "), quoteIdents(meta.pk, "t."), quoteIdents(meta.pk, "j."),
+			), string(data[t.name])); err != nil {
+				return res, fmt.Errorf("RestoreBackup error (%s unique): %w", t.name, err)
+			}
+		}
+		sel := make([]string, len(meta.cols))
+		var set []string
+		for i, c := range meta.cols {
+			q := pgx.Identifier{c}.Sanitize()
+			sel[i] = q
+			if contains(t.deferred, c) {
+				sel[i] = "NULL"
+				continue
+			}
+			if expr, ok := t.fix[c]; ok {
+				sel[i] = "(" + expr + ")"
+			}
+			if !contains(meta.pk, c) {
+				set = append(set, q+" = EXCLUDED."+q)
+			}
+		}
+		conflict := "DO NOTHING"
+		if len(set) > 0 {
+			conflict = "DO UPDATE SET " + strings.Join(set, ", ")
+		}
+		if _, err = tx.Exec(ctx, fmt.Sprintf(
+			`INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT (%s) %s`,
+			ident, quoteIdents(meta.cols, ""), strings.Join(sel, ", "), src, quoteIdents(meta.pk, ""), conflict,
+		), string(data[t.name])); err != nil {
+			return res, fmt.Errorf("RestoreBackup error (%s): %w", t.name, err)
+		}
+	}
+
+	// zyklische FKs nachziehen, sobald alle Zieltabellen gefüllt sind
+	for _, t := range tables {
+		meta := metas[t.name]
+		for _, c := range t.deferred {
+			if !contains(meta.cols, c) {
+				continue
+			}
+			ident := pgx.Identifier{t.name}.Sanitize()
+			q := pgx.Identifier{c}.Sanitize()
+			var match []string
+			for _, k := range meta.pk {
+				kq := pgx.Identifier{k}.Sanitize()
+				match = append(match, "t."+kq+" = j."+kq)
+			}
+			if _, err = tx.Exec(ctx, fmt.Sprintf(
+				`UPDATE %s t SET %s = j.%s FROM json_populate_recordset(NULL::%s, $1::json) j WHERE %s`,
+				ident, q, q, ident, strings.Join(match, " AND "),
+			), string(data[t.name])); err != nil {
+				return res, fmt.Errorf("RestoreBackup error (%s.%s): %w", t.name, c, err)
+			}
+		}
+	}
+
+	res = structs.RestoreResult{SchemaVersion: a.SchemaVersion, Tables: map[string]int{}, Pruned: prune}
+	for _, t := range tables {
+		meta := metas[t.name]
+		ident := pgx.Identifier{t.name}.Sanitize()
+		// Sequenzen hinter die eingespielten IDs setzen
+		for col, seq := range meta.serial {
+			q := pgx.Identifier{col}.Sanitize()
+			if _, err = tx.Exec(ctx, fmt.Sprintf(
+				`SELECT setval($1::regclass, COALESCE((SELECT max(%s) FROM %s), 0) + 1, false)`, q, ident,
+			), seq); err != nil {
+				return res, fmt.Errorf("RestoreBackup error (%s sequence): %w", t.name, err)
+			}
+		}
+		var n int
+		if err = tx.QueryRow(ctx, `SELECT count(*) FROM `+ident).Scan(&n); err != nil {
+			return res, fmt.Errorf("RestoreBackup error (%s): %w", t.name, err)
+		}
+		res.Tables[t.name] = n
+	}
+	return res, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, it := range list {
+		if it == s {
+			return true
+		}
+	}
+	return false
+}
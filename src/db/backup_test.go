@@ -0,0 +1,63 @@
+package db
+
+import (
+	"errors"
+	"speedliner-server/src/utils/structs"
+	"testing"
+)
+
+func TestRestoreSchemaVersions(t *testing.T) {
+	ctx := requirePostgres(t)
+	for _, v := range []int{minRestoreSchemaVersion - 1, SchemaVersion + 1} {
+		a := &structs.BackupArchive{Format: structs.BackupFormat, Version: structs.BackupFormatVersion, SchemaVersion: v}
+		if err := CheckBackup(ctx, a); !errors.Is(err, ErrBackupIncompatible) {
+			t.Errorf("schema version %d: want ErrBackupIncompatible, got %v", v, err)
+		}
+	}
+}
+
+// Schema-1-Archive kennen route_rates_target_uq und provider_profiles_duty_chk noch nicht.
+func TestRestoreV1Archive(t *testing.T) {
+	ctx := requirePostgres(t)
+	const (
+		charID = 93000002
+		corp   = 98900002
+	)
+	mustExec(t, ctx, `INSERT INTO users (char_id, name) VALUES ($1,'Restore Test') ON CONFLICT DO NOTHING`, charID)
+	t.Cleanup(func() {
+		_, _ = Pool.Exec(ctx, `DELETE FROM route_rates WHERE corp_id = $1`, corp)
+		_, _ = Pool.Exec(ctx, `DELETE FROM users WHERE char_id = $1`, charID)
+	})
+	// bestehende Rate für dasselbe Ziel weicht der Archivzeile
+	mustExec(t, ctx, `INSERT INTO route_rates (corp_id, discount_pct) VALUES ($1, 3)`, corp)
+
+	a := &structs.BackupArchive{
+		Format: structs.BackupFormat, Version: structs.BackupFormatVersion, SchemaVersion: 1,
+		Tables: []structs.BackupTable{
+			{Name: "route_rates", Rows: []byte(`[
+				{"id":"00000000-0000-0000-0000-000000000001","corp_id":98900002,"discount_pct":5,"note":"","created_at":"2024-01-01T00:00:00Z"},
+				{"id":"00000000-0000-0000-0000-000000000002","corp_id":98900002,"discount_pct":10,"note":"","created_at":"2024-02-01T00:00:00Z"}]`)},
+			{Name: "provider_profiles", Rows: []byte(`[
+				{"char_id":93000002,"ship_class":"freighter","max_m3":0,"timezone":"UTC","on_duty":true,"notes":"","updated_at":"2024-01-01T00:00:00Z"}]`)},
+		},
+	}
+	if _, err := RestoreBackup(ctx, a, false); err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+
+	var n int
+	var pct float64
+	if err := Pool.QueryRow(ctx, `SELECT count(*), max(discount_pct) FROM route_rates WHERE corp_id = $1`, corp).Scan(&n, &pct); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || pct != 10 {
+		t.Errorf("after restore: %d rates, discount %v; want 1 rate with 10", n, pct)
+	}
+	var onDuty bool
+	if err := Pool.QueryRow(ctx, `SELECT on_duty FROM provider_profiles WHERE char_id = $1`, charID).Scan(&onDuty); err != nil {
+		t.Fatal(err)
+	}
+	if onDuty {
+		t.Error("profile without capacity restored on duty")
+	}
+}
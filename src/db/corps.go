@@ -1,18 +1,17 @@
 package db
 
-import "context"
+import (
+	"context"
+	"speedliner-server/src/utils/structs"
+)
 
-type CorpOption struct {
-	CorpID int64  `json:"corpId"`
-	Ticker string `json:"ticker"`
-	Name   string `json:"name"`
-}
-
-func SearchCorps(q string, limit int) ([]CorpOption, error) {
+func SearchCorps(ctx context.Context, q string, limit int) ([]structs.CorpOption, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
 	if limit <= 0 || limit > 200 {
 		limit = 50
 	}
-	rows, err := Pool.Query(context.Background(), `
+	rows, err := Pool.Query(ctx, `
 		SELECT corp_id, COALESCE(ticker,''), name
 		FROM corps
 		WHERE $1 = '' OR name ILIKE '%'||$1||'%' OR ticker ILIKE '%'||$1||'%'
@@ -23,9 +22,9 @@ func SearchCorps(q string, limit int) ([]CorpOption, error) {
 	}
 	defer rows.Close()
 
-	var list []CorpOption
+	var list []structs.CorpOption
 	for rows.Next() {
-		var it CorpOption
+		var it structs.CorpOption
 		if err := rows.Scan(&it.CorpID, &it.Ticker, &it.Name); err != nil {
 			return nil, err
 		}
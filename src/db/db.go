@@ -3,20 +3,37 @@ package db
 import (
 	"context"
 	"fmt"
-	"os"
+	"speedliner-server/src/config"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var Pool *pgxpool.Pool
 
-func InitDB() error {
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		return fmt.Errorf("DATABASE_URL environment variable is not set")
+// SchemaVersion bei jeder Schema-Änderung in ensureSchema hochzählen; /app/readyz vergleicht
+// sie mit schema_migrations (z.B. neues Image gegen altes Schema).
+//
+//	2: route_rates_target_uq, provider_profiles_duty_chk
+const SchemaVersion = 2
+
+// minRestoreSchemaVersion: älteste Schema-Version, deren Archive sich noch einspielen lassen.
+// Bis dahin kamen nur Constraints hinzu; backupTables passt die Zeilen beim Einspielen an.
+const minRestoreSchemaVersion = 1
+
+// InitDB verbindet den Pool (DATABASE_URL), setzt Query-Timeout und Slow-Query-Log und legt das Schema an.
+func InitDB(cfg config.Database) error {
+	if cfg.URL == "" {
+		return fmt.Errorf("DATABASE_URL is not set")
+	}
+	poolCfg, err := pgxpool.ParseConfig(cfg.URL)
+	if err != nil {
+		return fmt.Errorf("DB config error: %w", err)
+	}
+	queryTimeout = cfg.QueryTimeout.Std()
+	if cfg.SlowQueryThreshold > 0 {
+		poolCfg.ConnConfig.Tracer = slowQueryTracer{threshold: cfg.SlowQueryThreshold.Std()}
 	}
-	var err error
-	Pool, err = pgxpool.New(context.Background(), dsn)
+	Pool, err = pgxpool.NewWithConfig(context.Background(), poolCfg)
 	if err != nil {
 		return fmt.Errorf("DB connect error: %w", err)
 	}
@@ -82,14 +99,6 @@ func ensureSchema() error {
 		`CREATE INDEX IF NOT EXISTS idx_users_corp_id  ON users(corp_id);`,
 		`CREATE INDEX IF NOT EXISTS idx_corps_alliance ON corps(alliance_id);`,
 
-		`CREATE OR REPLACE VIEW v_users_enriched AS
-		 SELECT u.char_id, u.name, u.role,
-		        u.corp_id,       c.name  AS corp_name,     c.ticker  AS corp_ticker,
-		        c.alliance_id,   a.name  AS alliance_name, a.ticker  AS alliance_ticker
-		 FROM users u
-		 LEFT JOIN corps     c ON c.corp_id = u.corp_id
-		 LEFT JOIN alliances a ON a.alliance_id = c.alliance_id;`,
-
 		`CREATE TABLE IF NOT EXISTS oauth_tokens (
 			  char_id TEXT PRIMARY KEY,
 			  token_json TEXT NOT NULL,
@@ -134,6 +143,273 @@ func ensureSchema() error {
 		ADD CONSTRAINT routes_min_price_nonneg CHECK (min_price >= 0);
 		END IF;
 		END$$;`,
+
+		// Ausgehandelte Raten pro Corp/Alliance (route_id NULL = alle Routen).
+		// Bewusst ohne FK auf corps/alliances, damit Partner vor dem ersten Login eingetragen werden können.
+		`CREATE TABLE IF NOT EXISTS route_rates (
+			id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			route_id     UUID NULL REFERENCES routes(id) ON DELETE CASCADE,
+			corp_id      BIGINT NULL,
+			alliance_id  BIGINT NULL,
+			price_per_m3 NUMERIC(10,2) NULL,
+			discount_pct NUMERIC(5,2) NULL,
+			min_price    NUMERIC(14,2) NULL,
+			note         TEXT NOT NULL DEFAULT '',
+			created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+			CONSTRAINT route_rates_target_chk   CHECK ((corp_id IS NULL) <> (alliance_id IS NULL)),
+			CONSTRAINT route_rates_value_chk    CHECK (price_per_m3 IS NOT NULL OR discount_pct IS NOT NULL),
+			CONSTRAINT route_rates_discount_chk CHECK (discount_pct IS NULL OR (discount_pct > 0 AND discount_pct <= 100)),
+			CONSTRAINT route_rates_price_chk    CHECK (price_per_m3 IS NULL OR price_per_m3 >= 0),
+			CONSTRAINT route_rates_min_chk      CHECK (min_price IS NULL OR min_price >= 0)
+		);`,
+
+		`CREATE INDEX IF NOT EXISTS idx_route_rates_corp     ON route_rates(corp_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_route_rates_alliance ON route_rates(alliance_id);`,
+
+		// Eine Rate pro Ziel (Route + Corp/Alliance, NULL = 0) für UpsertRouteRate (ON CONFLICT).
+		// Beim Anlegen gewinnt bei Dubletten die jüngste Rate.
+		`DO $$
+		BEGIN
+		  IF to_regclass('route_rates_target_uq') IS NULL THEN
+		    DELETE FROM route_rates a
+		    USING route_rates b
+		    WHERE a.route_id IS NOT DISTINCT FROM b.route_id
+		      AND a.corp_id IS NOT DISTINCT FROM b.corp_id
+		      AND a.alliance_id IS NOT DISTINCT FROM b.alliance_id
+		      AND (a.created_at, a.id::text) < (b.created_at, b.id::text);
+		    CREATE UNIQUE INDEX route_rates_target_uq ON route_rates (` + rateTarget + `);
+		  END IF;
+		END$$;`,
+
+		`CREATE TABLE IF NOT EXISTS promo_codes (
+			code         TEXT PRIMARY KEY,
+			discount_pct NUMERIC(5,2) NOT NULL,
+			route_id     UUID NULL REFERENCES routes(id) ON DELETE CASCADE,
+			max_uses     INT NULL,
+			uses         INT NOT NULL DEFAULT 0,
+			expires_at   TIMESTAMPTZ NULL,
+			active       BOOLEAN NOT NULL DEFAULT true,
+			created_by   BIGINT NULL,
+			created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+			CONSTRAINT promo_codes_discount_chk CHECK (discount_pct > 0 AND discount_pct <= 100),
+			CONSTRAINT promo_codes_max_uses_chk CHECK (max_uses IS NULL OR max_uses > 0)
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS promo_redemptions (
+			id          BIGSERIAL PRIMARY KEY,
+			code        TEXT NOT NULL REFERENCES promo_codes(code) ON DELETE CASCADE,
+			char_id     BIGINT NOT NULL REFERENCES users(char_id) ON DELETE CASCADE,
+			route_id    UUID NULL REFERENCES routes(id) ON DELETE SET NULL,
+			redeemed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS provider_profiles (
+			char_id    BIGINT PRIMARY KEY REFERENCES users(char_id) ON DELETE CASCADE,
+			ship_class TEXT NOT NULL DEFAULT 'freighter',
+			max_m3     BIGINT NOT NULL DEFAULT 0,
+			timezone   TEXT NOT NULL DEFAULT 'UTC',
+			on_duty    BOOLEAN NOT NULL DEFAULT false,
+			notes      TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			CONSTRAINT provider_profiles_ship_chk CHECK (ship_class IN ('freighter','jump_freighter','blockade_runner','dst','other')),
+			CONSTRAINT provider_profiles_m3_chk   CHECK (max_m3 >= 0)
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS provider_routes (
+			char_id  BIGINT NOT NULL REFERENCES provider_profiles(char_id) ON DELETE CASCADE,
+			route_id UUID   NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
+			PRIMARY KEY (char_id, route_id)
+		);`,
+
+		`CREATE INDEX IF NOT EXISTS idx_provider_routes_route ON provider_routes(route_id);`,
+
+		// Im Dienst nur mit Kapazität: max_m3 = 0 wäre für FindAvailableProviders nie verfügbar.
+		// Alt-Profile ohne Kapazität gehen beim Anlegen der Constraint außer Dienst.
+		`DO $$
+		BEGIN
+		  IF NOT EXISTS (
+		    SELECT 1 FROM pg_constraint
+		    WHERE conname = 'provider_profiles_duty_chk'
+		    AND   conrelid = 'provider_profiles'::regclass
+		  ) THEN
+		    UPDATE provider_profiles SET on_duty = false WHERE on_duty AND max_m3 = 0;
+		    ALTER TABLE provider_profiles
+		      ADD CONSTRAINT provider_profiles_duty_chk CHECK (NOT on_duty OR max_m3 > 0);
+		  END IF;
+		END$$;`,
+
+		// Rechte-Modell: Rollen bündeln Rechte, Chars können mehrere Rollen haben.
+		// users.role bleibt als primäre Rolle (Anzeige/Kompatibilität) erhalten.
+		`CREATE TABLE IF NOT EXISTS roles (
+			name        TEXT PRIMARY KEY,
+			description TEXT NOT NULL DEFAULT '',
+			builtin     BOOLEAN NOT NULL DEFAULT false,
+			created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS role_permissions (
+			role       TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE ON UPDATE CASCADE,
+			permission TEXT NOT NULL,
+			PRIMARY KEY (role, permission)
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS user_roles (
+			char_id BIGINT NOT NULL REFERENCES users(char_id) ON DELETE CASCADE,
+			role    TEXT   NOT NULL REFERENCES roles(name) ON DELETE CASCADE ON UPDATE CASCADE,
+			PRIMARY KEY (char_id, role)
+		);`,
+
+		`CREATE INDEX IF NOT EXISTS idx_user_roles_role ON user_roles(role);`,
+
+		// Herkunft einer Zuweisung: manual (Admin, hat Vorrang), rule (Corp/Alliance-Regel), default.
+		// Beim ersten Anlegen gelten bestehende 'user'-Zuweisungen als Default, der Rest als manuell.
+		`DO $$
+		BEGIN
+		  IF NOT EXISTS (
+		    SELECT 1 FROM information_schema.columns
+		    WHERE table_name = 'user_roles' AND column_name = 'source'
+		  ) THEN
+		    ALTER TABLE user_roles
+		      ADD COLUMN source TEXT NOT NULL DEFAULT 'manual',
+		      ADD COLUMN reason TEXT NOT NULL DEFAULT '',
+		      ADD CONSTRAINT user_roles_source_chk CHECK (source IN ('manual','rule','default'));
+		    UPDATE user_roles SET source = 'default' WHERE role = 'user';
+		  END IF;
+		END$$;`,
+
+		`CREATE TABLE IF NOT EXISTS role_rules (
+			id         BIGSERIAL PRIMARY KEY,
+			match_type TEXT NOT NULL,
+			match_id   BIGINT NULL,
+			role       TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE ON UPDATE CASCADE,
+			note       TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			CONSTRAINT role_rules_type_chk  CHECK (match_type IN ('corp','alliance','default')),
+			CONSTRAINT role_rules_match_chk CHECK ((match_type = 'default') = (match_id IS NULL)),
+			CONSTRAINT role_rules_uq UNIQUE (match_type, match_id, role)
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS role_changes (
+			id         BIGSERIAL PRIMARY KEY,
+			char_id    BIGINT NOT NULL REFERENCES users(char_id) ON DELETE CASCADE,
+			old_roles  TEXT[] NOT NULL,
+			new_roles  TEXT[] NOT NULL,
+			source     TEXT NOT NULL,
+			reason     TEXT NOT NULL DEFAULT '',
+			changed_by BIGINT NULL,
+			changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+
+		`CREATE INDEX IF NOT EXISTS idx_role_changes_char ON role_changes(char_id, changed_at DESC);`,
+
+		// Private Deployments: Chars außerhalb der Allow-List landen in Quarantäne (keine Rechte)
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS quarantined BOOLEAN NOT NULL DEFAULT false;`,
+		// Von einem Admin freigegebene Chars bleiben trotz Allow-List zugelassen
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS access_exempt BOOLEAN NOT NULL DEFAULT false;`,
+
+		// char_id bewusst ohne FK: abgewiesene Chars werden nie in users angelegt
+		`CREATE TABLE IF NOT EXISTS login_denials (
+			id          BIGSERIAL PRIMARY KEY,
+			char_id     BIGINT NOT NULL,
+			char_name   TEXT NOT NULL DEFAULT '',
+			corp_id     BIGINT NULL,
+			alliance_id BIGINT NULL,
+			action      TEXT NOT NULL,
+			reason      TEXT NOT NULL DEFAULT '',
+			ip          TEXT NOT NULL DEFAULT '',
+			user_agent  TEXT NOT NULL DEFAULT '',
+			created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+			reviewed_at TIMESTAMPTZ NULL,
+			reviewed_by BIGINT NULL,
+			CONSTRAINT login_denials_action_chk CHECK (action IN ('reject','quarantine'))
+		);`,
+
+		`CREATE INDEX IF NOT EXISTS idx_login_denials_created ON login_denials(created_at DESC);`,
+
+		// Accounts: ein Main-Char plus verknüpfte Alts. Rollen hängen am Main-Char (user_roles),
+		// Alts erben dessen Rechte.
+		`CREATE TABLE IF NOT EXISTS accounts (
+			id           BIGSERIAL PRIMARY KEY,
+			main_char_id BIGINT NOT NULL UNIQUE REFERENCES users(char_id) ON DELETE CASCADE,
+			created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS account_id BIGINT NULL REFERENCES accounts(id) ON DELETE SET NULL;`,
+		`CREATE INDEX IF NOT EXISTS idx_users_account ON users(account_id);`,
+
+		// Bestands-User: jeder Char wird Main seines eigenen Accounts
+		`INSERT INTO accounts (main_char_id)
+		 SELECT u.char_id FROM users u
+		 WHERE u.account_id IS NULL
+		 ON CONFLICT (main_char_id) DO NOTHING;`,
+		`UPDATE users u SET account_id = a.id
+		 FROM accounts a
+		 WHERE a.main_char_id = u.char_id AND u.account_id IS NULL;`,
+
+		// Sperren + letzter Login für die User-Verwaltung
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS banned_at     TIMESTAMPTZ NULL;`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS banned_reason TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS banned_by     BIGINT NULL;`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMPTZ NULL;`,
+
+		// Aktivität: created_at (Bestand = Migrationszeitpunkt), last_seen_at gedrosselt über die Session
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at   TIMESTAMPTZ NOT NULL DEFAULT now();`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ NULL;`,
+
+		`CREATE TABLE IF NOT EXISTS login_events (
+			id         BIGSERIAL PRIMARY KEY,
+			char_id    BIGINT NOT NULL REFERENCES users(char_id) ON DELETE CASCADE,
+			kind       TEXT NOT NULL DEFAULT 'login',
+			ip         TEXT NOT NULL DEFAULT '',
+			user_agent TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			CONSTRAINT login_events_kind_chk CHECK (kind IN ('login','link'))
+		);`,
+
+		`CREATE INDEX IF NOT EXISTS idx_login_events_char ON login_events(char_id, created_at DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_login_events_ip   ON login_events(ip, created_at DESC);`,
+
+		// Persönliche API-Tokens (Bots/Skripte); gespeichert wird nur der SHA-256-Hash
+		`CREATE TABLE IF NOT EXISTS api_tokens (
+			id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			char_id      BIGINT NOT NULL REFERENCES users(char_id) ON DELETE CASCADE,
+			name         TEXT NOT NULL,
+			prefix       TEXT NOT NULL,
+			token_hash   BYTEA NOT NULL UNIQUE,
+			scopes       TEXT[] NOT NULL DEFAULT '{}',
+			rate_per_min INT NULL,
+			created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+			last_used_at TIMESTAMPTZ NULL,
+			expires_at   TIMESTAMPTZ NULL,
+			revoked_at   TIMESTAMPTZ NULL,
+			CONSTRAINT api_tokens_rate_chk CHECK (rate_per_min IS NULL OR rate_per_min > 0)
+		);`,
+
+		`CREATE INDEX IF NOT EXISTS idx_api_tokens_char ON api_tokens(char_id);`,
+
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+
+		// Fixed-Window-Zähler der Rate-Limits (RATE_LIMIT_STORE=postgres, mehrere Replicas)
+		`CREATE TABLE IF NOT EXISTS rate_limit_counters (
+			key          TEXT PRIMARY KEY,
+			window_start TIMESTAMPTZ NOT NULL,
+			count        INT NOT NULL
+		);`,
+
+		// View steht hinter allen users-Spalten; neue Spalten nur hinten anhängen (CREATE OR REPLACE VIEW)
+		`CREATE OR REPLACE VIEW v_users_enriched AS
+		 SELECT u.char_id, u.name, u.role,
+		        u.corp_id,       c.name  AS corp_name,     c.ticker  AS corp_ticker,
+		        c.alliance_id,   a.name  AS alliance_name, a.ticker  AS alliance_ticker,
+		        u.quarantined, u.banned_at, u.banned_reason, u.last_login_at, acc.main_char_id,
+		        u.created_at, u.last_seen_at
+		 FROM users u
+		 LEFT JOIN corps     c   ON c.corp_id = u.corp_id
+		 LEFT JOIN alliances a   ON a.alliance_id = c.alliance_id
+		 LEFT JOIN accounts  acc ON acc.id = u.account_id;`,
 	}
 
 	for _, s := range stmts {
@@ -141,6 +417,36 @@ func ensureSchema() error {
 			return err
 		}
 	}
+	if err = seedRoles(ctx, tx); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT DO NOTHING`, SchemaVersion); err != nil {
+		return err
+	}
 	fmt.Println("✅ DB schema checked/created")
 	return nil
 }
+
+// Ping prüft die DB-Verbindung (Readiness).
+func Ping(ctx context.Context) error {
+	if Pool == nil {
+		return fmt.Errorf("DB pool not initialized")
+	}
+	return Pool.Ping(ctx)
+}
+
+// AppliedSchemaVersion liefert die höchste eingetragene Schema-Version (0 = keine).
+func AppliedSchemaVersion(ctx context.Context) (int, error) {
+	var v int
+	if err := Pool.QueryRow(ctx, `SELECT COALESCE(max(version), 0) FROM schema_migrations`).Scan(&v); err != nil {
+		return 0, fmt.Errorf("AppliedSchemaVersion error: %w", err)
+	}
+	return v, nil
+}
+
+// Close schließt den Pool (wartet auf ausgeliehene Verbindungen).
+func Close() {
+	if Pool != nil {
+		Pool.Close()
+	}
+}
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"speedliner-server/src/utils/structs"
+)
+
+// RecordLogin protokolliert einen SSO-Login (kind login|link) und setzt last_login_at.
+func RecordLogin(ctx context.Context, charID int64, kind, ip, userAgent string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if _, err := Pool.Exec(ctx, `
+		INSERT INTO login_events (char_id, kind, ip, user_agent)
+		VALUES ($1,$2,$3,$4)`, charID, kind, ip, userAgent); err != nil {
+		return fmt.Errorf("RecordLogin error: %w", err)
+	}
+	_, err := Pool.Exec(ctx, `UPDATE users SET last_login_at=now(), last_seen_at=now() WHERE char_id=$1`, charID)
+	return err
+}
+
+func TouchLastSeen(ctx context.Context, charID int64) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := Pool.Exec(ctx, `UPDATE users SET last_seen_at=now() WHERE char_id=$1`, charID)
+	return err
+}
+
+func ListLoginEvents(ctx context.Context, charID int64, limit int) ([]structs.LoginEvent, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := Pool.Query(ctx, `
+		SELECT id, char_id, kind, ip, user_agent, created_at
+		FROM login_events
+		WHERE char_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`, charID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListLoginEvents error: %w", err)
+	}
+	defer rows.Close()
+
+	list := []structs.LoginEvent{}
+	for rows.Next() {
+		var e structs.LoginEvent
+		if err := rows.Scan(&e.ID, &e.CharID, &e.Kind, &e.IP, &e.UserAgent, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ListLoginEvents scan error: %w", err)
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// ListInactiveUsers: User ohne Aktivität seit days Tagen (älteste zuerst).
+func ListInactiveUsers(ctx context.Context, days, limit int) ([]structs.UserListItem, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := Pool.Query(ctx, `
+		SELECT `+userListCols+`
+		FROM v_users_enriched v
+		WHERE COALESCE(v.last_seen_at, v.last_login_at, v.created_at) < now() - make_interval(days => $1)
+		ORDER BY COALESCE(v.last_seen_at, v.last_login_at, v.created_at)
+		LIMIT $2`, days, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListInactiveUsers error: %w", err)
+	}
+	defer rows.Close()
+
+	items := []structs.UserListItem{}
+	for rows.Next() {
+		u, err := scanUserListItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListInactiveUsers scan error: %w", err)
+		}
+		items = append(items, u)
+	}
+	return items, rows.Err()
+}
+
+// SuspiciousLogins sucht im Zeitfenster nach IPs, die von mehreren Accounts genutzt werden
+// (Alts desselben Accounts zählen als einer), und nach Chars mit vielen verschiedenen IPs.
+func SuspiciousLogins(ctx context.Context, hours, minAccounts, minIPs int) ([]structs.SuspiciousLogin, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	list := []structs.SuspiciousLogin{}
+
+	rows, err := Pool.Query(ctx, `
+		SELECT e.ip,
+		       count(DISTINCT COALESCE(u.account_id, -u.char_id)) AS accounts,
+		       array_agg(DISTINCT e.char_id ORDER BY e.char_id),
+		       min(e.created_at), max(e.created_at)
+		FROM login_events e
+		JOIN users u ON u.char_id = e.char_id
+		WHERE e.created_at > now() - make_interval(hours => $1)
+		  AND e.ip <> ''
+		GROUP BY e.ip
+		HAVING count(DISTINCT COALESCE(u.account_id, -u.char_id)) >= $2
+		ORDER BY accounts DESC`, hours, minAccounts)
+	if err != nil {
+		return nil, fmt.Errorf("SuspiciousLogins shared_ip error: %w", err)
+	}
+	for rows.Next() {
+		s := structs.SuspiciousLogin{Kind: "shared_ip", IPs: []string{}}
+		if err := rows.Scan(&s.IP, &s.Count, &s.CharIDs, &s.FirstSeen, &s.LastSeen); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("SuspiciousLogins scan error: %w", err)
+		}
+		s.IPs = append(s.IPs, s.IP)
+		list = append(list, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = Pool.Query(ctx, `
+		SELECT e.char_id, count(DISTINCT e.ip) AS ips,
+		       array_agg(DISTINCT e.ip ORDER BY e.ip),
+		       min(e.created_at), max(e.created_at)
+		FROM login_events e
+		WHERE e.created_at > now() - make_interval(hours => $1)
+		  AND e.ip <> ''
+		GROUP BY e.char_id
+		HAVING count(DISTINCT e.ip) >= $2
+		ORDER BY ips DESC`, hours, minIPs)
+	if err != nil {
+		return nil, fmt.Errorf("SuspiciousLogins many_ips error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var charID int64
+		s := structs.SuspiciousLogin{Kind: "many_ips"}
+		if err := rows.Scan(&charID, &s.Count, &s.IPs, &s.FirstSeen, &s.LastSeen); err != nil {
+			return nil, fmt.Errorf("SuspiciousLogins scan error: %w", err)
+		}
+		s.CharID = &charID
+		s.CharIDs = []int64{charID}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
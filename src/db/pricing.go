@@ -0,0 +1,234 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"speedliner-server/src/utils/structs"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrPromoUnavailable: Code existiert nicht, ist abgelaufen, inaktiv, aufgebraucht oder gilt nicht für die Route.
+var ErrPromoUnavailable = errors.New("promo code not available")
+
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ---- Corp-/Alliance-Raten ----
+
+const rateCols = `id, route_id::text, corp_id, alliance_id, price_per_m3, discount_pct, min_price, note, created_at`
+
+func scanRate(row pgx.Row) (structs.RouteRate, error) {
+	var it structs.RouteRate
+	err := row.Scan(&it.ID, &it.RouteID, &it.CorpID, &it.AllianceID,
+		&it.PricePerM3, &it.DiscountPct, &it.MinPrice, &it.Note, &it.CreatedAt)
+	return it, err
+}
+
+func ListRouteRates(ctx context.Context) ([]structs.RouteRate, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx, `
+		SELECT `+rateCols+`
+		FROM route_rates
+		ORDER BY route_id NULLS FIRST, corp_id NULLS LAST, alliance_id`)
+	if err != nil {
+		return nil, fmt.Errorf("ListRouteRates query error: %w", err)
+	}
+	defer rows.Close()
+
+	var list []structs.RouteRate
+	for rows.Next() {
+		it, err := scanRate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListRouteRates scan error: %w", err)
+		}
+		list = append(list, it)
+	}
+	return list, rows.Err()
+}
+
+// rateTarget: Ziel einer Rate für den Unique-Index route_rates_target_uq (NULL zählt als gleich).
+const rateTarget = `(COALESCE(route_id, '00000000-0000-0000-0000-000000000000'::uuid)), (COALESCE(corp_id, 0)), (COALESCE(alliance_id, 0))`
+
+// UpsertRouteRate legt eine Rate an oder ersetzt die bestehende für dasselbe Ziel (Route + Corp/Alliance).
+func UpsertRouteRate(ctx context.Context, r structs.RouteRate) (structs.RouteRate, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	out, err := scanRate(Pool.QueryRow(ctx, `
+		INSERT INTO route_rates (route_id, corp_id, alliance_id, price_per_m3, discount_pct, min_price, note)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		ON CONFLICT (`+rateTarget+`) DO UPDATE
+		SET price_per_m3=EXCLUDED.price_per_m3,
+		    discount_pct=EXCLUDED.discount_pct,
+		    min_price=EXCLUDED.min_price,
+		    note=EXCLUDED.note,
+		    created_at=now()
+		RETURNING `+rateCols,
+		r.RouteID, r.CorpID, r.AllianceID, r.PricePerM3, r.DiscountPct, r.MinPrice, r.Note))
+	if err != nil {
+		return r, fmt.Errorf("UpsertRouteRate error: %w", err)
+	}
+	return out, nil
+}
+
+func DeleteRouteRate(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := Pool.Exec(ctx, `DELETE FROM route_rates WHERE id::text = $1`, id)
+	return err
+}
+
+// GetEffectiveRate sucht die passende Rate für Route + Char.
+// Reihenfolge: Route+Corp, Route+Alliance, global Corp, global Alliance. nil = keine.
+func GetEffectiveRate(ctx context.Context, routeID string, charID int64) (*structs.RouteRate, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	it, err := scanRate(Pool.QueryRow(ctx, `
+		SELECT `+rateCols+`
+		FROM route_rates rr
+		JOIN users u ON u.char_id = $2
+		LEFT JOIN corps c ON c.corp_id = u.corp_id
+		WHERE (rr.route_id IS NULL OR rr.route_id::text = $1)
+		  AND (rr.corp_id = u.corp_id OR rr.alliance_id = c.alliance_id)
+		ORDER BY rr.route_id NULLS LAST, rr.corp_id NULLS LAST
+		LIMIT 1`, routeID, charID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetEffectiveRate error: %w", err)
+	}
+	return &it, nil
+}
+
+// ---- Promo-Codes ----
+
+const promoCols = `code, discount_pct, route_id::text, max_uses, uses, expires_at, active, created_at`
+
+func scanPromo(row pgx.Row) (structs.PromoCode, error) {
+	var it structs.PromoCode
+	err := row.Scan(&it.Code, &it.DiscountPct, &it.RouteID, &it.MaxUses, &it.Uses,
+		&it.ExpiresAt, &it.Active, &it.CreatedAt)
+	return it, err
+}
+
+func ListPromoCodes(ctx context.Context) ([]structs.PromoCode, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx, `
+		SELECT `+promoCols+`
+		FROM promo_codes
+		ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("ListPromoCodes query error: %w", err)
+	}
+	defer rows.Close()
+
+	var list []structs.PromoCode
+	for rows.Next() {
+		it, err := scanPromo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListPromoCodes scan error: %w", err)
+		}
+		list = append(list, it)
+	}
+	return list, rows.Err()
+}
+
+// UpsertPromoCode legt einen Code an bzw. aktualisiert Rabatt/Limits. Der Nutzungszähler bleibt erhalten.
+func UpsertPromoCode(ctx context.Context, p structs.PromoCode, createdBy int64) (structs.PromoCode, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	out, err := scanPromo(Pool.QueryRow(ctx, `
+		INSERT INTO promo_codes (code, discount_pct, route_id, max_uses, expires_at, active, created_by)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		ON CONFLICT (code) DO UPDATE
+		SET discount_pct=EXCLUDED.discount_pct,
+		    route_id=EXCLUDED.route_id,
+		    max_uses=EXCLUDED.max_uses,
+		    expires_at=EXCLUDED.expires_at,
+		    active=EXCLUDED.active
+		RETURNING `+promoCols,
+		NormalizePromoCode(p.Code), p.DiscountPct, p.RouteID, p.MaxUses, p.ExpiresAt, p.Active, createdBy))
+	if err != nil {
+		return p, fmt.Errorf("UpsertPromoCode error: %w", err)
+	}
+	return out, nil
+}
+
+func DeletePromoCode(ctx context.Context, code string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := Pool.Exec(ctx,
+		`DELETE FROM promo_codes WHERE code = $1`, NormalizePromoCode(code))
+	return err
+}
+
+// GetUsablePromoCode prüft einen Code für eine Route, ohne ihn zu verbrauchen.
+func GetUsablePromoCode(ctx context.Context, code, routeID string) (*structs.PromoCode, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	it, err := scanPromo(Pool.QueryRow(ctx, `
+		SELECT `+promoCols+`
+		FROM promo_codes
+		WHERE code = $1
+		  AND active
+		  AND (expires_at IS NULL OR expires_at > now())
+		  AND (max_uses IS NULL OR uses < max_uses)
+		  AND (route_id IS NULL OR route_id::text = $2)`,
+		NormalizePromoCode(code), routeID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPromoUnavailable
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetUsablePromoCode error: %w", err)
+	}
+	return &it, nil
+}
+
+// RedeemPromoCode verbraucht eine Nutzung atomar und protokolliert die Einlösung.
+func RedeemPromoCode(ctx context.Context, code, routeID string, charID int64) (err error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	var c string
+	err = tx.QueryRow(ctx, `
+		UPDATE promo_codes
+		   SET uses = uses + 1
+		 WHERE code = $1
+		   AND active
+		   AND (expires_at IS NULL OR expires_at > now())
+		   AND (max_uses IS NULL OR uses < max_uses)
+		   AND (route_id IS NULL OR route_id::text = $2)
+		RETURNING code`, NormalizePromoCode(code), routeID).Scan(&c)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrPromoUnavailable
+	}
+	if err != nil {
+		return fmt.Errorf("RedeemPromoCode error: %w", err)
+	}
+
+	var rid *string
+	if routeID != "" {
+		rid = &routeID
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO promo_redemptions (code, char_id, route_id)
+		VALUES ($1,$2,$3::uuid)`, c, charID, rid)
+	return err
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"speedliner-server/src/utils/structs"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrProviderNoCapacity: Dienst ohne eingetragene Kapazität (max_m3 = 0).
+var ErrProviderNoCapacity = errors.New("provider has no capacity (max_m3)")
+
+const providerSelect = `
+	SELECT p.char_id, u.name, p.ship_class, p.max_m3, p.timezone, p.on_duty, p.notes, p.updated_at,
+	       COALESCE(ARRAY(SELECT pr.route_id::text FROM provider_routes pr WHERE pr.char_id = p.char_id ORDER BY pr.route_id), '{}')
+	FROM provider_profiles p
+	JOIN users u ON u.char_id = p.char_id`
+
+func scanProvider(row pgx.Row) (structs.ProviderProfile, error) {
+	var it structs.ProviderProfile
+	err := row.Scan(&it.CharID, &it.Name, &it.ShipClass, &it.MaxM3, &it.Timezone,
+		&it.OnDuty, &it.Notes, &it.UpdatedAt, &it.RouteIDs)
+	return it, err
+}
+
+func listProviders(ctx context.Context, query string, args ...any) ([]structs.ProviderProfile, error) {
+	rows, err := Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []structs.ProviderProfile
+	for rows.Next() {
+		it, err := scanProvider(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, it)
+	}
+	return list, rows.Err()
+}
+
+// GetProviderProfile liefert das Profil eines Haulers oder nil, wenn noch keins angelegt wurde.
+func GetProviderProfile(ctx context.Context, charID int64) (*structs.ProviderProfile, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	it, err := scanProvider(Pool.QueryRow(ctx,
+		providerSelect+` WHERE p.char_id = $1`, charID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetProviderProfile error: %w", err)
+	}
+	return &it, nil
+}
+
+func ListProviderProfiles(ctx context.Context) ([]structs.ProviderProfile, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	list, err := listProviders(ctx, providerSelect+` ORDER BY p.on_duty DESC, u.name`)
+	if err != nil {
+		return nil, fmt.Errorf("ListProviderProfiles error: %w", err)
+	}
+	return list, nil
+}
+
+// UpsertProviderProfile speichert Profil + Routenzuordnung (ersetzt die bisherigen Routen).
+func UpsertProviderProfile(ctx context.Context, p structs.ProviderProfile) (err error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, `
+		INSERT INTO provider_profiles (char_id, ship_class, max_m3, timezone, on_duty, notes, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,now())
+		ON CONFLICT (char_id) DO UPDATE
+		SET ship_class=EXCLUDED.ship_class,
+		    max_m3=EXCLUDED.max_m3,
+		    timezone=EXCLUDED.timezone,
+		    on_duty=EXCLUDED.on_duty,
+		    notes=EXCLUDED.notes,
+		    updated_at=now()`,
+		p.CharID, p.ShipClass, p.MaxM3, p.Timezone, p.OnDuty, p.Notes); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM provider_routes WHERE char_id=$1`, p.CharID); err != nil {
+		return err
+	}
+	for _, rid := range p.RouteIDs {
+		if _, err = tx.Exec(ctx, `
+			INSERT INTO provider_routes (char_id, route_id)
+			VALUES ($1,$2::uuid) ON CONFLICT DO NOTHING`, p.CharID, rid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetProviderOnDuty schaltet den Dienststatus; false, wenn kein Profil existiert.
+// Ohne eingetragene Kapazität (max_m3 = 0) gibt es keinen Dienst: ErrProviderNoCapacity.
+func SetProviderOnDuty(ctx context.Context, charID int64, onDuty bool) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var maxM3 int64
+	err := Pool.QueryRow(ctx, `
+		UPDATE provider_profiles SET on_duty = $2 AND max_m3 > 0, updated_at=now()
+		WHERE char_id=$1
+		RETURNING max_m3`, charID, onDuty).Scan(&maxM3)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("SetProviderOnDuty error: %w", err)
+	}
+	if onDuty && maxM3 <= 0 {
+		return true, ErrProviderNoCapacity
+	}
+	return true, nil
+}
+
+// FindAvailableProviders: Hauler im Dienst, die die Route fliegen und das Volumen laden können.
+// Recht und Sperre/Quarantäne wie GetUserPermissions: Rollen liegen am Main, Alts erben sie.
+func FindAvailableProviders(ctx context.Context, routeID string, volumeM3 int64) ([]structs.ProviderProfile, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	list, err := listProviders(ctx, providerSelect+`
+		LEFT JOIN accounts a ON a.id = u.account_id
+		JOIN users m         ON m.char_id = COALESCE(a.main_char_id, u.char_id)
+		WHERE p.on_duty
+		  AND p.max_m3 >= $2
+		  AND NOT u.quarantined AND NOT m.quarantined
+		  AND u.banned_at IS NULL AND m.banned_at IS NULL
+		  AND EXISTS (
+		        SELECT 1 FROM user_roles ur
+		        JOIN role_permissions rp ON rp.role = ur.role
+		        WHERE ur.char_id = m.char_id AND rp.permission = $3)
+		  AND EXISTS (SELECT 1 FROM provider_routes pr WHERE pr.char_id = p.char_id AND pr.route_id::text = $1)
+		ORDER BY p.max_m3, u.name`, routeID, volumeM3, structs.PermProvidersProfile)
+	if err != nil {
+		return nil, fmt.Errorf("FindAvailableProviders error: %w", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RateLimitHit zählt einen Treffer im aktuellen Fenster (Fixed Window, Uhr der DB)
+// und liefert Zählerstand und Fensterbeginn.
+func RateLimitHit(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var (
+		count int
+		start time.Time
+	)
+	err := Pool.QueryRow(ctx, `
+		INSERT INTO rate_limit_counters (key, window_start, count)
+		VALUES ($1, to_timestamp(floor(extract(epoch FROM now()) / $2) * $2), 1)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN rate_limit_counters.window_start = EXCLUDED.window_start
+			             THEN rate_limit_counters.count + 1 ELSE 1 END,
+			window_start = EXCLUDED.window_start
+		RETURNING count, window_start`, key, window.Seconds()).Scan(&count, &start)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("RateLimitHit error: %w", err)
+	}
+	return count, start, nil
+}
+
+// PruneRateLimits löscht Zähler, deren Fenster älter als olderThan ist.
+func PruneRateLimits(ctx context.Context, olderThan time.Duration) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tag, err := Pool.Exec(ctx,
+		`DELETE FROM rate_limit_counters WHERE window_start < now() - make_interval(secs => $1)`,
+		olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("PruneRateLimits error: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
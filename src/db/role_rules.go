@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"speedliner-server/src/utils/structs"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const ruleCols = `rr.id, rr.match_type, rr.match_id, rr.role, rr.note, rr.created_at`
+
+func scanRules(rows pgx.Rows) ([]structs.RoleRule, error) {
+	defer rows.Close()
+	var list []structs.RoleRule
+	for rows.Next() {
+		var it structs.RoleRule
+		if err := rows.Scan(&it.ID, &it.MatchType, &it.MatchID, &it.Role, &it.Note, &it.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, it)
+	}
+	return list, rows.Err()
+}
+
+func ListRoleRules(ctx context.Context) ([]structs.RoleRule, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx, `
+		SELECT `+ruleCols+`
+		FROM role_rules rr
+		ORDER BY rr.match_type, rr.match_id NULLS FIRST, rr.role`)
+	if err != nil {
+		return nil, fmt.Errorf("ListRoleRules error: %w", err)
+	}
+	return scanRules(rows)
+}
+
+func InsertRoleRule(ctx context.Context, rule structs.RoleRule) (structs.RoleRule, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	err := Pool.QueryRow(ctx, `
+		INSERT INTO role_rules (match_type, match_id, role, note)
+		VALUES ($1,$2,$3,$4)
+		ON CONFLICT (match_type, match_id, role) DO UPDATE SET note=EXCLUDED.note
+		RETURNING id, created_at`,
+		rule.MatchType, rule.MatchID, rule.Role, rule.Note).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		return rule, fmt.Errorf("InsertRoleRule error: %w", err)
+	}
+	return rule, nil
+}
+
+func DeleteRoleRule(ctx context.Context, id int64) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := Pool.Exec(ctx, `DELETE FROM role_rules WHERE id=$1`, id)
+	return err
+}
+
+// GetMatchingRoleRules liefert die Corp-/Alliance-Regeln für den Char; passt keine, die Default-Regeln.
+func GetMatchingRoleRules(ctx context.Context, charID int64) ([]structs.RoleRule, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx, `
+		SELECT `+ruleCols+`
+		FROM role_rules rr
+		JOIN users u      ON u.char_id = $1
+		LEFT JOIN corps c ON c.corp_id = u.corp_id
+		WHERE (rr.match_type = 'corp'     AND rr.match_id = u.corp_id)
+		   OR (rr.match_type = 'alliance' AND rr.match_id = c.alliance_id)
+		ORDER BY rr.id`, charID)
+	if err != nil {
+		return nil, fmt.Errorf("GetMatchingRoleRules error: %w", err)
+	}
+	list, err := scanRules(rows)
+	if err != nil || len(list) > 0 {
+		return list, err
+	}
+
+	rows, err = Pool.Query(ctx, `
+		SELECT `+ruleCols+`
+		FROM role_rules rr
+		WHERE rr.match_type = 'default'
+		ORDER BY rr.id`)
+	if err != nil {
+		return nil, fmt.Errorf("GetMatchingRoleRules default error: %w", err)
+	}
+	return scanRules(rows)
+}
+
+func GetUserRoleAssignments(ctx context.Context, charID int64) ([]structs.RoleAssignment, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx, `
+		SELECT role, source, reason FROM user_roles WHERE char_id=`+mainCharSQL+` ORDER BY role`, charID)
+	if err != nil {
+		return nil, fmt.Errorf("GetUserRoleAssignments error: %w", err)
+	}
+	defer rows.Close()
+
+	list := []structs.RoleAssignment{}
+	for rows.Next() {
+		var it structs.RoleAssignment
+		if err := rows.Scan(&it.Role, &it.Source, &it.Reason); err != nil {
+			return nil, err
+		}
+		list = append(list, it)
+	}
+	return list, rows.Err()
+}
+
+// ApplyRuleRoles ersetzt die regelbasierten Zuweisungen; manuelle bleiben unberührt.
+func ApplyRuleRoles(ctx context.Context, charID int64, assign []structs.RoleAssignment) error {
+	reasons := make([]string, 0, len(assign))
+	for _, a := range assign {
+		reasons = append(reasons, a.Role+": "+a.Reason)
+	}
+	return replaceUserRoles(ctx, charID, assign, true, structs.RoleSourceRule, strings.Join(reasons, "; "), nil)
+}
+
+// ClearManualRoles entfernt manuelle Zuweisungen, damit wieder die Regeln greifen.
+func ClearManualRoles(ctx context.Context, charID int64, changedBy int64) error {
+	return replaceUserRoles(ctx, charID, nil, true, structs.RoleSourceManual,
+		fmt.Sprintf("manual override cleared by %d", changedBy), &changedBy)
+}
+
+func ListRoleChanges(ctx context.Context, charID int64, limit int) ([]structs.RoleChange, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := Pool.Query(ctx, `
+		SELECT id, char_id, old_roles, new_roles, source, reason, changed_by, changed_at
+		FROM role_changes
+		WHERE char_id = $1
+		ORDER BY changed_at DESC
+		LIMIT $2`, charID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListRoleChanges error: %w", err)
+	}
+	defer rows.Close()
+
+	var list []structs.RoleChange
+	for rows.Next() {
+		var it structs.RoleChange
+		if err := rows.Scan(&it.ID, &it.CharID, &it.OldRoles, &it.NewRoles, &it.Source,
+			&it.Reason, &it.ChangedBy, &it.ChangedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, it)
+	}
+	return list, rows.Err()
+}
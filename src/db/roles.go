@@ -0,0 +1,371 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"speedliner-server/src/utils/structs"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrRoleBuiltin  = errors.New("builtin role cannot be changed")
+)
+
+// rolePriority bestimmt die "primäre" Rolle, die weiterhin in users.role steht.
+var rolePriority = []string{"admin", "provider", "user"}
+
+func primaryRole(roles []string) string {
+	for _, p := range rolePriority {
+		for _, r := range roles {
+			if r == p {
+				return p
+			}
+		}
+	}
+	if len(roles) > 0 {
+		sorted := append([]string{}, roles...)
+		sort.Strings(sorted)
+		return sorted[0]
+	}
+	return "user"
+}
+
+// seedRoles legt die eingebauten Rollen an und übernimmt Alt-Daten aus users.role.
+func seedRoles(ctx context.Context, tx pgx.Tx) error {
+	for name, perms := range structs.BuiltinRoles {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO roles (name, description, builtin)
+			VALUES ($1, $2, true)
+			ON CONFLICT (name) DO UPDATE SET builtin = true`, name, "builtin"); err != nil {
+			return err
+		}
+		// admin wird immer auf alle Rechte gezogen, die anderen nur beim ersten Anlegen befüllt
+		if name != "admin" {
+			var n int
+			if err := tx.QueryRow(ctx, `SELECT count(*) FROM role_permissions WHERE role=$1`, name).Scan(&n); err != nil {
+				return err
+			}
+			if n > 0 {
+				continue
+			}
+		}
+		for _, p := range perms {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO role_permissions (role, permission)
+				VALUES ($1,$2) ON CONFLICT DO NOTHING`, name, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO user_roles (char_id, role, source)
+		SELECT u.char_id, u.role, CASE WHEN u.role = 'user' THEN 'default' ELSE 'manual' END
+		FROM users u
+		WHERE u.role IN (SELECT name FROM roles)
+		  AND NOT EXISTS (SELECT 1 FROM user_roles ur WHERE ur.char_id = u.char_id)`)
+	return err
+}
+
+// GetUserPermissions: effektive Rechte des Accounts (Rollen am Main-Char).
+// Ist der Char oder sein Main in Quarantäne oder gesperrt, gibt es keine.
+func GetUserPermissions(ctx context.Context, charID int64) ([]string, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx, `
+		SELECT DISTINCT rp.permission
+		FROM users u
+		LEFT JOIN accounts a     ON a.id = u.account_id
+		JOIN users m             ON m.char_id = COALESCE(a.main_char_id, u.char_id)
+		JOIN user_roles ur       ON ur.char_id = m.char_id
+		JOIN role_permissions rp ON rp.role = ur.role
+		WHERE u.char_id = $1
+		  AND NOT u.quarantined
+		  AND NOT m.quarantined
+		  AND u.banned_at IS NULL
+		  AND m.banned_at IS NULL
+		ORDER BY 1`, charID)
+	if err != nil {
+		return nil, fmt.Errorf("GetUserPermissions error: %w", err)
+	}
+	defer rows.Close()
+
+	perms := []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, fmt.Errorf("GetUserPermissions scan error: %w", err)
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
+
+// GetGrantedPermissions: Rechte aus den Rollen des Accounts, unabhängig von Sperre/Quarantäne
+// (für Rangvergleiche: ein gesperrter Admin bleibt ein Admin).
+func GetGrantedPermissions(ctx context.Context, charID int64) ([]string, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx, `
+		SELECT DISTINCT rp.permission
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role = ur.role
+		WHERE ur.char_id = `+mainCharSQL+`
+		ORDER BY 1`, charID)
+	if err != nil {
+		return nil, fmt.Errorf("GetGrantedPermissions error: %w", err)
+	}
+	defer rows.Close()
+
+	perms := []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, fmt.Errorf("GetGrantedPermissions scan error: %w", err)
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
+
+// mainCharSQL löst $1 auf den Main-Char des Accounts auf (Rollen liegen nur dort).
+const mainCharSQL = `(SELECT COALESCE(a.main_char_id, u.char_id)
+	FROM users u LEFT JOIN accounts a ON a.id = u.account_id WHERE u.char_id = $1)`
+
+func GetUserRoleNames(ctx context.Context, charID int64) ([]string, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx,
+		`SELECT role FROM user_roles WHERE char_id = `+mainCharSQL+` ORDER BY role`, charID)
+	if err != nil {
+		return nil, fmt.Errorf("GetUserRoleNames error: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var r string
+		if err := rows.Scan(&r); err != nil {
+			return nil, fmt.Errorf("GetUserRoleNames scan error: %w", err)
+		}
+		roles = append(roles, r)
+	}
+	return roles, rows.Err()
+}
+
+// SetUserRoles setzt die Rollen eines Chars manuell (überstimmt Regeln) und protokolliert die Änderung.
+func SetUserRoles(ctx context.Context, charID int64, roles []string, changedBy int64) error {
+	assign := make([]structs.RoleAssignment, 0, len(roles))
+	for _, r := range roles {
+		assign = append(assign, structs.RoleAssignment{Role: r, Source: structs.RoleSourceManual,
+			Reason: fmt.Sprintf("set by %d", changedBy)})
+	}
+	return replaceUserRoles(ctx, charID, assign, false, structs.RoleSourceManual,
+		fmt.Sprintf("manual change by %d", changedBy), &changedBy)
+}
+
+// SetUserRolesAs setzt Rollen ohne eingeloggten Admin (CLI); actor landet nur im Protokoll.
+func SetUserRolesAs(ctx context.Context, charID int64, roles []string, actor string) error {
+	assign := make([]structs.RoleAssignment, 0, len(roles))
+	for _, r := range roles {
+		assign = append(assign, structs.RoleAssignment{Role: r, Source: structs.RoleSourceManual,
+			Reason: "set by " + actor})
+	}
+	return replaceUserRoles(ctx, charID, assign, false, structs.RoleSourceManual, "manual change by "+actor, nil)
+}
+
+// replaceUserRoles ersetzt Rollenzuweisungen in einer Transaktion. keepManual=true lässt manuelle
+// Zuweisungen stehen (Regel-Auswertung). users.role bekommt danach die primäre Rolle.
+func replaceUserRoles(ctx context.Context, charID int64, assign []structs.RoleAssignment, keepManual bool, source, reason string, changedBy *int64) (err error) {
+	// Rollen gehören dem Account -> immer am Main-Char setzen
+	if charID, err = MainCharID(ctx, charID); err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	var oldRoles []string
+	if err = tx.QueryRow(ctx, `
+		SELECT COALESCE(array_agg(role ORDER BY role), '{}') FROM user_roles WHERE char_id=$1`, charID).
+		Scan(&oldRoles); err != nil {
+		return err
+	}
+
+	if keepManual {
+		_, err = tx.Exec(ctx, `DELETE FROM user_roles WHERE char_id=$1 AND source <> 'manual'`, charID)
+	} else {
+		_, err = tx.Exec(ctx, `DELETE FROM user_roles WHERE char_id=$1`, charID)
+	}
+	if err != nil {
+		return err
+	}
+	for _, a := range assign {
+		if _, err = tx.Exec(ctx, `
+			INSERT INTO user_roles (char_id, role, source, reason)
+			VALUES ($1,$2,$3,$4) ON CONFLICT DO NOTHING`, charID, a.Role, a.Source, a.Reason); err != nil {
+			return err
+		}
+	}
+
+	var newRoles []string
+	if err = tx.QueryRow(ctx, `
+		SELECT COALESCE(array_agg(role ORDER BY role), '{}') FROM user_roles WHERE char_id=$1`, charID).
+		Scan(&newRoles); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, `
+		UPDATE users SET role=$2
+		WHERE char_id=$1 OR account_id = (SELECT account_id FROM users WHERE char_id=$1)`,
+		charID, primaryRole(newRoles)); err != nil {
+		return err
+	}
+	if !equalStrings(oldRoles, newRoles) {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO role_changes (char_id, old_roles, new_roles, source, reason, changed_by)
+			VALUES ($1,$2,$3,$4,$5,$6)`, charID, oldRoles, newRoles, source, reason, changedBy)
+	}
+	return err
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func RoleExists(ctx context.Context, name string) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var ok bool
+	err := Pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM roles WHERE name=$1)`, name).Scan(&ok)
+	return ok, err
+}
+
+func ListRoles(ctx context.Context) ([]structs.Role, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx, `
+		SELECT r.name, r.description, r.builtin,
+		       COALESCE(ARRAY(SELECT rp.permission FROM role_permissions rp WHERE rp.role = r.name ORDER BY 1), '{}')
+		FROM roles r
+		ORDER BY r.builtin DESC, r.name`)
+	if err != nil {
+		return nil, fmt.Errorf("ListRoles error: %w", err)
+	}
+	defer rows.Close()
+
+	var list []structs.Role
+	for rows.Next() {
+		var it structs.Role
+		if err := rows.Scan(&it.Name, &it.Description, &it.Builtin, &it.Permissions); err != nil {
+			return nil, fmt.Errorf("ListRoles scan error: %w", err)
+		}
+		list = append(list, it)
+	}
+	return list, rows.Err()
+}
+
+// UpsertRole legt eine Rolle an bzw. ersetzt ihre Rechte. "admin" ist unveränderlich.
+func UpsertRole(ctx context.Context, role structs.Role) (err error) {
+	if role.Name == "admin" {
+		return ErrRoleBuiltin
+	}
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, `
+		INSERT INTO roles (name, description)
+		VALUES ($1,$2)
+		ON CONFLICT (name) DO UPDATE SET description=EXCLUDED.description`,
+		role.Name, role.Description); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM role_permissions WHERE role=$1`, role.Name); err != nil {
+		return err
+	}
+	for _, p := range role.Permissions {
+		if _, err = tx.Exec(ctx, `
+			INSERT INTO role_permissions (role, permission)
+			VALUES ($1,$2) ON CONFLICT DO NOTHING`, role.Name, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteRole löscht eine selbst definierte Rolle (Zuweisungen fallen per CASCADE weg). Alles in
+// einer Transaktion, damit users.role nie auf eine gelöschte Rolle zeigt.
+func DeleteRole(ctx context.Context, name string) (err error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	var builtin bool
+	err = tx.QueryRow(ctx,
+		`SELECT builtin FROM roles WHERE name=$1 FOR UPDATE`, name).Scan(&builtin)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrRoleNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if builtin {
+		return ErrRoleBuiltin
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM roles WHERE name=$1`, name); err != nil {
+		return err
+	}
+	// primäre Rolle der betroffenen User neu bestimmen
+	_, err = tx.Exec(ctx, `
+		UPDATE users u
+		   SET role = COALESCE((
+		         SELECT ur.role FROM user_roles ur
+		         WHERE ur.char_id = u.char_id
+		         ORDER BY CASE ur.role WHEN 'admin' THEN 0 WHEN 'provider' THEN 1 WHEN 'user' THEN 2 ELSE 3 END, ur.role
+		         LIMIT 1), 'user')
+		 WHERE u.role = $1`, name)
+	return err
+}
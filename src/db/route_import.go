@@ -0,0 +1,295 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"speedliner-server/src/utils/structs"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ExportRoutes liefert alle Routen inkl. Whitelist-Corps (IDs + Ticker).
+func ExportRoutes(ctx context.Context) ([]structs.RouteExport, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx, exportRoutesSQL)
+	if err != nil {
+		return nil, fmt.Errorf("ExportRoutes error: %w", err)
+	}
+	list, err := scanRouteExports(rows)
+	if err != nil {
+		return nil, fmt.Errorf("ExportRoutes error: %w", err)
+	}
+	return list, nil
+}
+
+const exportRoutesSQL = `
+	SELECT
+	    r.id,
+	    r.from_system,
+	    r.to_system,
+	    r.price_per_m3,
+	    r.no_collateral,
+	    r.visibility,
+	    r.min_price,
+	    COALESCE(array_agg(rv.corp_id ORDER BY rv.corp_id) FILTER (WHERE rv.corp_id IS NOT NULL), '{}'),
+	    COALESCE(array_agg(COALESCE(c.ticker, '') ORDER BY rv.corp_id) FILTER (WHERE rv.corp_id IS NOT NULL), '{}')
+	FROM
+	    routes r
+	LEFT JOIN
+	    route_visibility rv ON rv.route_id = r.id
+	LEFT JOIN
+	    corps c ON c.corp_id = rv.corp_id
+	GROUP BY
+	    r.id
+	ORDER BY
+	    r.from_system,
+	    r.to_system`
+
+func scanRouteExports(rows pgx.Rows) ([]structs.RouteExport, error) {
+	defer rows.Close()
+	list := []structs.RouteExport{}
+	for rows.Next() {
+		var it structs.RouteExport
+		if err := rows.Scan(&it.ID, &it.From, &it.To, &it.PricePerM3, &it.NoCollateral, &it.Visibility,
+			&it.MinPrice, &it.AllowedCorps, &it.AllowedCorpTickers); err != nil {
+			return nil, err
+		}
+		if len(it.AllowedCorps) == 0 {
+			it.AllowedCorps, it.AllowedCorpTickers = nil, nil
+		}
+		list = append(list, it)
+	}
+	return list, rows.Err()
+}
+
+// ImportRoutes plant den Abgleich der Routen mit rows und wendet ihn – außer bei dryRun – in einer
+// Transaktion an. Zuordnung per ID, sonst per Von/Nach (ohne Groß-/Kleinschreibung).
+// prune löscht Routen, die in rows nicht vorkommen. Bei Zeilenfehlern wird nichts geändert.
+func ImportRoutes(ctx context.Context, rows []structs.RouteImportRow, prune, dryRun bool) (plan structs.RouteImportPlan, err error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return plan, fmt.Errorf("ImportRoutes error: %w", err)
+	}
+	defer func() {
+		if err != nil || !plan.Applied {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	// Routen sperren, damit der Plan bis zum Commit gilt
+	if _, err = tx.Exec(ctx, `LOCK TABLE routes IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return plan, fmt.Errorf("ImportRoutes error: %w", err)
+	}
+	q, err := tx.Query(ctx, exportRoutesSQL)
+	if err != nil {
+		return plan, fmt.Errorf("ImportRoutes error: %w", err)
+	}
+	existing, err := scanRouteExports(q)
+	if err != nil {
+		return plan, fmt.Errorf("ImportRoutes error: %w", err)
+	}
+	tickers, corps, err := loadCorps(ctx, tx)
+	if err != nil {
+		return plan, fmt.Errorf("ImportRoutes error: %w", err)
+	}
+
+	plan = planRouteImport(existing, rows, tickers, corps, prune)
+	plan.DryRun = dryRun
+	if dryRun || len(plan.Errors) > 0 {
+		return plan, nil
+	}
+
+	for i := range plan.Changes {
+		ch := &plan.Changes[i]
+		switch ch.Action {
+		case "create":
+			err = insertRouteTx(ctx, tx, &ch.Route.Route)
+		case "update":
+			err = updateRouteTx(ctx, tx, ch.Route.Route)
+		case "delete":
+			_, err = tx.Exec(ctx, `DELETE FROM routes WHERE id = $1`, ch.Route.ID)
+		}
+		if err != nil {
+			return plan, fmt.Errorf("ImportRoutes error (line %d): %w", ch.Line, err)
+		}
+	}
+	plan.Applied = true
+	return plan, nil
+}
+
+// loadCorps: Ticker (uppercase) → Corp-ID sowie alle bekannten Corp-IDs → Ticker.
+// Ticker, die mehrfach vorkommen, sind mehrdeutig (ID 0).
+func loadCorps(ctx context.Context, tx pgx.Tx) (map[string]int64, map[int64]string, error) {
+	rows, err := tx.Query(ctx, `SELECT corp_id, COALESCE(ticker, '') FROM corps`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	tickers := map[string]int64{}
+	corps := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var ticker string
+		if err := rows.Scan(&id, &ticker); err != nil {
+			return nil, nil, err
+		}
+		corps[id] = ticker
+		if ticker == "" {
+			continue
+		}
+		key := strings.ToUpper(ticker)
+		if _, dup := tickers[key]; dup {
+			tickers[key] = 0
+			continue
+		}
+		tickers[key] = id
+	}
+	return tickers, corps, rows.Err()
+}
+
+func planRouteImport(existing []structs.RouteExport, rows []structs.RouteImportRow, tickers map[string]int64, corps map[int64]string, prune bool) structs.RouteImportPlan {
+	plan := structs.RouteImportPlan{Changes: []structs.RouteChange{}}
+	fail := func(line int, format string, args ...any) {
+		plan.Errors = append(plan.Errors, structs.RouteImportError{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	byID := map[string]int{}
+	byPair := map[string]int{}
+	for i, e := range existing {
+		byID[e.ID] = i
+		byPair[routePairKey(e.From, e.To)] = i
+	}
+
+	seen := map[int]int{} // Index in existing → Zeile
+	for _, row := range rows {
+		in := row.Route
+		ids, err := resolveRouteCorps(in, tickers, corps)
+		if err != nil {
+			fail(row.Line, "%v", err)
+			continue
+		}
+		in.AllowedCorps = ids
+		if err := ValidateRoute(&in.Route); err != nil {
+			fail(row.Line, "%v", err)
+			continue
+		}
+		in.AllowedCorpTickers = nil
+		for _, cid := range in.AllowedCorps {
+			in.AllowedCorpTickers = append(in.AllowedCorpTickers, corps[cid])
+		}
+		if len(in.AllowedCorps) == 0 {
+			in.AllowedCorps, in.AllowedCorpTickers = nil, nil
+		}
+
+		idx, found := -1, false
+		if in.ID != "" {
+			if idx, found = byID[in.ID]; !found {
+				fail(row.Line, "unknown route id %q", in.ID)
+				continue
+			}
+		} else {
+			idx, found = byPair[routePairKey(in.From, in.To)]
+		}
+		if found {
+			if prev, dup := seen[idx]; dup {
+				fail(row.Line, "route %s → %s already listed in line %d", in.From, in.To, prev)
+				continue
+			}
+			seen[idx] = row.Line
+			before := existing[idx]
+			in.ID = before.ID
+			action := "update"
+			if routeEqual(before, in) {
+				action = "unchanged"
+			}
+			plan.Changes = append(plan.Changes, structs.RouteChange{Action: action, Line: row.Line, Route: in, Before: &before})
+			continue
+		}
+
+		// neue Route: Von/Nach-Paar darf nicht doppelt in der Datei stehen
+		key := routePairKey(in.From, in.To)
+		dup := false
+		for _, ch := range plan.Changes {
+			if ch.Action == "create" && routePairKey(ch.Route.From, ch.Route.To) == key {
+				fail(row.Line, "route %s → %s already listed in line %d", in.From, in.To, ch.Line)
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			plan.Changes = append(plan.Changes, structs.RouteChange{Action: "create", Line: row.Line, Route: in})
+		}
+	}
+
+	if prune {
+		for i, e := range existing {
+			if _, ok := seen[i]; !ok {
+				plan.Changes = append(plan.Changes, structs.RouteChange{Action: "delete", Route: e})
+			}
+		}
+	}
+
+	for _, ch := range plan.Changes {
+		switch ch.Action {
+		case "create":
+			plan.Creates++
+		case "update":
+			plan.Updates++
+		case "delete":
+			plan.Deletes++
+		default:
+			plan.Unchanged++
+		}
+	}
+	return plan
+}
+
+// resolveRouteCorps vereint AllowedCorps und AllowedCorpTickers zu sortierten, eindeutigen Corp-IDs.
+func resolveRouteCorps(r structs.RouteExport, tickers map[string]int64, corps map[int64]string) ([]int64, error) {
+	ids := slices.Clone(r.AllowedCorps)
+	for _, t := range r.AllowedCorpTickers {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		cid, ok := tickers[t]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("unknown corp ticker %q", t)
+		case cid == 0:
+			return nil, fmt.Errorf("ambiguous corp ticker %q, use the corp id", t)
+		}
+		ids = append(ids, cid)
+	}
+	for _, cid := range ids {
+		if _, ok := corps[cid]; !ok {
+			return nil, fmt.Errorf("unknown corp id %d", cid)
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+func routePairKey(from, to string) string {
+	return strings.ToLower(strings.TrimSpace(from)) + "\x00" + strings.ToLower(strings.TrimSpace(to))
+}
+
+func routeEqual(a, b structs.RouteExport) bool {
+	corps := func(r structs.RouteExport) []int64 {
+		if r.Visibility != "whitelist" {
+			return nil
+		}
+		return r.AllowedCorps
+	}
+	return a.From == b.From && a.To == b.To &&
+		a.PricePerM3 == b.PricePerM3 && a.MinPrice == b.MinPrice &&
+		a.NoCollateral == b.NoCollateral && a.Visibility == b.Visibility &&
+		slices.Equal(corps(a), corps(b))
+}
@@ -2,12 +2,57 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"speedliner-server/src/utils/structs"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
 )
 
-func InsertRoute(r structs.Route) (err error) {
-	ctx := context.Background()
+// DefaultMinPrice: Mindestpreis, wenn nichts/0 geliefert wird.
+const DefaultMinPrice = 50_000_000
+
+var ErrInvalidRoute = errors.New("invalid route")
+
+// ValidateRoute normalisiert r (Trim, Defaults) und prüft die Regeln für Anlegen/Ändern/Import.
+func ValidateRoute(r *structs.Route) error {
+	r.From = strings.TrimSpace(r.From)
+	r.To = strings.TrimSpace(r.To)
+	r.Visibility = strings.ToLower(strings.TrimSpace(r.Visibility))
+	if r.Visibility == "" {
+		r.Visibility = "all"
+	}
+	// Fallback auf Default 50 Mio, wenn nichts/0 geliefert
+	if r.MinPrice <= 0 {
+		r.MinPrice = DefaultMinPrice
+	}
+
+	switch {
+	case r.From == "" || r.To == "":
+		return fmt.Errorf("%w: from and to are required", ErrInvalidRoute)
+	case strings.EqualFold(r.From, r.To):
+		return fmt.Errorf("%w: from and to must differ", ErrInvalidRoute)
+	case r.PricePerM3 < 0 || math.IsNaN(r.PricePerM3) || math.IsInf(r.PricePerM3, 0):
+		return fmt.Errorf("%w: price_per_m3 must be >= 0", ErrInvalidRoute)
+	case math.IsNaN(r.MinPrice) || math.IsInf(r.MinPrice, 0):
+		return fmt.Errorf("%w: min_price must be a number", ErrInvalidRoute)
+	case r.Visibility != "all" && r.Visibility != "whitelist":
+		return fmt.Errorf("%w: visibility must be \"all\" or \"whitelist\"", ErrInvalidRoute)
+	}
+	if r.Visibility == "all" {
+		r.AllowedCorps = nil
+	}
+	return nil
+}
+
+func InsertRoute(ctx context.Context, r structs.Route) (err error) {
+	if err = ValidateRoute(&r); err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
 	tx, err := Pool.Begin(ctx)
 	if err != nil {
 		return err
@@ -19,40 +64,15 @@ func InsertRoute(r structs.Route) (err error) {
 			err = tx.Commit(ctx)
 		}
 	}()
+	return insertRouteTx(ctx, tx, &r)
+}
 
-	// Fallback auf Default 50 Mio, wenn nichts/0 geliefert
-	minPrice := r.MinPrice
-	if minPrice == 0 || minPrice < 0 {
-		minPrice = 50_000_000
-	}
-	if minPrice < 0 {
-		return fmt.Errorf("min_price must be >= 0")
-	}
-
-	row := tx.QueryRow(ctx, `
-        INSERT INTO routes (from_system, to_system, price_per_m3, no_collateral, visibility, min_price)
-        VALUES ($1,$2,$3,$4,$5,$6)
-        RETURNING id`,
-		r.From, r.To, r.PricePerM3, r.NoCollateral, r.Visibility, minPrice,
-	)
-	if err = row.Scan(&r.ID); err != nil {
+func UpdateRoute(ctx context.Context, r structs.Route) (err error) {
+	if err = ValidateRoute(&r); err != nil {
 		return err
 	}
-
-	if r.Visibility == "whitelist" && len(r.AllowedCorps) > 0 {
-		for _, cid := range r.AllowedCorps {
-			if _, err = tx.Exec(ctx, `
-                INSERT INTO route_visibility (route_id, corp_id)
-                VALUES ($1,$2) ON CONFLICT DO NOTHING`, r.ID, cid); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
-}
-
-func UpdateRoute(r structs.Route) (err error) {
-	ctx := context.Background()
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
 	tx, err := Pool.Begin(ctx)
 	if err != nil {
 		return err
@@ -64,12 +84,25 @@ func UpdateRoute(r structs.Route) (err error) {
 			err = tx.Commit(ctx)
 		}
 	}()
+	return updateRouteTx(ctx, tx, r)
+}
 
-	if r.MinPrice == 0 || r.MinPrice < 0 {
-		r.MinPrice = 50_000_000
+// insertRouteTx legt eine bereits validierte Route an und setzt r.ID.
+func insertRouteTx(ctx context.Context, tx pgx.Tx, r *structs.Route) error {
+	row := tx.QueryRow(ctx, `
+        INSERT INTO routes (from_system, to_system, price_per_m3, no_collateral, visibility, min_price)
+        VALUES ($1,$2,$3,$4,$5,$6)
+        RETURNING id`,
+		r.From, r.To, r.PricePerM3, r.NoCollateral, r.Visibility, r.MinPrice,
+	)
+	if err := row.Scan(&r.ID); err != nil {
+		return err
 	}
+	return setRouteVisibilityTx(ctx, tx, *r)
+}
 
-	if _, err = tx.Exec(ctx, `
+func updateRouteTx(ctx context.Context, tx pgx.Tx, r structs.Route) error {
+	if _, err := tx.Exec(ctx, `
         UPDATE routes
            SET from_system=$2,
                to_system=$3,
@@ -81,27 +114,32 @@ func UpdateRoute(r structs.Route) (err error) {
 		r.ID, r.From, r.To, r.PricePerM3, r.NoCollateral, r.Visibility, r.MinPrice); err != nil {
 		return err
 	}
-
-	if _, err = tx.Exec(ctx, `DELETE FROM route_visibility WHERE route_id=$1`, r.ID); err != nil {
+	if _, err := tx.Exec(ctx, `DELETE FROM route_visibility WHERE route_id=$1`, r.ID); err != nil {
 		return err
 	}
-	if r.Visibility == "whitelist" && len(r.AllowedCorps) > 0 {
-		for _, cid := range r.AllowedCorps {
-			if _, err = tx.Exec(ctx, `
+	return setRouteVisibilityTx(ctx, tx, r)
+}
+
+func setRouteVisibilityTx(ctx context.Context, tx pgx.Tx, r structs.Route) error {
+	if r.Visibility != "whitelist" {
+		return nil
+	}
+	for _, cid := range r.AllowedCorps {
+		if _, err := tx.Exec(ctx, `
                 INSERT INTO route_visibility (route_id, corp_id)
                 VALUES ($1,$2) ON CONFLICT DO NOTHING`, r.ID, cid); err != nil {
-				return err
-			}
+			return err
 		}
 	}
 	return nil
 }
 
-func GetAllRoutesForUser(charID *int64, role string) ([]structs.Route, error) {
-	ctx := context.Background()
+func GetAllRoutesForUser(ctx context.Context, charID *int64, seeAll bool) ([]structs.Route, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
 
-	// Provider/Admin? -> ungefiltert
-	if role == "admin" || role == "provider" {
+	// Recht routes.view_all (Provider/Admin)? -> ungefiltert
+	if seeAll {
 		rows, err := Pool.Query(ctx, `
              SELECT 
                  r.id, 
@@ -209,7 +247,56 @@ func GetAllRoutesForUser(charID *int64, role string) ([]structs.Route, error) {
 	return list, rows.Err()
 }
 
-func DeleteRoute(id string) error {
-	_, err := Pool.Exec(context.Background(), `DELETE FROM routes WHERE id = $1`, id)
+func DeleteRoute(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := Pool.Exec(ctx, `DELETE FROM routes WHERE id = $1`, id)
 	return err
 }
+
+// GetRouteForUser liefert eine einzelne Route, sofern sie für den User sichtbar ist (sonst nil).
+func GetRouteForUser(ctx context.Context, id string, charID *int64, seeAll bool) (*structs.Route, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var cid int64
+	if charID != nil {
+		cid = *charID
+	}
+	var it structs.Route
+	err := Pool.QueryRow(ctx, `
+        SELECT 
+            r.id, 
+            r.from_system, 
+            r.to_system, 
+            r.price_per_m3, 
+            r.no_collateral, 
+            r.visibility, 
+            r.min_price
+		FROM 
+		    routes r
+		WHERE 
+		    r.id::text = $1
+		  AND (
+		        $3
+		     OR r.visibility='all'
+		     OR (r.visibility='whitelist' AND EXISTS (
+				  SELECT 1 
+				  FROM 
+				      route_visibility rv
+				  JOIN 
+				      users u ON u.corp_id = rv.corp_id
+				  WHERE 
+				      rv.route_id=r.id 
+				    AND 
+				      u.char_id=$2
+				)))`, id, cid, seeAll).
+		Scan(&it.ID, &it.From, &it.To, &it.PricePerM3, &it.NoCollateral, &it.Visibility, &it.MinPrice)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &it, nil
+}
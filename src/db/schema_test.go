@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"speedliner-server/src/config"
+	"speedliner-server/src/testenv/pgtest"
+	"speedliner-server/src/utils/structs"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// pgSkip: Grund, warum die Schema-Tests nicht laufen (keine Postgres-Binaries).
+var pgSkip string
+
+func TestMain(m *testing.M) {
+	os.Exit(runWithPostgres(m))
+}
+
+func runWithPostgres(m *testing.M) int {
+	ctx := context.Background()
+	pg, err := pgtest.Start(ctx)
+	if errors.Is(err, pgtest.ErrNoPostgres) {
+		pgSkip = err.Error()
+		return m.Run()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pgtest: %v\n", err)
+		return 1
+	}
+	defer pg.Stop()
+
+	cfg := config.Default().Database
+	cfg.URL = pg.URL
+	if err := InitDB(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "InitDB: %v\n%s", err, pg.Log())
+		return 1
+	}
+	defer Close()
+	return m.Run()
+}
+
+func requirePostgres(t *testing.T) context.Context {
+	t.Helper()
+	if pgSkip != "" {
+		t.Skip(pgSkip)
+	}
+	return context.Background()
+}
+
+// wantCheck: err ist eine Verletzung der Constraint name (CHECK oder UNIQUE).
+func wantCheck(t *testing.T, err error, name string) {
+	t.Helper()
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		t.Fatalf("want violation of %s, got %v", name, err)
+	}
+	if pgErr.ConstraintName != name {
+		t.Fatalf("want violation of %s, got %s (%s)", name, pgErr.ConstraintName, pgErr.Message)
+	}
+}
+
+func mustExec(t *testing.T, ctx context.Context, sql string, args ...any) {
+	t.Helper()
+	if _, err := Pool.Exec(ctx, sql, args...); err != nil {
+		t.Fatalf("%s: %v", sql, err)
+	}
+}
+
+func TestEnsureSchemaIdempotent(t *testing.T) {
+	ctx := requirePostgres(t)
+	if err := ensureSchema(); err != nil {
+		t.Fatalf("second ensureSchema: %v", err)
+	}
+	v, err := AppliedSchemaVersion(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != SchemaVersion {
+		t.Errorf("schema version %d, want %d", v, SchemaVersion)
+	}
+}
+
+func TestRouteConstraints(t *testing.T) {
+	ctx := requirePostgres(t)
+
+	_, err := Pool.Exec(ctx, `INSERT INTO routes (from_system, to_system, price_per_m3, visibility) VALUES ('Jita','Perimeter',1,'secret')`)
+	wantCheck(t, err, "routes_visibility_chk")
+
+	_, err = Pool.Exec(ctx, `INSERT INTO routes (from_system, to_system, price_per_m3, min_price) VALUES ('Jita','Perimeter',1,-1)`)
+	wantCheck(t, err, "routes_min_price_nonneg")
+
+	// gleiche Systeme in beliebiger Schreibweise
+	for _, to := range []string{"Amarr", "amarr", " AMARR "} {
+		err := InsertRoute(ctx, structs.Route{From: "Amarr", To: to, PricePerM3: 1})
+		if !errors.Is(err, ErrInvalidRoute) {
+			t.Errorf("Amarr → %q: want ErrInvalidRoute, got %v", to, err)
+		}
+	}
+}
+
+func TestProviderDutyConstraint(t *testing.T) {
+	ctx := requirePostgres(t)
+	mustExec(t, ctx, `INSERT INTO users (char_id, name) VALUES (93000001,'Duty Test') ON CONFLICT DO NOTHING`)
+	t.Cleanup(func() { _, _ = Pool.Exec(ctx, `DELETE FROM users WHERE char_id = 93000001`) })
+
+	_, err := Pool.Exec(ctx, `INSERT INTO provider_profiles (char_id, max_m3, on_duty) VALUES (93000001, 0, true)`)
+	wantCheck(t, err, "provider_profiles_duty_chk")
+
+	// Alt-Bestand: ohne Constraint angelegtes Profil geht beim Nachziehen außer Dienst
+	mustExec(t, ctx, `ALTER TABLE provider_profiles DROP CONSTRAINT provider_profiles_duty_chk`)
+	mustExec(t, ctx, `INSERT INTO provider_profiles (char_id, max_m3, on_duty) VALUES (93000001, 0, true)`)
+	if err := ensureSchema(); err != nil {
+		t.Fatalf("ensureSchema: %v", err)
+	}
+	var onDuty bool
+	if err := Pool.QueryRow(ctx, `SELECT on_duty FROM provider_profiles WHERE char_id = 93000001`).Scan(&onDuty); err != nil {
+		t.Fatal(err)
+	}
+	if onDuty {
+		t.Error("profile without capacity still on duty after migration")
+	}
+	_, err = Pool.Exec(ctx, `UPDATE provider_profiles SET on_duty = true WHERE char_id = 93000001`)
+	wantCheck(t, err, "provider_profiles_duty_chk")
+}
+
+func TestRouteRatesUniqueTarget(t *testing.T) {
+	ctx := requirePostgres(t)
+	const corp = 98900001
+	t.Cleanup(func() { _, _ = Pool.Exec(ctx, `DELETE FROM route_rates WHERE corp_id = $1`, corp) })
+
+	// Alt-Bestand mit Dubletten (globale Rate, route_id NULL): beim Anlegen des Index bleibt die jüngste
+	mustExec(t, ctx, `DROP INDEX route_rates_target_uq`)
+	mustExec(t, ctx, `INSERT INTO route_rates (corp_id, discount_pct, created_at) VALUES ($1, 5, now() - interval '1 day')`, corp)
+	mustExec(t, ctx, `INSERT INTO route_rates (corp_id, discount_pct, created_at) VALUES ($1, 10, now())`, corp)
+	if err := ensureSchema(); err != nil {
+		t.Fatalf("ensureSchema: %v", err)
+	}
+	var n int
+	var pct float64
+	if err := Pool.QueryRow(ctx, `SELECT count(*), max(discount_pct) FROM route_rates WHERE corp_id = $1`, corp).Scan(&n, &pct); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || pct != 10 {
+		t.Fatalf("after dedupe: %d rates, discount %v; want 1 rate with 10", n, pct)
+	}
+
+	_, err := Pool.Exec(ctx, `INSERT INTO route_rates (corp_id, discount_pct) VALUES ($1, 7)`, corp)
+	wantCheck(t, err, "route_rates_target_uq")
+
+	// UpsertRouteRate ersetzt die Rate für dasselbe Ziel
+	c, d := int64(corp), 15.0
+	out, err := UpsertRouteRate(ctx, structs.RouteRate{CorpID: &c, DiscountPct: &d, Note: "renegotiated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.DiscountPct == nil || *out.DiscountPct != 15 || out.Note != "renegotiated" {
+		t.Errorf("upsert returned %+v", out)
+	}
+	if err := Pool.QueryRow(ctx, `SELECT count(*) FROM route_rates WHERE corp_id = $1`, corp).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("%d rates after upsert, want 1", n)
+	}
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"speedliner-server/src/utils/reqctx"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// queryTimeout: Obergrenze pro db-Funktion (DB_QUERY_TIMEOUT, 0 = nur Request-Context).
+var queryTimeout = 10 * time.Second
+
+// withTimeout begrenzt ctx auf queryTimeout; eine kürzere Deadline des Aufrufers bleibt bestehen.
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, queryTimeout)
+}
+
+type traceStartKey struct{}
+
+type traceStart struct {
+	at  time.Time
+	sql string
+}
+
+// slowQueryTracer loggt Statements ab threshold mit Dauer und Request-ID (aus LoggerMiddleware).
+type slowQueryTracer struct {
+	threshold time.Duration
+}
+
+func (t slowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, traceStartKey{}, traceStart{at: time.Now(), sql: data.SQL})
+}
+
+func (t slowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(traceStartKey{}).(traceStart)
+	if !ok {
+		return
+	}
+	d := time.Since(start.at)
+	if d < t.threshold {
+		return
+	}
+	attrs := []any{
+		"duration_ms", d.Milliseconds(),
+		"sql", compactSQL(start.sql),
+		"rows", data.CommandTag.RowsAffected(),
+	}
+	if id := reqctx.RequestID(ctx); id != "" {
+		attrs = append(attrs, "req.id", id)
+	}
+	if data.Err != nil {
+		attrs = append(attrs, "error", data.Err)
+	}
+	slog.Warn("slow query", attrs...)
+}
+
+// compactSQL: Whitespace zusammenziehen und auf eine Log-Zeile kürzen.
+func compactSQL(sql string) string {
+	s := strings.Join(strings.Fields(sql), " ")
+	if len(s) > 300 {
+		s = s[:300] + "…"
+	}
+	return s
+}
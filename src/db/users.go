@@ -2,21 +2,44 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"speedliner-server/src/utils/structs"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
-func UpsertUser(charID int64, name string) error {
-	_, err := Pool.Exec(context.Background(), `
+func UpsertUser(ctx context.Context, charID int64, name string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := Pool.Exec(ctx, `
 		INSERT INTO users (char_id, name)
 		VALUES ($1, $2)
 		ON CONFLICT (char_id) DO UPDATE SET name = EXCLUDED.name;`,
 		charID, name)
+	if err != nil {
+		return err
+	}
+	if _, err = Pool.Exec(ctx, ensureAccountSQL, charID); err != nil {
+		return err
+	}
+	// neue Mains bekommen die Standardrolle (Regeln überschreiben sie bei der Auswertung),
+	// Alts erben die Rollen ihres Accounts
+	_, err = Pool.Exec(ctx, `
+		INSERT INTO user_roles (char_id, role, source)
+		SELECT $1, 'user', 'default'
+		WHERE EXISTS (SELECT 1 FROM accounts WHERE main_char_id = $1)
+		  AND NOT EXISTS (SELECT 1 FROM user_roles WHERE char_id = $1);`, charID)
 	return err
 }
 
-func GetUserRoles(charID int64) (string, error) {
-	row := Pool.QueryRow(context.Background(),
+func GetUserRoles(ctx context.Context, charID int64) (string, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	row := Pool.QueryRow(ctx,
 		`SELECT role FROM users WHERE char_id = $1;`, charID)
 	var role string
 	if err := row.Scan(&role); err != nil {
@@ -25,18 +48,17 @@ func GetUserRoles(charID int64) (string, error) {
 	return role, nil
 }
 
-func UpdateUserRole(charID string, role string) error {
-	query := `UPDATE users SET role = $1 WHERE char_id = $2;`
-	_, err := Pool.Exec(context.Background(), query, role, charID)
-	if err != nil {
-		return fmt.Errorf("UpdateUserRole error: %w", err)
-	}
-	return nil
-}
-
-func GetAllUsers() ([]structs.User, error) {
-	rows, err := Pool.Query(context.Background(),
-		`SELECT char_id, name, role FROM users ORDER BY name;`)
+func GetAllUsers(ctx context.Context) ([]structs.User, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx,
+		`SELECT u.char_id, u.name, u.role,
+		        COALESCE(ARRAY(SELECT ur.role FROM user_roles ur
+		                       WHERE ur.char_id = COALESCE(a.main_char_id, u.char_id) ORDER BY 1), '{}'),
+		        a.main_char_id
+		 FROM users u
+		 LEFT JOIN accounts a ON a.id = u.account_id
+		 ORDER BY u.name;`)
 	if err != nil {
 		return nil, fmt.Errorf("GetAllUsers query error: %w", err)
 	}
@@ -45,7 +67,7 @@ func GetAllUsers() ([]structs.User, error) {
 	var users []structs.User
 	for rows.Next() {
 		var u structs.User
-		if err := rows.Scan(&u.CharID, &u.Name, &u.Role); err != nil {
+		if err := rows.Scan(&u.CharID, &u.Name, &u.Role, &u.Roles, &u.MainCharID); err != nil {
 			return nil, fmt.Errorf("GetAllUsers scan error: %w", err)
 		}
 		users = append(users, u)
@@ -53,8 +75,10 @@ func GetAllUsers() ([]structs.User, error) {
 	return users, rows.Err()
 }
 
-func UpsertAlliance(id int64, name, ticker string) error {
-	_, err := Pool.Exec(context.Background(),
+func UpsertAlliance(ctx context.Context, id int64, name, ticker string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := Pool.Exec(ctx,
 		`INSERT INTO alliances (alliance_id,name,ticker)
 		 VALUES ($1,$2,$3)
 		 ON CONFLICT (alliance_id) DO UPDATE
@@ -62,8 +86,10 @@ func UpsertAlliance(id int64, name, ticker string) error {
 	return err
 }
 
-func UpsertCorp(id int64, name, ticker string, allianceID *int64) error {
-	_, err := Pool.Exec(context.Background(),
+func UpsertCorp(ctx context.Context, id int64, name, ticker string, allianceID *int64) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := Pool.Exec(ctx,
 		`INSERT INTO corps (corp_id,name,ticker,alliance_id)
 		 VALUES ($1,$2,$3,$4)
 		 ON CONFLICT (corp_id) DO UPDATE
@@ -72,8 +98,238 @@ func UpsertCorp(id int64, name, ticker string, allianceID *int64) error {
 	return err
 }
 
-func UpdateUserCorp(charID, corpID int64) error {
-	_, err := Pool.Exec(context.Background(),
+func UpdateUserCorp(ctx context.Context, charID, corpID int64) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := Pool.Exec(ctx,
 		`UPDATE users SET corp_id=$2 WHERE char_id=$1`, charID, corpID)
 	return err
 }
+
+// ListUserIDs liefert alle Char-IDs (für den Affiliation-Refresh).
+func ListUserIDs(ctx context.Context) ([]int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	rows, err := Pool.Query(ctx, `SELECT char_id FROM users ORDER BY char_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Sortierspalten für SearchUsers (Whitelist, landet direkt im SQL)
+var userSortCols = map[string]string{
+	"name":       "v.name",
+	"corp":       "v.corp_ticker",
+	"alliance":   "v.alliance_ticker",
+	"role":       "v.role",
+	"last_login": "v.last_login_at",
+	"last_seen":  "v.last_seen_at",
+	"created":    "v.created_at",
+}
+
+const userListCols = `v.char_id, v.name, v.role,
+	COALESCE(ARRAY(SELECT ur.role FROM user_roles ur
+	               WHERE ur.char_id = COALESCE(v.main_char_id, v.char_id) ORDER BY 1), '{}'),
+	v.main_char_id, v.corp_id, v.corp_name, v.corp_ticker,
+	v.alliance_id, v.alliance_name, v.alliance_ticker,
+	v.quarantined, v.banned_at, v.banned_reason, v.last_login_at, v.created_at, v.last_seen_at`
+
+func scanUserListItem(row pgx.Row) (structs.UserListItem, error) {
+	var u structs.UserListItem
+	var charID int64
+	err := row.Scan(&charID, &u.Name, &u.Role, &u.Roles, &u.MainCharID,
+		&u.CorpID, &u.CorpName, &u.CorpTicker, &u.AllianceID, &u.AllianceName, &u.AllianceTicker,
+		&u.Quarantined, &u.BannedAt, &u.BannedReason, &u.LastLoginAt, &u.CreatedAt, &u.LastSeenAt)
+	u.CharID = strconv.FormatInt(charID, 10)
+	return u, err
+}
+
+// SearchUsers: Suche (Name, Corp-/Alliance-Ticker), Filter, Sortierung und Paging über v_users_enriched.
+func SearchUsers(ctx context.Context, q structs.UserQuery) ([]structs.UserListItem, int, error) {
+	var where []string
+	var args []any
+	if s := strings.TrimSpace(q.Search); s != "" {
+		args = append(args, "%"+s+"%")
+		n := len(args)
+		where = append(where, fmt.Sprintf(
+			"(v.name ILIKE $%d OR v.corp_ticker ILIKE $%d OR v.alliance_ticker ILIKE $%d)", n, n, n))
+	}
+	if q.Role != "" {
+		args = append(args, q.Role)
+		where = append(where, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM user_roles ur WHERE ur.char_id = COALESCE(v.main_char_id, v.char_id) AND ur.role = $%d)", len(args)))
+	}
+	if q.Banned != nil {
+		if *q.Banned {
+			where = append(where, "v.banned_at IS NOT NULL")
+		} else {
+			where = append(where, "v.banned_at IS NULL")
+		}
+	}
+	cond := ""
+	if len(where) > 0 {
+		cond = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var total int
+	if err := Pool.QueryRow(ctx, `SELECT count(*) FROM v_users_enriched v `+cond, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("SearchUsers count error: %w", err)
+	}
+
+	order, ok := userSortCols[q.Sort]
+	if !ok {
+		order = userSortCols["name"]
+	}
+	dir := "ASC"
+	if q.Desc {
+		dir = "DESC"
+	}
+	args = append(args, q.PerPage, (q.Page-1)*q.PerPage)
+	rows, err := Pool.Query(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM v_users_enriched v
+		%s
+		ORDER BY %s %s NULLS LAST, v.name, v.char_id
+		LIMIT $%d OFFSET $%d`, userListCols, cond, order, dir, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("SearchUsers query error: %w", err)
+	}
+	defer rows.Close()
+
+	items := []structs.UserListItem{}
+	for rows.Next() {
+		u, err := scanUserListItem(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("SearchUsers scan error: %w", err)
+		}
+		items = append(items, u)
+	}
+	return items, total, rows.Err()
+}
+
+// GetUserDetail: User inkl. Account-Chars, Token-Status und Promo-Einlösungen. nil = unbekannt.
+// Verknüpfte Aufträge gibt es nicht: Contracts laufen im Spiel, das Schema hat keine orders-Tabelle
+// (backup.go sichert nur eine evtl. vorhandene Alt-Tabelle). Promo-Einlösungen sind die einzige Spur.
+func GetUserDetail(ctx context.Context, charID int64) (*structs.UserDetail, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	item, err := scanUserListItem(Pool.QueryRow(ctx,
+		`SELECT `+userListCols+` FROM v_users_enriched v WHERE v.char_id = $1`, charID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetUserDetail error: %w", err)
+	}
+	d := structs.UserDetail{UserListItem: item, Characters: []structs.AccountCharacter{}, Redemptions: []structs.PromoRedemption{}}
+
+	if err := Pool.QueryRow(ctx, `SELECT banned_by FROM users WHERE char_id=$1`, charID).Scan(&d.BannedBy); err != nil {
+		return nil, fmt.Errorf("GetUserDetail ban error: %w", err)
+	}
+	if d.Token, err = GetTokenStatus(ctx, charID); err != nil {
+		return nil, err
+	}
+	if acc, err := GetAccountForChar(ctx, charID); err != nil {
+		return nil, err
+	} else if acc != nil {
+		d.Characters = acc.Characters
+	}
+
+	rows, err := Pool.Query(ctx, `
+		SELECT pr.code, pr.route_id::text, r.from_system || ' ↔ ' || r.to_system, pr.redeemed_at
+		FROM promo_redemptions pr
+		LEFT JOIN routes r ON r.id = pr.route_id
+		WHERE pr.char_id = $1
+		ORDER BY pr.redeemed_at DESC
+		LIMIT 100`, charID)
+	if err != nil {
+		return nil, fmt.Errorf("GetUserDetail redemptions error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var it structs.PromoRedemption
+		if err := rows.Scan(&it.Code, &it.RouteID, &it.Route, &it.RedeemedAt); err != nil {
+			return nil, err
+		}
+		d.Redemptions = append(d.Redemptions, it)
+	}
+	return &d, rows.Err()
+}
+
+// GetTokenStatus liest Ablauf/Refresh-Fähigkeit des gespeicherten ESI-Tokens, ohne ihn herauszugeben.
+func GetTokenStatus(ctx context.Context, charID int64) (structs.TokenStatus, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var ts structs.TokenStatus
+	var updated time.Time
+	var expiry *time.Time
+	var refreshable bool
+	err := Pool.QueryRow(ctx, `
+		SELECT updated_at,
+		       NULLIF(token_json::jsonb->>'expiry', '')::timestamptz,
+		       COALESCE(token_json::jsonb->>'refresh_token', '') <> ''
+		FROM oauth_tokens WHERE char_id = $1`, strconv.FormatInt(charID, 10)).
+		Scan(&updated, &expiry, &refreshable)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ts, nil
+	}
+	if err != nil {
+		return ts, fmt.Errorf("GetTokenStatus error: %w", err)
+	}
+	ts.HasToken, ts.Refreshable, ts.Expiry, ts.UpdatedAt = true, refreshable, expiry, &updated
+	return ts, nil
+}
+
+// BanUser sperrt einen Char (Login + API). false = unbekannter Char.
+func BanUser(ctx context.Context, charID int64, reason string, bannedBy int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tag, err := Pool.Exec(ctx, `
+		UPDATE users SET banned_at=now(), banned_reason=$2, banned_by=$3 WHERE char_id=$1`,
+		charID, reason, bannedBy)
+	if err != nil {
+		return false, fmt.Errorf("BanUser error: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func UnbanUser(ctx context.Context, charID int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	tag, err := Pool.Exec(ctx, `
+		UPDATE users SET banned_at=NULL, banned_reason='', banned_by=NULL WHERE char_id=$1`, charID)
+	if err != nil {
+		return false, fmt.Errorf("UnbanUser error: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// IsUserBanned: gesperrt ist ein Char, wenn er selbst oder der Main seines Accounts gesperrt ist.
+func IsUserBanned(ctx context.Context, charID int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var banned bool
+	err := Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM users u
+			LEFT JOIN accounts a ON a.id = u.account_id
+			LEFT JOIN users m    ON m.char_id = a.main_char_id
+			WHERE u.char_id = $1
+			  AND (u.banned_at IS NOT NULL OR m.banned_at IS NOT NULL)
+		)`, charID).Scan(&banned)
+	return banned, err
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+	"speedliner-server/src/utils/access"
+	"strconv"
+
+	db2 "speedliner-server/src/db"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetAccessPolicyHandler godoc
+// @Summary      Zugangs-Policy
+// @Description  Aktive Allow-List/Quarantäne-Einstellungen (nur lesend, kommt aus der Umgebung).
+// @Tags         Access
+// @Produce      json
+// @Success      200 {object} access.Policy
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Router       /app/v1/access/policy [get]
+func GetAccessPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, access.Current())
+}
+
+// ListLoginDenialsHandler godoc
+// @Summary      Abgewiesene Logins
+// @Description  Abgewiesene und in Quarantäne genommene Logins, neueste zuerst.
+// @Tags         Access
+// @Produce      json
+// @Param        pending query bool false "nur ungeprüfte"
+// @Param        limit   query int  false "max. Einträge (Standard 100)"
+// @Success      200 {array}  structs.LoginDenial
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/access/denials [get]
+func ListLoginDenialsHandler(w http.ResponseWriter, r *http.Request) {
+	pending, _ := strconv.ParseBool(r.URL.Query().Get("pending"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	list, err := db2.ListLoginDenials(r.Context(), pending, limit)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
+
+// ReviewLoginDenialHandler godoc
+// @Summary      Abweisung als geprüft markieren
+// @Tags         Access
+// @Param        id path int true "Denial ID"
+// @Success      204 {string} string "No Content"
+// @Failure      400 {object} structs.ErrorResponse "Invalid id"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/access/denials/{id}/review [post]
+func ReviewLoginDenialHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid id")
+		return
+	}
+	if err := db2.MarkLoginDenialReviewed(r.Context(), id, changedBy(r)); err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReleaseUserHandler godoc
+// @Summary      Quarantäne aufheben
+// @Description  Der Char bleibt trotz Allow-List zugelassen. Nur für Benutzer, deren Rechte der Aufrufer selbst hat.
+// @Tags         Access
+// @Param        charID path string true "Character ID"
+// @Success      204 {string} string "No Content"
+// @Failure      400 {object} structs.ErrorResponse "Invalid charID"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "User has permissions you do not have"
+// @Failure      404 {object} structs.ErrorResponse "User not found"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/users/{charID}/release [post]
+func ReleaseUserHandler(w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseInt(chi.URLParam(r, "charID"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid charID")
+		return
+	}
+	if !canManageUser(w, r, charID) {
+		return
+	}
+	ok, err := db2.ReleaseUser(r.Context(), charID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	if !ok {
+		jsonError(w, r, http.StatusNotFound, "User not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"speedliner-server/src/middleware"
+	"speedliner-server/src/utils/esiauth"
+	"speedliner-server/src/utils/structs"
+	"speedliner-server/src/utils/users"
+	"strconv"
+	"time"
+
+	db2 "speedliner-server/src/db"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	linkStatePrefix = "link:"
+	linkStateCookie = "link_state"
+)
+
+// GetMyAccountHandler godoc
+// @Summary      Eigener Account
+// @Description  Main, Alts und aktiver Char; je Char, ob ein ESI-Token gespeichert ist.
+// @Tags         Account
+// @Produce      json
+// @Success      200 {object} structs.Account
+// @Failure      401 {object} structs.ErrorResponse "Not logged in"
+// @Failure      404 {object} structs.ErrorResponse "No account"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/account [get]
+func GetMyAccountHandler(w http.ResponseWriter, r *http.Request) {
+	me := loggedInChar(r)
+	if me == nil {
+		jsonError(w, r, http.StatusUnauthorized, "Not logged in")
+		return
+	}
+	acc, err := db2.GetAccountForChar(r.Context(), *me)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	if acc == nil {
+		jsonError(w, r, http.StatusNotFound, "No account")
+		return
+	}
+	for i := range acc.Characters {
+		_, acc.Characters[i].HasToken = esiauth.LoadToken(r.Context(), strconv.FormatInt(acc.Characters[i].CharID, 10))
+	}
+	writeJSON(w, r, http.StatusOK, acc)
+}
+
+// LinkCharacterHandler godoc
+// @Summary      Char verknüpfen
+// @Description  Zweiter SSO-Login; der neue Char wird Alt des eingeloggten Accounts. Ergebnis per Redirect (?link=ok|self|taken|has_alts|failed).
+// @Tags         Account
+// @Success      302 {string} string "Redirect"
+// @Failure      401 {object} structs.ErrorResponse "Not logged in"
+// @Failure      429 {object} structs.ErrorResponse "Rate limit (RateLimit-*, Retry-After)"
+// @Router       /app/v1/account/link [get]
+func LinkCharacterHandler(w http.ResponseWriter, r *http.Request) {
+	if loggedInChar(r) == nil {
+		jsonError(w, r, http.StatusUnauthorized, "Not logged in")
+		return
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		jsonError(w, r, http.StatusInternalServerError, "State error")
+		return
+	}
+	nonce := hex.EncodeToString(b[:])
+	middleware.SetCookie(w, &http.Cookie{
+		Name:     linkStateCookie,
+		Value:    nonce,
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  time.Now().Add(10 * time.Minute),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, esiauth.GetOAuthConfig().AuthCodeURL(linkStatePrefix+nonce), http.StatusFound)
+}
+
+// linkResult verknüpft nach dem SSO-Callback und liefert den Status fürs Frontend (?link=...).
+func linkResult(ctx context.Context, mainID, altID int64) string {
+	if mainID == altID {
+		return "self"
+	}
+	err := db2.LinkCharacter(ctx, mainID, altID)
+	switch {
+	case errors.Is(err, db2.ErrCharLinkedElsewhere):
+		return "taken"
+	case errors.Is(err, db2.ErrCharHasAlts):
+		return "has_alts"
+	case err != nil:
+		log.Printf("LinkCharacter %d -> %d: %v", altID, mainID, err)
+		return "failed"
+	}
+	return "ok"
+}
+
+// SwitchActiveCharHandler godoc
+// @Summary      Aktiven Char wechseln
+// @Description  Z.B. zum Mail-Versand mit einem Alt; der Char braucht einen gespeicherten ESI-Token.
+// @Tags         Account
+// @Accept       json
+// @Param        char body structs.SwitchCharReq true "Char"
+// @Success      204 {string} string "No Content"
+// @Failure      401 {object} structs.ErrorResponse "Not logged in"
+// @Failure      403 {object} structs.ErrorResponse "Character not linked to your account"
+// @Failure      409 {object} structs.ErrorResponse "No token for character"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/account/active [post]
+func SwitchActiveCharHandler(w http.ResponseWriter, r *http.Request) {
+	me := loggedInChar(r)
+	if me == nil {
+		jsonError(w, r, http.StatusUnauthorized, "Not logged in")
+		return
+	}
+	var req structs.SwitchCharReq
+	if err := decodeJSON(r, &req); err != nil {
+		badJSON(w, r, err)
+		return
+	}
+	ok, err := db2.SameAccount(r.Context(), *me, req.CharID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	if !ok {
+		jsonError(w, r, http.StatusForbidden, "Character not linked to your account")
+		return
+	}
+	charIDStr := strconv.FormatInt(req.CharID, 10)
+	if _, ok := esiauth.LoadToken(r.Context(), charIDStr); !ok {
+		jsonError(w, r, http.StatusConflict, "No token for character, log in with it again")
+		return
+	}
+	setCharCookie(w, charIDStr)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnlinkCharacterHandler godoc
+// @Summary      Alt lösen
+// @Description  Löst einen Alt aus dem Account; war er aktiv, wird der Main wieder aktiv.
+// @Tags         Account
+// @Param        charID path string true "Character ID"
+// @Success      204 {string} string "No Content"
+// @Failure      400 {object} structs.ErrorResponse "Invalid charID"
+// @Failure      401 {object} structs.ErrorResponse "Not logged in"
+// @Failure      404 {object} structs.ErrorResponse "Character not linked to your account"
+// @Failure      409 {object} structs.ErrorResponse "Main character cannot be unlinked"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/account/characters/{charID} [delete]
+func UnlinkCharacterHandler(w http.ResponseWriter, r *http.Request) {
+	me := loggedInChar(r)
+	if me == nil {
+		jsonError(w, r, http.StatusUnauthorized, "Not logged in")
+		return
+	}
+	altID, err := strconv.ParseInt(chi.URLParam(r, "charID"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid charID")
+		return
+	}
+	mainID, err := db2.MainCharID(r.Context(), *me)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+
+	err = db2.UnlinkCharacter(r.Context(), *me, altID)
+	switch {
+	case errors.Is(err, db2.ErrCharNotInAccount):
+		jsonError(w, r, http.StatusNotFound, "Character not linked to your account")
+		return
+	case errors.Is(err, db2.ErrCannotUnlinkMain):
+		jsonError(w, r, http.StatusConflict, "Main character cannot be unlinked")
+		return
+	case err != nil:
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	if err := users.ApplyRoleRules(r.Context(), altID); err != nil {
+		log.Printf("ApplyRoleRules %d: %v", altID, err)
+	}
+	// war der Alt aktiv, zurück auf den Main
+	if *me == altID {
+		setCharCookie(w, strconv.FormatInt(mainID, 10))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
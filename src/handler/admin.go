@@ -1,64 +1,518 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
+	"regexp"
 	"speedliner-server/src/db"
 	"speedliner-server/src/utils/structs"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 )
 
 // ListUsersHandler godoc
-// @Summary      Alle Benutzer abrufen
-// @Description  Gibt eine Liste aller Benutzer mit deren Rollen zurück (nur Admin).
+// @Summary      Benutzer suchen
+// @Description  Paginierte Benutzerliste inkl. Corp/Alliance; Suche über Name, Corp- oder Alliance-Ticker.
 // @Tags         Admin
 // @Produce      json
-// @Success      200 {array} structs.User
-// @Failure      401 {string} string "Unauthorized"
-// @Failure      403 {string} string "Forbidden"
-// @Failure      500 {string} string "DB error"
-// @Router       /app/users [get]
+// @Param        q        query string false "Suchbegriff (Name, Corp-/Alliance-Ticker)"
+// @Param        role     query string false "nur Benutzer mit dieser Rolle"
+// @Param        banned   query bool   false "nur gesperrte (true) bzw. nicht gesperrte (false)"
+// @Param        sort     query string false "name | corp | alliance | role | last_login | last_seen | created"
+// @Param        order    query string false "asc | desc"
+// @Param        page     query int    false "Seite (ab 1)"
+// @Param        per_page query int    false "Einträge pro Seite (max. 200)"
+// @Success      200 {object} structs.UserPage
+// @Failure      400 {object} structs.ErrorResponse "Invalid query"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/users [get]
 func ListUsersHandler(w http.ResponseWriter, r *http.Request) {
-	users, err := db.GetAllUsers()
+	qs := r.URL.Query()
+	q := structs.UserQuery{
+		Search:  qs.Get("q"),
+		Role:    qs.Get("role"),
+		Sort:    qs.Get("sort"),
+		Desc:    strings.EqualFold(qs.Get("order"), "desc"),
+		Page:    1,
+		PerPage: 50,
+	}
+	if v := qs.Get("banned"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			jsonError(w, r, http.StatusBadRequest, "Invalid banned filter")
+			return
+		}
+		q.Banned = &b
+	}
+	if v := qs.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			jsonError(w, r, http.StatusBadRequest, "Invalid page")
+			return
+		}
+		q.Page = n
+	}
+	if v := qs.Get("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 200 {
+			jsonError(w, r, http.StatusBadRequest, "per_page must be 1..200")
+			return
+		}
+		q.PerPage = n
+	}
+
+	items, total, err := db.SearchUsers(r.Context(), q)
 	if err != nil {
-		http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(users)
+	writeJSON(w, r, http.StatusOK, structs.UserPage{Items: items, Total: total, Page: q.Page, PerPage: q.PerPage})
+}
+
+// GetUserDetailHandler godoc
+// @Summary      Benutzer-Details
+// @Description  Letzter Login, Token-Status, verknüpfte Chars und eingelöste Promo-Codes eines Benutzers.
+// @Description  Aufträge werden serverseitig nicht gespeichert (keine orders-Tabelle); eingelöste Promo-Codes sind die einzige Auftragsspur.
+// @Tags         Admin
+// @Produce      json
+// @Param        charID path string true "Character ID"
+// @Success      200 {object} structs.UserDetail
+// @Failure      400 {object} structs.ErrorResponse "Invalid charID"
+// @Failure      404 {object} structs.ErrorResponse "User not found"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/users/{charID} [get]
+func GetUserDetailHandler(w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseInt(chi.URLParam(r, "charID"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid charID")
+		return
+	}
+	d, err := db.GetUserDetail(r.Context(), charID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	if d == nil {
+		jsonError(w, r, http.StatusNotFound, "User not found")
+		return
+	}
+	writeJSON(w, r, http.StatusOK, d)
+}
+
+// BanUserHandler godoc
+// @Summary      Benutzer sperren
+// @Description  Sperrt Login und API-Zugriff eines Chars (bei einem Main für den ganzen Account).
+// @Tags         Admin
+// @Accept       json
+// @Param        charID path string true "Character ID"
+// @Param        ban body structs.BanReq false "Grund"
+// @Success      204 {string} string "No Content"
+// @Failure      400 {object} structs.ErrorResponse "Invalid charID"
+// @Failure      403 {object} structs.ErrorResponse "User has permissions you do not have"
+// @Failure      404 {object} structs.ErrorResponse "User not found"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/users/{charID}/ban [post]
+func BanUserHandler(w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseInt(chi.URLParam(r, "charID"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid charID")
+		return
+	}
+	var req structs.BanReq
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			jsonError(w, r, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+	}
+	by := changedBy(r)
+	if by == charID {
+		jsonError(w, r, http.StatusBadRequest, "Cannot ban yourself")
+		return
+	}
+	if !canManageUser(w, r, charID) {
+		return
+	}
+	ok, err := db.BanUser(r.Context(), charID, strings.TrimSpace(req.Reason), by)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	if !ok {
+		jsonError(w, r, http.StatusNotFound, "User not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnbanUserHandler godoc
+// @Summary      Sperre aufheben
+// @Tags         Admin
+// @Param        charID path string true "Character ID"
+// @Success      204 {string} string "No Content"
+// @Failure      400 {object} structs.ErrorResponse "Invalid charID"
+// @Failure      403 {object} structs.ErrorResponse "User has permissions you do not have"
+// @Failure      404 {object} structs.ErrorResponse "User not found"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/users/{charID}/ban [delete]
+func UnbanUserHandler(w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseInt(chi.URLParam(r, "charID"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid charID")
+		return
+	}
+	if !canManageUser(w, r, charID) {
+		return
+	}
+	ok, err := db.UnbanUser(r.Context(), charID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	if !ok {
+		jsonError(w, r, http.StatusNotFound, "User not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // UpdateUserRoleHandler godoc
 // @Summary      Rolle eines Benutzers ändern
-// @Description  Setzt die Rolle eines Benutzers anhand der charID (nur Admin).
+// @Description  Setzt die Rolle eines Benutzers anhand der charID (Recht users.manage; nur Rollen, deren Rechte man selbst hat).
 // @Tags         Admin
 // @Accept       json
 // @Produce      plain
 // @Param        charID path string true "Character ID"
 // @Param        role body structs.UpdateRoleReq true "Neue Rolle"
 // @Success      204 {string} string "No Content"
-// @Failure      400 {string} string "Invalid JSON or role"
-// @Failure      401 {string} string "Unauthorized"
-// @Failure      403 {string} string "Forbidden"
-// @Failure      500 {string} string "DB error"
-// @Router       /app/users/{charID}/role [put]
+// @Failure      400 {object} structs.ErrorResponse "Invalid JSON or role"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/users/{charID}/role [put]
 func UpdateUserRoleHandler(w http.ResponseWriter, r *http.Request) {
-	charID := chi.URLParam(r, "charID")
+	charID, err := strconv.ParseInt(chi.URLParam(r, "charID"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid charID")
+		return
+	}
 
 	var req structs.UpdateRoleReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if !canAssignRoles(w, r, charID, []string{req.Role}) {
+		return
+	}
+
+	if err := db.SetUserRoles(r.Context(), charID, []string{req.Role}, changedBy(r)); err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateUserRolesHandler godoc
+// @Summary      Alle Rollen eines Benutzers setzen
+// @Description  Ersetzt die Rollen eines Benutzers anhand der charID (Recht users.manage; nur Rollen, deren Rechte man selbst hat).
+// @Tags         Admin
+// @Accept       json
+// @Produce      plain
+// @Param        charID path string true "Character ID"
+// @Param        roles body structs.UpdateUserRolesReq true "Neue Rollen"
+// @Success      204 {string} string "No Content"
+// @Failure      400 {object} structs.ErrorResponse "Invalid JSON or role"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/users/{charID}/roles [put]
+func UpdateUserRolesHandler(w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseInt(chi.URLParam(r, "charID"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid charID")
+		return
+	}
+
+	var req structs.UpdateUserRolesReq
+	if err := decodeJSON(r, &req); err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if !canAssignRoles(w, r, charID, req.Roles) {
+		return
+	}
+
+	if err := db.SetUserRoles(r.Context(), charID, req.Roles, changedBy(r)); err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
 		return
 	}
-	if !structs.AllowedRoles[req.Role] {
-		http.Error(w, "Invalid role", http.StatusBadRequest)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListRolesHandler godoc
+// @Summary      Rollen abrufen
+// @Description  Alle Rollen mit ihren Rechten.
+// @Tags         Admin
+// @Produce      json
+// @Success      200 {array} structs.Role
+// @Router       /app/v1/roles [get]
+func ListRolesHandler(w http.ResponseWriter, r *http.Request) {
+	roles, err := db.ListRoles(r.Context())
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
 		return
 	}
+	writeJSON(w, r, http.StatusOK, roles)
+}
+
+// ListPermissionsHandler godoc
+// @Summary      Bekannte Rechte abrufen
+// @Tags         Admin
+// @Produce      json
+// @Success      200 {array} string
+// @Router       /app/v1/permissions [get]
+func ListPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, structs.AllPermissions)
+}
 
-	if err := db.UpdateUserRole(charID, req.Role); err != nil {
-		http.Error(w, "DB error: "+err.Error(), http.StatusInternalServerError)
+var roleNameRe = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// UpsertRoleHandler godoc
+// @Summary      Rolle anlegen oder ändern
+// @Description  Setzt Beschreibung und Rechte einer Rolle. "admin" ist unveränderlich.
+// @Description  Vergeben und ändern lassen sich nur Rechte, die der Aufrufer selbst hat.
+// @Tags         Admin
+// @Accept       json
+// @Param        name path string true "Rollenname"
+// @Param        role body structs.Role true "Rolle"
+// @Success      204 {string} string "No Content"
+// @Failure      400 {object} structs.ErrorResponse "Invalid role"
+// @Failure      403 {object} structs.ErrorResponse "Permissions the caller does not have"
+// @Router       /app/v1/roles/{name} [put]
+func UpsertRoleHandler(w http.ResponseWriter, r *http.Request) {
+	var role structs.Role
+	if err := decodeJSON(r, &role); err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	role.Name = chi.URLParam(r, "name")
+	if !roleNameRe.MatchString(role.Name) {
+		jsonError(w, r, http.StatusBadRequest, "Invalid role name")
+		return
+	}
+	for _, p := range role.Permissions {
+		if !structs.IsKnownPermission(p) {
+			jsonError(w, r, http.StatusBadRequest, "Unknown permission: "+p)
+			return
+		}
+	}
+	// keine Rechte vergeben, die man selbst nicht hat; bestehende Rollen nur, wenn man sie ganz hält
+	if !canGrantPermissions(w, r, role.Permissions, "Role grants permissions you do not have") ||
+		!canEditRole(w, r, role.Name) {
+		return
+	}
+
+	if err := db.UpsertRole(r.Context(), role); errors.Is(err, db.ErrRoleBuiltin) {
+		jsonError(w, r, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// DeleteRoleHandler godoc
+// @Summary      Rolle löschen
+// @Description  Löscht eine selbst definierte Rolle samt Zuweisungen.
+// @Tags         Admin
+// @Param        name path string true "Rollenname"
+// @Success      204 {string} string "No Content"
+// @Failure      400 {object} structs.ErrorResponse "Builtin role"
+// @Failure      403 {object} structs.ErrorResponse "Permissions the caller does not have"
+// @Failure      404 {object} structs.ErrorResponse "Not found"
+// @Router       /app/v1/roles/{name} [delete]
+func DeleteRoleHandler(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if !canEditRole(w, r, name) {
+		return
+	}
+	err := db.DeleteRole(r.Context(), name)
+	switch {
+	case errors.Is(err, db.ErrRoleNotFound):
+		jsonError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, db.ErrRoleBuiltin):
+		jsonError(w, r, http.StatusBadRequest, err.Error())
+	case err != nil:
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// canEditRole: eine bestehende Rolle ändern/löschen darf nur, wer alle ihre Rechte hat (neue Rollen: true).
+func canEditRole(w http.ResponseWriter, r *http.Request, name string) bool {
+	all, err := db.ListRoles(r.Context())
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return false
+	}
+	for _, role := range all {
+		if role.Name == name {
+			return canGrantPermissions(w, r, role.Permissions, "Role "+name+" has permissions you do not have")
+		}
+	}
+	return true
+}
+
+// changedBy: Char-ID des handelnden Admins fürs Protokoll (0 = unbekannt)
+func changedBy(r *http.Request) int64 {
+	if me, _ := currentUser(r); me != nil {
+		return *me
+	}
+	return 0
+}
+
+// canAssignRoles: Rollen von target ändern darf nur, wer selbst alle Rechte der neuen Rollen und
+// des Ziel-Accounts hat – sonst könnte sich users.manage admin geben oder Admins entmachten.
+// Bei false ist die Antwort (400/403) bereits geschrieben.
+func canAssignRoles(w http.ResponseWriter, r *http.Request, target int64, roles []string) bool {
+	return canGrantRoles(w, r, roles) && canManageUser(w, r, target)
+}
+
+// canGrantRoles: alle Rollen existieren und der Aufrufer hat jedes ihrer Rechte selbst (sonst 400/403).
+func canGrantRoles(w http.ResponseWriter, r *http.Request, roles []string) bool {
+	if len(roles) == 0 {
+		return true
+	}
+	all, err := db.ListRoles(r.Context())
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return false
+	}
+	rolePerms := make(map[string][]string, len(all))
+	for _, role := range all {
+		rolePerms[role.Name] = role.Permissions
+	}
+	for _, role := range roles {
+		perms, ok := rolePerms[role]
+		if !ok {
+			jsonError(w, r, http.StatusBadRequest, "Invalid role: "+role)
+			return false
+		}
+		if !canGrantPermissions(w, r, perms, "Role "+role+" grants permissions you do not have") {
+			return false
+		}
+	}
+	return true
+}
+
+// canGrantPermissions: der Aufrufer hat alle perms selbst, sonst 403 mit msg.
+func canGrantPermissions(w http.ResponseWriter, r *http.Request, perms []string, msg string) bool {
+	_, mine := currentUser(r)
+	for _, p := range perms {
+		if !mine[p] {
+			jsonError(w, r, http.StatusForbidden, msg)
+			return false
+		}
+	}
+	return true
+}
+
+// canManageUser: Sperren, Freigeben und Rollenänderungen nur an Accounts, deren Rechte der
+// Aufrufer selbst alle hat (sonst 403). Zählt die Rollen auch bei gesperrten Accounts.
+func canManageUser(w http.ResponseWriter, r *http.Request, target int64) bool {
+	current, err := db.GetGrantedPermissions(r.Context(), target)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return false
+	}
+	return canGrantPermissions(w, r, current, "User has permissions you do not have")
+}
+
+// ListLoginEventsHandler godoc
+// @Summary      Login-Historie eines Benutzers
+// @Tags         Admin
+// @Produce      json
+// @Param        charID path  string true  "Character ID"
+// @Param        limit  query int    false "max. Einträge (Standard 100)"
+// @Success      200 {array} structs.LoginEvent
+// @Failure      400 {object} structs.ErrorResponse "Invalid charID"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/users/{charID}/logins [get]
+func ListLoginEventsHandler(w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseInt(chi.URLParam(r, "charID"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid charID")
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	list, err := db.ListLoginEvents(r.Context(), charID, limit)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
+
+// ListInactiveUsersHandler godoc
+// @Summary      Inaktive Benutzer
+// @Description  Benutzer ohne Aktivität (last_seen, last_login, created) seit `days` Tagen.
+// @Tags         Admin
+// @Produce      json
+// @Param        days  query int false "Tage ohne Aktivität (Standard 30)"
+// @Param        limit query int false "max. Einträge (Standard 100)"
+// @Success      200 {array} structs.UserListItem
+// @Failure      400 {object} structs.ErrorResponse "Invalid days"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/users/inactive [get]
+func ListInactiveUsersHandler(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			jsonError(w, r, http.StatusBadRequest, "Invalid days")
+			return
+		}
+		days = n
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	list, err := db.ListInactiveUsers(r.Context(), days, limit)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
+
+// SuspiciousLoginsHandler godoc
+// @Summary      Auffällige Logins
+// @Description  IPs mit Logins mehrerer Accounts und Chars mit vielen IPs im Zeitfenster.
+// @Tags         Admin
+// @Produce      json
+// @Param        hours        query int false "Zeitfenster in Stunden (Standard 24)"
+// @Param        min_accounts query int false "ab wie vielen Accounts pro IP (Standard 3)"
+// @Param        min_ips      query int false "ab wie vielen IPs pro Char (Standard 5)"
+// @Success      200 {array} structs.SuspiciousLogin
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/logins/suspicious [get]
+func SuspiciousLoginsHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	atLeast := func(key string, def int) int {
+		if n, err := strconv.Atoi(qs.Get(key)); err == nil && n > 0 {
+			return n
+		}
+		return def
+	}
+	list, err := db.SuspiciousLogins(r.Context(), atLeast("hours", 24), atLeast("min_accounts", 3), atLeast("min_ips", 5))
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"speedliner-server/src/middleware"
+	"speedliner-server/src/utils/apitokens"
+	"speedliner-server/src/utils/structs"
+	"strings"
+	"time"
+
+	db2 "speedliner-server/src/db"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// sessionChar: Token-Verwaltung nur aus der Browser-Session, nicht mit einem Token selbst.
+func sessionChar(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id := middleware.IdentityFrom(r.Context())
+	if id == nil {
+		jsonError(w, r, http.StatusUnauthorized, "Not logged in")
+		return 0, false
+	}
+	if id.Token != nil {
+		jsonError(w, r, http.StatusForbidden, "API tokens cannot manage tokens")
+		return 0, false
+	}
+	return id.CharID, true
+}
+
+// ListMyAPITokensHandler godoc
+// @Summary      Eigene API-Tokens
+// @Description  Listet die API-Tokens des eingeloggten Chars (ohne Klartext)
+// @Tags         Tokens
+// @Produce      json
+// @Success      200 {array} structs.APIToken
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      403 {object} structs.ErrorResponse
+// @Router       /app/v1/tokens [get]
+func ListMyAPITokensHandler(w http.ResponseWriter, r *http.Request) {
+	charID, ok := sessionChar(w, r)
+	if !ok {
+		return
+	}
+	list, err := db2.ListAPITokens(r.Context(), charID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
+
+// maxRatePerMin: Obergrenze für eigene Token-Limits mit users.manage
+const maxRatePerMin = 6000
+
+// CreateAPITokenHandler godoc
+// @Summary      API-Token anlegen
+// @Description  Der Klartext-Token wird nur in dieser Antwort geliefert; Nutzung per "Authorization: Bearer <token>".
+// @Description  ratePerMin höchstens API_TOKEN_RATE_PER_MIN (Standard 60), mit users.manage bis 6000.
+// @Tags         Tokens
+// @Accept       json
+// @Produce      json
+// @Param        token body structs.CreateAPITokenReq true "Name, Scopes, Limits"
+// @Success      201 {object} structs.CreatedAPIToken
+// @Failure      400 {object} structs.ErrorResponse
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      403 {object} structs.ErrorResponse
+// @Router       /app/v1/tokens [post]
+func CreateAPITokenHandler(w http.ResponseWriter, r *http.Request) {
+	charID, ok := sessionChar(w, r)
+	if !ok {
+		return
+	}
+	var req structs.CreateAPITokenReq
+	if err := decodeJSON(r, &req); err != nil {
+		badJSON(w, r, err)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 64 {
+		jsonError(w, r, http.StatusBadRequest, "name required (max 64 chars)")
+		return
+	}
+	scopes := []string{}
+	seen := map[string]bool{}
+	for _, s := range req.Scopes {
+		if !structs.IsKnownPermission(s) {
+			jsonError(w, r, http.StatusBadRequest, "Unknown scope: "+s)
+			return
+		}
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	if req.RatePerMin != nil {
+		// über dem Standard-Limit nur mit users.manage
+		limit := apitokens.DefaultRatePerMin()
+		if _, mine := currentUser(r); mine[structs.PermUsersManage] {
+			limit = maxRatePerMin
+		}
+		if *req.RatePerMin < 1 || *req.RatePerMin > limit {
+			jsonError(w, r, http.StatusBadRequest, fmt.Sprintf("%s must be 1..%d", field(r, "ratePerMin"), limit))
+			return
+		}
+	}
+	t := structs.APIToken{CharID: charID, Name: req.Name, Scopes: scopes, RatePerMin: req.RatePerMin}
+	if req.ExpiresInDays != nil {
+		if *req.ExpiresInDays < 1 || *req.ExpiresInDays > 3650 {
+			jsonError(w, r, http.StatusBadRequest, field(r, "expiresInDays")+" must be 1..3650")
+			return
+		}
+		exp := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		t.ExpiresAt = &exp
+	}
+
+	raw, prefix, hash, err := apitokens.Generate()
+	if err != nil {
+		jsonError(w, r, http.StatusInternalServerError, "Token generation failed")
+		return
+	}
+	t.Prefix = prefix
+	out, err := db2.InsertAPIToken(r.Context(), t, hash)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusCreated, structs.CreatedAPIToken{APIToken: out, Token: raw})
+}
+
+// RevokeAPITokenHandler godoc
+// @Summary      API-Token widerrufen
+// @Tags         Tokens
+// @Param        id path string true "Token ID"
+// @Success      204 {string} string "No Content"
+// @Failure      404 {object} structs.ErrorResponse
+// @Router       /app/v1/tokens/{id} [delete]
+func RevokeAPITokenHandler(w http.ResponseWriter, r *http.Request) {
+	charID, ok := sessionChar(w, r)
+	if !ok {
+		return
+	}
+	found, err := db2.RevokeAPIToken(r.Context(), chi.URLParam(r, "id"), charID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	if !found {
+		jsonError(w, r, http.StatusNotFound, "Token not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
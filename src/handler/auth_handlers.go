@@ -1,171 +1,284 @@
 package handler
 
 import (
-	"context"
 	"encoding/json"
 	"log"
 	"net/http"
+	"speedliner-server/src/utils/esi"
 	"strconv"
+	"strings"
 	"time"
 
 	db2 "speedliner-server/src/db"
-	"speedliner-server/src/utils/esi"
+	"speedliner-server/src/middleware"
+	"speedliner-server/src/utils/access"
 	"speedliner-server/src/utils/esiauth"
 	"speedliner-server/src/utils/structs"
+	"speedliner-server/src/utils/users"
 )
 
-// Health
+// PingHandler godoc
+// @Summary      Healthcheck
+// @Description  Gibt "pong" zurück
+// @Tags         System
+// @Produce      plain
+// @Success      200 {string} string "pong"
+// @Router       /app/v1/ping [get]
 func PingHandler(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("pong"))
 }
 
-// Login redirect
+// LoginHandler godoc
+// @Summary      Login redirect
+// @Description  Leitet zum ESI-Login um
+// @Tags         Auth
+// @Success      302 {string} string "Redirect"
+// @Failure      429 {object} structs.ErrorResponse "Rate limit (RateLimit-*, Retry-After)"
+// @Router       /app/v1/login [get]
 func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	oauth := esiauth.GetOAuthConfig()
 	url := oauth.AuthCodeURL("state-token")
 	http.Redirect(w, r, url, http.StatusFound)
 }
 
-// OAuth Callback —> speichert Token, setzt Cookie, resolved Corp/Alliance via Affiliation
+// CallbackHandler godoc
+// @Summary      ESI Callback
+// @Description  OAuth2 Callback: speichert Token, setzt Cookie, löst Corp/Alliance via Affiliation auf.
+// @Description  Mit Link-State (/account/link) wird der Char stattdessen als Alt an den eingeloggten Account gehängt.
+// @Tags         Auth
+// @Param        code  query string true  "Authorization Code"
+// @Param        state query string false "State (login oder link:<nonce>)"
+// @Success      302 {string} string "Redirect to home"
+// @Failure      400 {object} structs.ErrorResponse
+// @Failure      500 {object} structs.ErrorResponse
+// @Failure      429 {object} structs.ErrorResponse "Rate limit (RateLimit-*, Retry-After)"
+// @Router       /app/v1/callback [get]
 func CallbackHandler(w http.ResponseWriter, r *http.Request) {
 	oauth := esiauth.GetOAuthConfig()
 	code := r.URL.Query().Get("code")
 
-	token, err := oauth.Exchange(context.Background(), code)
+	var linkTo *int64
+	if state := r.URL.Query().Get("state"); strings.HasPrefix(state, linkStatePrefix) {
+		c, err := r.Cookie(linkStateCookie)
+		if err != nil || c.Value == "" || linkStatePrefix+c.Value != state {
+			jsonError(w, r, http.StatusBadRequest, "Invalid link state")
+			return
+		}
+		clearCookie(w, linkStateCookie)
+		if linkTo = loggedInChar(r); linkTo == nil {
+			jsonError(w, r, http.StatusUnauthorized, "Not logged in")
+			return
+		}
+	}
+
+	token, err := oauth.Exchange(r.Context(), code)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, "Token exchange failed: "+err.Error())
+		serverError(w, r, http.StatusInternalServerError, "Token exchange failed", err)
 		return
 	}
 
-	client := oauth.Client(context.Background(), token)
-	resp, err := client.Get("https://esi.evetech.net/verify")
+	client := oauth.Client(r.Context(), token)
+	resp, err := client.Get(esi.URL("/verify"))
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, "Verify failed: "+err.Error())
+		serverError(w, r, http.StatusInternalServerError, "Verify failed", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	var verify structs.VerifyResponse
 	if err := json.NewDecoder(resp.Body).Decode(&verify); err != nil {
-		jsonError(w, http.StatusInternalServerError, "Verify JSON error: "+err.Error())
+		serverError(w, r, http.StatusInternalServerError, "Verify JSON error", err)
 		return
 	}
 
-	// Token & Cookie
-	charIDStr := strconv.Itoa(verify.CharacterID)
-	esiauth.SaveToken(charIDStr, token)
-	http.SetCookie(w, &http.Cookie{
-		Name:     "char",
-		Value:    charIDStr,
-		Path:     "/",
-		HttpOnly: true,
-		Expires:  time.Now().Add(48 * time.Hour),
-		SameSite: http.SameSiteLaxMode,
-	})
-
 	charID := int64(verify.CharacterID)
 
-	// User upserten
-	if err := db2.UpsertUser(charID, verify.CharacterName); err != nil {
-		log.Printf("UpsertUser: %v", err)
+	// gesperrte Chars: kein Token, kein Cookie
+	if banned, err := db2.IsUserBanned(r.Context(), charID); err != nil {
+		log.Printf("IsUserBanned: %v", err)
+	} else if banned {
+		http.Redirect(w, r, "/?login=banned", http.StatusFound)
+		return
 	}
 
-	// ——— NEU: Zugehörigkeit via Affiliation (frisch) + Details resolven ———
-	corpID, corpName, corpTicker, alliID, alliName, alliTicker :=
-		esi.FetchCorpAndAlliance(verify.CharacterID)
-
-	// Alliance optional
-	var alliPtr *int64
-	if alliID != nil && *alliID != 0 {
-		var aName, aTick string
-		if alliName != nil {
-			aName = *alliName
+	// Zugehörigkeit frisch via Affiliation und gegen die Zugangs-Policy prüfen
+	aff := users.ResolveAffiliation(charID)
+	allowed, reason := users.CheckAccess(r.Context(), charID, aff)
+	if !allowed {
+		denial := structs.LoginDenial{
+			CharID:     charID,
+			CharName:   verify.CharacterName,
+			AllianceID: aff.AllianceID,
+			Action:     access.Current().DenyAction,
+			Reason:     reason,
+			IP:         middleware.ClientIP(r),
+			UserAgent:  r.UserAgent(),
 		}
-		if alliTicker != nil {
-			aTick = *alliTicker
+		if aff.CorpID != 0 {
+			denial.CorpID = &aff.CorpID
 		}
-		if err := db2.UpsertAlliance(*alliID, aName, aTick); err != nil {
-			log.Printf("UpsertAlliance: %v", err)
+		if err := db2.RecordLoginDenial(r.Context(), denial); err != nil {
+			log.Printf("RecordLoginDenial: %v", err)
+		}
+		// reject: kein Token, kein Cookie, kein User-Eintrag
+		if denial.Action == access.ActionReject {
+			http.Redirect(w, r, "/?login=denied", http.StatusFound)
+			return
 		}
-		alliPtr = alliID
 	}
 
-	// Corp + User setzen
-	if corpID != 0 {
-		if err := db2.UpsertCorp(corpID, corpName, corpTicker, alliPtr); err != nil {
-			log.Printf("UpsertCorp: %v", err)
-		}
-		if err := db2.UpdateUserCorp(charID, corpID); err != nil {
-			log.Printf("UpdateUserCorp: %v", err)
+	// Token & Cookie (beim Verknüpfen bleibt der aktive Char unverändert)
+	charIDStr := strconv.Itoa(verify.CharacterID)
+	esiauth.SaveToken(r.Context(), charIDStr, token)
+	if linkTo == nil {
+		setCharCookie(w, charIDStr)
+	}
+
+	// User upserten
+	if err := db2.UpsertUser(r.Context(), charID, verify.CharacterName); err != nil {
+		log.Printf("UpsertUser: %v", err)
+	}
+	kind := "login"
+	if linkTo != nil {
+		kind = "link"
+	}
+	if err := db2.RecordLogin(r.Context(), charID, kind, middleware.ClientIP(r), r.UserAgent()); err != nil {
+		log.Printf("RecordLogin: %v", err)
+	}
+	// quarantine: User existiert, hat aber bis zur Freigabe keine Rechte
+	if !allowed {
+		if _, err := db2.SetUserQuarantined(r.Context(), charID, true); err != nil {
+			log.Printf("SetUserQuarantined: %v", err)
 		}
 	}
 
+	// Corp/Alliance speichern + Rollen-Regeln auswerten
+	if err := users.SaveAffiliation(r.Context(), charID, aff); err != nil {
+		log.Printf("SaveAffiliation: %v", err)
+	}
+
+	if linkTo != nil {
+		http.Redirect(w, r, "/?link="+linkResult(r.Context(), *linkTo, charID), http.StatusFound)
+		return
+	}
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
-// /me – leichtgewichtig: nur Verify (keine ESI-Polllawine)
+func setCharCookie(w http.ResponseWriter, charID string) {
+	middleware.SetCookie(w, &http.Cookie{
+		Name:     "char",
+		Value:    charID,
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  time.Now().Add(48 * time.Hour),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearCookie(w http.ResponseWriter, name string) {
+	middleware.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+	})
+}
+
+// MeHandler godoc
+// @Summary      User Info
+// @Description  Charakter-ID und Name aus dem Cookie – leichtgewichtig, nur Verify (keine ESI-Polllawine)
+// @Tags         Auth
+// @Produce      json
+// @Success      200 {object} structs.VerifyResponse
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      500 {object} structs.ErrorResponse
+// @Router       /app/v1/me [get]
 func MeHandler(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("char")
 	if err != nil || cookie.Value == "" {
-		jsonError(w, http.StatusUnauthorized, "Not logged in")
+		jsonError(w, r, http.StatusUnauthorized, "Not logged in")
 		return
 	}
 
-	token, ok := esiauth.LoadToken(cookie.Value)
+	token, ok := esiauth.LoadToken(r.Context(), cookie.Value)
 	if !ok {
-		jsonError(w, http.StatusUnauthorized, "No token for user")
+		jsonError(w, r, http.StatusUnauthorized, "No token for user")
 		return
 	}
 
-	client := esiauth.GetOAuthConfig().Client(context.Background(), token)
-	resp, err := client.Get("https://esi.evetech.net/verify")
+	client := esiauth.GetOAuthConfig().Client(r.Context(), token)
+	resp, err := client.Get(esi.URL("/verify"))
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, "Verify failed: "+err.Error())
+		serverError(w, r, http.StatusInternalServerError, "Verify failed", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	var verify structs.VerifyResponse
 	if err := json.NewDecoder(resp.Body).Decode(&verify); err != nil {
-		jsonError(w, http.StatusInternalServerError, "Verify JSON error: "+err.Error())
+		serverError(w, r, http.StatusInternalServerError, "Verify JSON error", err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, verify)
+	writeJSON(w, r, http.StatusOK, verify)
 }
 
+// LogoutHandler godoc
+// @Summary      Logout
+// @Description  Löscht den Auth-Cookie und entfernt das Token aus dem Speicher
+// @Tags         Auth
+// @Success      204 {string} string "No Content"
+// @Router       /app/v1/logout [get]
 func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	if c, err := r.Cookie("char"); err == nil && c.Value != "" {
 		esiauth.DeleteToken()
 	}
-	http.SetCookie(w, &http.Cookie{
-		Name:     "char",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		Expires:  time.Unix(0, 0),
-		MaxAge:   -1,
-	})
+	clearCookie(w, "char")
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// /role
-func GetUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+// GetUserRoleHandler godoc
+// @Summary      Rollen und Rechte abrufen
+// @Description  Primäre Rolle, alle Rollen, effektive Rechte und Quarantäne-Status des eingeloggten Chars
+// @Tags         Auth
+// @Produce      json
+// @Success      200 {object} structs.RoleResponse
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      500 {object} structs.ErrorResponse
+// @Router       /app/v1/role [get]
+func (h *Handler) GetUserRoleHandler(w http.ResponseWriter, r *http.Request) {
 	c, err := r.Cookie("char")
 	if err != nil || c.Value == "" {
-		jsonError(w, http.StatusUnauthorized, "Not logged in")
+		jsonError(w, r, http.StatusUnauthorized, "Not logged in")
 		return
 	}
 	charID, err := strconv.ParseInt(c.Value, 10, 64)
 	if err != nil {
-		jsonError(w, http.StatusUnauthorized, "Bad char id")
+		jsonError(w, r, http.StatusUnauthorized, "Bad char id")
 		return
 	}
 
-	role, err := db2.GetUserRoles(charID)
+	role, err := h.users.PrimaryRole(r.Context(), charID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	roles, err := h.users.RoleNames(r.Context(), charID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	perms, err := h.users.Permissions(r.Context(), charID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	quarantined, err := h.users.IsQuarantined(r.Context(), charID)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, "DB error: "+err.Error())
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]string{"role": role})
+	writeJSON(w, r, http.StatusOK, structs.RoleResponse{Role: role, Roles: roles, Permissions: perms, Quarantined: quarantined})
 }
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"speedliner-server/src/middleware"
+	"speedliner-server/src/utils/backup"
+)
+
+// ListBackupsHandler godoc
+// @Summary      Backups auflisten
+// @Description  Archive im Backup-Verzeichnis (BACKUP_DIR), neueste zuerst. Einspielen nur per CLI (backup restore).
+// @Tags         Admin
+// @Produce      json
+// @Success      200 {array} structs.BackupInfo
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      403 {object} structs.ErrorResponse
+// @Failure      500 {object} structs.ErrorResponse
+// @Router       /app/v1/backups [get]
+func ListBackupsHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := backup.List(cfg.Backup.Dir)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to list backups", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
+
+// CreateBackupHandler godoc
+// @Summary      Backup anlegen
+// @Description  Exportiert die Fachtabellen (ohne OAuth-/API-Tokens) als JSON-Archiv nach BACKUP_DIR und räumt
+// @Description  ältere Archive ab (BACKUP_KEEP).
+// @Tags         Admin
+// @Produce      json
+// @Success      201 {object} structs.BackupInfo
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      403 {object} structs.ErrorResponse
+// @Failure      500 {object} structs.ErrorResponse
+// @Router       /app/v1/backups [post]
+func CreateBackupHandler(w http.ResponseWriter, r *http.Request) {
+	info, err := backup.Create(r.Context(), cfg.Backup.Dir, cfg.Backup.Keep)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Backup failed", err)
+		return
+	}
+	var by int64
+	if charID, _ := currentUser(r); charID != nil {
+		by = *charID
+	}
+	slog.Info("backup created", "file", info.Name, "size", info.Size, "by", by, "req.id", middleware.RequestID(r))
+	writeJSON(w, r, http.StatusCreated, info)
+}
@@ -1,17 +1,121 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
+	"log/slog"
 	"net/http"
+	"speedliner-server/src/config"
+	"speedliner-server/src/middleware"
+	"speedliner-server/src/utils/access"
+	"speedliner-server/src/utils/apijson"
+	"speedliner-server/src/utils/reqctx"
+	"speedliner-server/src/utils/structs"
+	"strconv"
+	"sync"
 )
 
-// einheitliche JSON-Antworten
-func writeJSON(w http.ResponseWriter, status int, v any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(v)
+// cfg: beim Start validierte Konfiguration (SetConfig); Default nur bis dahin.
+var cfg = func() *config.Config { c := config.Default(); return &c }()
+
+// SetConfig übergibt die geladene Konfiguration an die Handler.
+func SetConfig(c *config.Config) {
+	cfg = c
+}
+
+// backgroundCtx: Lebensdauer der Server-Instanz für Hintergrundjobs aus Handlern (endet beim Shutdown).
+// background zählt die laufenden Jobs, damit der Shutdown vor db.Close() auf sie wartet.
+var (
+	backgroundCtx = context.Background()
+	background    sync.WaitGroup
+)
+
+// SetBackgroundContext setzt den Context, mit dem Handler-Hintergrundjobs beim Shutdown abbrechen.
+func SetBackgroundContext(ctx context.Context) {
+	backgroundCtx = ctx
+}
+
+// WaitBackground blockiert, bis alle Handler-Hintergrundjobs beendet sind.
+func WaitBackground() {
+	background.Wait()
+}
+
+// einheitliche JSON-Antworten (unter /app/v1 mit snake_case-Feldern)
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	apijson.Write(w, r, status, v)
+}
+
+// jsonError schreibt den Fehler-Envelope {"error": {code, message, details, request_id}}.
+func jsonError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	apijson.Error(w, r, status, msg, nil)
+}
+
+// serverError loggt die Ursache und liefert dem Client nur msg – DB-/ESI-Interna bleiben im Log.
+func serverError(w http.ResponseWriter, r *http.Request, status int, msg string, err error) {
+	slog.Error(msg, "error", err, "req.id", middleware.RequestID(r), "req.path", r.URL.Path)
+	apijson.Error(w, r, status, msg, nil)
+}
+
+// upstreamError: Fehler einer Gegenstelle (ESI); die Antwort der Gegenstelle landet nur im Log,
+// der Client bekommt den Status.
+func upstreamError(w http.ResponseWriter, r *http.Request, msg string, resp *http.Response, body []byte) {
+	slog.Error(msg, "upstream_status", resp.StatusCode, "upstream_body", string(body),
+		"req.id", middleware.RequestID(r), "req.path", r.URL.Path)
+	apijson.Error(w, r, http.StatusBadGateway, msg, map[string]any{
+		"upstream_status": resp.StatusCode,
+	})
+}
+
+func badJSON(w http.ResponseWriter, r *http.Request, err error) {
+	apijson.Error(w, r, http.StatusBadRequest, "Invalid JSON", err.Error())
+}
+
+// field: JSON-Feldname für Fehlermeldungen, wie ihn der Client schreibt (snake_case unter /app/v1).
+func field(r *http.Request, name string) string {
+	if reqctx.IsAPIV1(r.Context()) {
+		return apijson.ToSnake(name)
+	}
+	return name
+}
+
+func decodeJSON(r *http.Request, v any) error {
+	return apijson.Decode(r, v)
+}
+
+// currentUser liefert den Char aus Cookie oder API-Token (nil = anonym) samt effektiver Rechte.
+func currentUser(r *http.Request) (*int64, structs.PermissionSet) {
+	id := middleware.IdentityFrom(r.Context())
+	if id == nil {
+		return nil, structs.PermissionSet{}
+	}
+	perms, err := id.Permissions(r.Context())
+	if err != nil {
+		perms = structs.PermissionSet{}
+	}
+	return &id.CharID, perms
+}
+
+// loggedInChar liest nur die Char-ID aus dem Cookie (nil = anonym); für reine Browser-Abläufe.
+func loggedInChar(r *http.Request) *int64 {
+	c, err := r.Cookie("char")
+	if err != nil || c.Value == "" {
+		return nil
+	}
+	v, err := strconv.ParseInt(c.Value, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &v
 }
 
-func jsonError(w http.ResponseWriter, status int, msg string) {
-	http.Error(w, msg, status)
+// canSeeRoutes: Routen/Preise sind nur öffentlich, solange ALLOW_ANONYMOUS_ROUTES nicht abgeschaltet ist.
+// Sonst braucht es einen eingeloggten Char außerhalb der Quarantäne.
+func (h *Handler) canSeeRoutes(ctx context.Context, charID *int64) bool {
+	if access.Current().AllowAnonymousRoutes {
+		return true
+	}
+	if charID == nil {
+		return false
+	}
+	q, err := h.users.IsQuarantined(ctx, *charID)
+	return err == nil && !q
 }
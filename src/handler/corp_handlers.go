@@ -1,17 +1,24 @@
 package handler
 
-import (
-	"net/http"
+import "net/http"
 
-	db2 "speedliner-server/src/db"
-)
-
-func ListCorpsHandler(w http.ResponseWriter, r *http.Request) {
+// ListCorpsHandler godoc
+// @Summary      Corps suchen
+// @Description  Bekannte Corps nach Name oder Ticker (max. 100), z.B. für Whitelists und Raten.
+// @Tags         Admin
+// @Produce      json
+// @Param        q query string false "Suchbegriff (Name, Ticker)"
+// @Success      200 {array}  structs.CorpOption
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/corps [get]
+func (h *Handler) ListCorpsHandler(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query().Get("q")
-	items, err := db2.SearchCorps(q, 100)
+	items, err := h.corps.Search(r.Context(), q, 100)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, "DB error: "+err.Error())
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
 		return
 	}
-	writeJSON(w, http.StatusOK, items)
+	writeJSON(w, r, http.StatusOK, items)
 }
@@ -0,0 +1,17 @@
+package handler
+
+import "speedliner-server/src/repo"
+
+// Handler: Endpunkte mit injizierter Datenhaltung (Routen-CRUD, /role, Corp-Suche; /quote und
+// der Express-Versand nur für die Routen-Sichtbarkeit). Nur diese lassen sich mit memrepo testen,
+// die übrigen Handler (Preise, Provider, Admin, Accounts) greifen direkt auf das db-Paket zu.
+type Handler struct {
+	routes repo.RouteRepository
+	users  repo.UserRepository
+	corps  repo.CorpRepository
+}
+
+// New erstellt die Handler auf den übergebenen Repositories (repo.Postgres bzw. memrepo in Tests).
+func New(r repo.Repos) *Handler {
+	return &Handler{routes: r.Routes, users: r.Users, corps: r.Corps}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	db2 "speedliner-server/src/db"
+	"speedliner-server/src/utils/esiauth"
+	"speedliner-server/src/utils/structs"
+)
+
+// HealthzHandler godoc
+// @Summary      Liveness
+// @Description  Prozess läuft und bedient Requests – keine Abhängigkeiten
+// @Tags         System
+// @Produce      json
+// @Success      200 {object} structs.HealthReport
+// @Router       /app/v1/healthz [get]
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, structs.HealthReport{Status: "ok", Checks: map[string]structs.HealthCheck{}})
+}
+
+// ReadyzHandler godoc
+// @Summary      Readiness
+// @Description  Prüft DB-Verbindung, Schema-Version und den Service-Token (EXPRESS_SENDER_CHAR_ID).
+// @Description  Ein fehlgeschlagener kritischer Check liefert 503; der Service-Token ist nur mit READY_REQUIRE_SERVICE_TOKEN=true kritisch.
+// @Tags         System
+// @Produce      json
+// @Success      200 {object} structs.HealthReport
+// @Failure      503 {object} structs.HealthReport
+// @Router       /app/v1/readyz [get]
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	rep := structs.HealthReport{Status: "ok", Checks: map[string]structs.HealthCheck{}}
+	rep.Checks["db"] = timed(func() structs.HealthCheck { return checkDB(ctx) })
+	if rep.Checks["db"].Status == "ok" {
+		rep.Checks["schema"] = timed(func() structs.HealthCheck { return checkSchema(ctx) })
+	} else {
+		rep.Checks["schema"] = structs.HealthCheck{Status: "skipped", Message: "database unavailable", Critical: true}
+	}
+	rep.Checks["service_token"] = timed(func() structs.HealthCheck { return checkServiceToken(ctx) })
+
+	status := http.StatusOK
+	for _, c := range rep.Checks {
+		switch {
+		case c.Status == "fail" && c.Critical:
+			rep.Status, status = "fail", http.StatusServiceUnavailable
+		case (c.Status == "fail" || c.Status == "warn") && rep.Status == "ok":
+			rep.Status = "degraded"
+		}
+	}
+	writeJSON(w, r, status, rep)
+}
+
+func timed(f func() structs.HealthCheck) structs.HealthCheck {
+	start := time.Now()
+	c := f()
+	c.LatencyMs = time.Since(start).Milliseconds()
+	return c
+}
+
+func checkDB(ctx context.Context) structs.HealthCheck {
+	if err := db2.Ping(ctx); err != nil {
+		return structs.HealthCheck{Status: "fail", Message: "database unreachable", Critical: true}
+	}
+	return structs.HealthCheck{Status: "ok", Critical: true}
+}
+
+func checkSchema(ctx context.Context) structs.HealthCheck {
+	v, err := db2.AppliedSchemaVersion(ctx)
+	if err != nil {
+		return structs.HealthCheck{Status: "fail", Message: "schema_migrations not readable", Critical: true}
+	}
+	if v != db2.SchemaVersion {
+		return structs.HealthCheck{Status: "fail", Critical: true,
+			Message: "schema version " + strconv.Itoa(v) + ", expected " + strconv.Itoa(db2.SchemaVersion)}
+	}
+	return structs.HealthCheck{Status: "ok", Message: "version " + strconv.Itoa(v), Critical: true}
+}
+
+// Service-Token-Prüfung ist teuer (ggf. Refresh gegen SSO) – Ergebnis kurz cachen.
+var (
+	tokenCheckMu    sync.Mutex
+	tokenCheckAt    time.Time
+	tokenCheckCache structs.HealthCheck
+	tokenCheckTTL   = 5 * time.Minute
+)
+
+func checkServiceToken(ctx context.Context) structs.HealthCheck {
+	critical := cfg.Ready.RequireServiceToken
+	if !cfg.Express.Enabled {
+		return structs.HealthCheck{Status: "skipped", Message: "express disabled", Critical: critical}
+	}
+	senderCharID := strconv.FormatInt(cfg.Express.SenderCharID, 10)
+
+	tokenCheckMu.Lock()
+	defer tokenCheckMu.Unlock()
+	if time.Since(tokenCheckAt) < tokenCheckTTL {
+		c := tokenCheckCache
+		c.Critical = critical
+		return c
+	}
+
+	c := serviceTokenState(ctx, senderCharID)
+	c.Critical = critical
+	tokenCheckCache, tokenCheckAt = c, time.Now()
+	return c
+}
+
+// serviceTokenState: Token vorhanden und refreshbar; ein abgelaufener Access-Token wird
+// testweise erneuert (und gespeichert), damit ein widerrufener Refresh-Token auffällt.
+func serviceTokenState(ctx context.Context, senderCharID string) structs.HealthCheck {
+	tok, ok := esiauth.LoadToken(ctx, senderCharID)
+	if !ok || tok == nil {
+		return structs.HealthCheck{Status: "fail", Message: "no token stored (login service char once)"}
+	}
+	if tok.RefreshToken == "" {
+		return structs.HealthCheck{Status: "fail", Message: "token has no refresh token"}
+	}
+	if tok.Valid() {
+		return structs.HealthCheck{Status: "ok", Message: "access token valid until " + tok.Expiry.UTC().Format(time.RFC3339)}
+	}
+	ts := esiauth.NewSavingTokenSource(ctx, senderCharID, esiauth.GetOAuthConfig().TokenSource(ctx, tok))
+	nt, err := ts.Token()
+	if err != nil {
+		return structs.HealthCheck{Status: "fail", Message: "token refresh failed"}
+	}
+	return structs.HealthCheck{Status: "ok", Message: "refreshed, valid until " + nt.Expiry.UTC().Format(time.RFC3339)}
+}
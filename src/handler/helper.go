@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"speedliner-server/src/utils/esi"
 	"speedliner-server/src/utils/structs"
 	"strconv"
 	"strings"
@@ -47,6 +48,9 @@ func buildExpressMailBody(req structs.ExpressMailRequest) string {
 	fmt.Fprintf(b, "Volume: %s m³\n", formatISK(req.VolumeM3))
 	fmt.Fprintln(b, "Days to complete: 1")
 	fmt.Fprintln(b, "Deliver within 2–4h after acceptance.")
+	if req.PromoCode != "" {
+		fmt.Fprintf(b, "Promo code: %s\n", strings.ToUpper(strings.TrimSpace(req.PromoCode)))
+	}
 	if req.CustomerCharName != "" {
 		fmt.Fprintf(b, "\nRequested by: %s (%d)\n", req.CustomerCharName, req.CustomerCharID)
 	}
@@ -65,7 +69,7 @@ type Affil struct {
 func FetchAffiliation(ctx context.Context, ids []int64, lastETag string) ([]Affil, string, int, error) {
 	body, _ := json.Marshal(ids)
 	req, _ := http.NewRequestWithContext(ctx, http.MethodPost,
-		"https://esi.evetech.net/v1/characters/affiliation/?datasource=tranquility",
+		esi.URL("/v1/characters/affiliation/?datasource=tranquility"),
 		bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	if lastETag != "" {
@@ -7,42 +7,59 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"os"
+	"speedliner-server/src/utils/esi"
 	"strconv"
 	"strings"
 	"time"
 
 	db2 "speedliner-server/src/db"
 	"speedliner-server/src/utils/esiauth"
+	"speedliner-server/src/utils/metrics"
 	"speedliner-server/src/utils/structs"
 
 	"github.com/jackc/pgx/v5"
 	"golang.org/x/oauth2"
 )
 
-// /mail – sendet als eingeloggter User
+// ESI erlaubt max. 50 Empfänger pro Mail
+const maxMailRecipients = 50
+
+// SendMailHandler godoc
+// @Summary      EVE-Mail senden (ohne CSPA)
+// @Description  Sendet eine EVE-Mail über ESI als eingeloggter Char. Empfänger nutzt kein CSPA, daher wird `approved_cost` immer 0 gesendet.
+// @Tags         Mail
+// @Accept       json
+// @Produce      json
+// @Param        mail body structs.SendMailRequest true "Mail-Daten"
+// @Success      201 {object} structs.MailIDResponse
+// @Failure      400 {object} structs.ErrorResponse
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      502 {object} structs.ErrorResponse
+// @Failure      429 {object} structs.ErrorResponse "Rate limit (RateLimit-*, Retry-After)"
+// @Router       /app/v1/mail [post]
 func SendMailHandler(w http.ResponseWriter, r *http.Request) {
 	c, err := r.Cookie("char")
 	if err != nil || c.Value == "" {
-		jsonError(w, http.StatusUnauthorized, "Not logged in")
+		jsonError(w, r, http.StatusUnauthorized, "Not logged in")
 		return
 	}
 
-	token, ok := esiauth.LoadToken(c.Value)
+	token, ok := esiauth.LoadToken(r.Context(), c.Value)
 	if !ok {
-		jsonError(w, http.StatusUnauthorized, "No token for user")
+		jsonError(w, r, http.StatusUnauthorized, "No token for user")
 		return
 	}
-	httpClient := esiauth.GetOAuthConfig().Client(context.Background(), token)
+	httpClient := esiauth.GetOAuthConfig().Client(r.Context(), token)
 
 	var req structs.SendMailRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		jsonError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+	if err := decodeJSON(r, &req); err != nil {
+		badJSON(w, r, err)
 		return
 	}
 	if req.Subject == "" || req.Body == "" || len(req.Recipients) == 0 {
-		jsonError(w, http.StatusBadRequest, "subject, body, recipients required")
+		jsonError(w, r, http.StatusBadRequest, "subject, body, recipients required")
 		return
 	}
 
@@ -50,7 +67,7 @@ func SendMailHandler(w http.ResponseWriter, r *http.Request) {
 	recipients := make([]map[string]interface{}, 0, len(req.Recipients))
 	for _, rcpt := range req.Recipients {
 		if rcpt.ID <= 0 || !allowed[rcpt.Type] {
-			jsonError(w, http.StatusBadRequest, "invalid recipient entry")
+			jsonError(w, r, http.StatusBadRequest, "invalid recipient entry")
 			return
 		}
 		recipients = append(recipients, map[string]interface{}{
@@ -59,7 +76,7 @@ func SendMailHandler(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	url := fmt.Sprintf("https://esi.evetech.net/latest/characters/%s/mail/?datasource=tranquility", c.Value)
+	url := esi.URL("/latest/characters/%s/mail/?datasource=tranquility", c.Value)
 	payload := map[string]interface{}{
 		"approved_cost": 0,
 		"subject":       req.Subject,
@@ -68,74 +85,139 @@ func SendMailHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	body, _ := json.Marshal(payload)
 
-	reqESI, _ := http.NewRequest("POST", url, bytes.NewReader(body))
+	reqESI, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, url, bytes.NewReader(body))
 	reqESI.Header.Set("Content-Type", "application/json")
 	reqESI.Header.Set("User-Agent", "speedliner-server/1.0 (mail)")
 
 	resp, err := httpClient.Do(reqESI)
 	if err != nil {
-		jsonError(w, http.StatusBadGateway, "ESI error: "+err.Error())
+		metrics.MailSent("mail", "esi_error")
+		serverError(w, r, http.StatusBadGateway, "ESI error", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
+		metrics.MailSent("mail", "esi_rejected")
 		b, _ := io.ReadAll(resp.Body)
-		jsonError(w, http.StatusBadGateway, fmt.Sprintf("ESI send failed: %s: %s", resp.Status, string(b)))
+		upstreamError(w, r, "ESI send failed", resp, b)
 		return
 	}
+	metrics.MailSent("mail", "sent")
 
 	raw, _ := io.ReadAll(resp.Body)
 	mailID, _ := strconv.Atoi(strings.TrimSpace(string(raw)))
 
-	writeJSON(w, http.StatusCreated, map[string]int{"mail_id": mailID})
+	writeJSON(w, r, http.StatusCreated, map[string]int{"mail_id": mailID})
 }
 
-// EXPRESS: sendet als Service-Char an Ziel-Corp/Alliance
-func SendExpressMailFromServiceHandler(w http.ResponseWriter, r *http.Request) {
-	senderCharID := strings.TrimSpace(os.Getenv("EXPRESS_SENDER_CHAR_ID"))
-	targetKind := strings.TrimSpace(os.Getenv("EXPRESS_TARGET_TYPE"))
-	targetIDStr := strings.TrimSpace(os.Getenv("EXPRESS_TARGET_CORP_ID"))
-
-	if senderCharID == "" || targetIDStr == "" {
-		jsonError(w, http.StatusInternalServerError, "missing EXPRESS_SENDER_CHAR_ID or EXPRESS_TARGET_CORP_ID")
-		return
-	}
-	targetID, err := strconv.ParseInt(targetIDStr, 10, 64)
-	if err != nil || targetID <= 0 {
-		jsonError(w, http.StatusInternalServerError, "bad EXPRESS_TARGET_CORP_ID")
+// SendExpressMailFromServiceHandler godoc
+// @Summary      EVE-Mail für EXPRESS senden
+// @Description  Sendet als Service-Char (ENV) an die diensthabenden Provider der Route, sonst an die Ziel-Corp/Alliance (ENV).
+// @Description  Der Reward wird serverseitig wie bei /quote berechnet; ein abweichender reward_isk ergibt 422.
+// @Tags         Mail
+// @Accept       json
+// @Produce      json
+// @Param        body body structs.ExpressMailRequest true "Express Daten"
+// @Success      201 {object} map[string]any "mail_id, dispatch, recipients, reward_isk"
+// @Failure      400 {object} structs.ErrorResponse
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      422 {object} structs.ErrorResponse
+// @Failure      500 {object} structs.ErrorResponse
+// @Failure      502 {object} structs.ErrorResponse
+// @Failure      429 {object} structs.ErrorResponse "Rate limit (RateLimit-*, Retry-After)"
+// @Router       /app/v1/express/mail [post]
+func (h *Handler) SendExpressMailFromServiceHandler(w http.ResponseWriter, r *http.Request) {
+	// Werte sind beim Start validiert (config.Express)
+	if !cfg.Express.Enabled {
+		jsonError(w, r, http.StatusServiceUnavailable, "Express dispatch is disabled")
 		return
 	}
+	senderCharID := strconv.FormatInt(cfg.Express.SenderCharID, 10)
+	targetKind := cfg.Express.TargetType
+	targetID := cfg.Express.TargetID
 
 	var req structs.ExpressMailRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		jsonError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+	if err := decodeJSON(r, &req); err != nil {
+		badJSON(w, r, err)
 		return
 	}
-	if !req.Express || strings.TrimSpace(req.Route) == "" || req.RewardISK <= 0 || req.VolumeM3 <= 0 {
-		jsonError(w, http.StatusBadRequest, "missing required express fields")
+	if !req.Express || strings.TrimSpace(req.Route) == "" || req.RouteID == "" || req.RewardISK < 0 || req.VolumeM3 <= 0 {
+		jsonError(w, r, http.StatusBadRequest, "missing required express fields")
 		return
 	}
 
-	// Empfänger prüfen
-	if ok, status, verr := validateRecipient(targetKind, targetID); verr != nil {
-		jsonError(w, http.StatusBadGateway, "validate recipient error: "+verr.Error())
+	// Promo-Code braucht Login, eingelöst wird erst nach erfolgreichem Versand
+	var promoCharID int64
+	if strings.TrimSpace(req.PromoCode) != "" {
+		charID, _ := currentUser(r)
+		if charID == nil {
+			jsonError(w, r, http.StatusUnauthorized, "login required for promo codes")
+			return
+		}
+		promoCharID = *charID
+	}
+
+	// Reward rechnet der Server (Rate + Promo); ein abweichender reward_isk vom Client wird abgelehnt
+	q, ok := h.quote(w, r, structs.QuoteRequest{
+		RouteID:       req.RouteID,
+		VolumeM3:      req.VolumeM3,
+		CollateralISK: req.CollatISK,
+		Express:       true,
+		PromoCode:     req.PromoCode,
+	})
+	if !ok {
 		return
-	} else if !ok {
-		jsonError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s id %d (ESI %s)", targetKind, targetID, status))
+	}
+	if req.RewardISK != 0 && req.RewardISK != q.Total {
+		jsonError(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("reward_isk does not match quote (%d ISK)", q.Total))
 		return
 	}
+	req.RewardISK = q.Total
+
+	// Hauler im Dienst für diese Lane? Dann direkt an sie, sonst an die Ziel-Corp/Alliance
+	recipients := []map[string]interface{}{
+		{"recipient_id": targetID, "recipient_type": targetKind},
+	}
+	dispatch := targetKind
+	haulers, herr := db2.FindAvailableProviders(r.Context(), req.RouteID, req.VolumeM3)
+	if herr != nil {
+		log.Printf("FindAvailableProviders: %v", herr)
+	} else if len(haulers) > 0 {
+		recipients = recipients[:0]
+		for i, p := range haulers {
+			if i == maxMailRecipients {
+				break
+			}
+			recipients = append(recipients, map[string]interface{}{
+				"recipient_id":   p.CharID,
+				"recipient_type": "character",
+			})
+		}
+		dispatch = "providers"
+	}
 
-	tok, ok := esiauth.LoadToken(senderCharID)
+	// Empfänger prüfen (nur Corp/Alliance-Fallback)
+	if dispatch != "providers" {
+		if ok, status, verr := validateRecipient(r.Context(), targetKind, targetID); verr != nil {
+			serverError(w, r, http.StatusBadGateway, "validate recipient error", verr)
+			return
+		} else if !ok {
+			jsonError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid %s id %d (ESI %s)", targetKind, targetID, status))
+			return
+		}
+	}
+
+	tok, ok := esiauth.LoadToken(r.Context(), senderCharID)
 	if !ok {
-		jsonError(w, http.StatusUnauthorized, "service token missing (login service char once)")
+		jsonError(w, r, http.StatusUnauthorized, "service token missing (login service char once)")
 		return
 	}
 
-	cfg := esiauth.GetOAuthConfig()
-	ctx := context.Background()
-	baseTS := cfg.TokenSource(ctx, tok)
-	ts := esiauth.NewSavingTokenSource(senderCharID, baseTS)
+	oauthCfg := esiauth.GetOAuthConfig()
+	ctx := r.Context()
+	baseTS := oauthCfg.TokenSource(ctx, tok)
+	ts := esiauth.NewSavingTokenSource(ctx, senderCharID, baseTS)
 	httpClient := oauth2.NewClient(ctx, ts)
 
 	subject := fmt.Sprintf("EXPRESS: %s — %s ISK", req.Route, formatISK(req.RewardISK))
@@ -145,57 +227,77 @@ func SendExpressMailFromServiceHandler(w http.ResponseWriter, r *http.Request) {
 		"approved_cost": 0,
 		"subject":       subject,
 		"body":          body,
-		"recipients": []map[string]interface{}{
-			{"recipient_id": targetID, "recipient_type": targetKind},
-		},
+		"recipients":    recipients,
 	}
 	bts, _ := json.Marshal(payload)
 
-	url := fmt.Sprintf("https://esi.evetech.net/latest/characters/%s/mail/?datasource=tranquility", senderCharID)
-	reqESI, _ := http.NewRequest("POST", url, bytes.NewReader(bts))
+	url := esi.URL("/latest/characters/%s/mail/?datasource=tranquility", senderCharID)
+	reqESI, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bts))
 	reqESI.Header.Set("Content-Type", "application/json")
 	reqESI.Header.Set("User-Agent", "speedliner-server/1.0 (express-mail)")
 
 	resp, err := httpClient.Do(reqESI)
 	if err != nil {
-		jsonError(w, http.StatusBadGateway, "ESI error: "+err.Error())
+		metrics.MailSent("express", "esi_error")
+		serverError(w, r, http.StatusBadGateway, "ESI error", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
+		metrics.MailSent("express", "esi_rejected")
 		raw, _ := io.ReadAll(resp.Body)
-		jsonError(w, http.StatusBadGateway, fmt.Sprintf("ESI send failed: %s: %s", resp.Status, string(raw)))
+		upstreamError(w, r, "ESI send failed", resp, raw)
 		return
 	}
+	metrics.MailSent("express", "sent")
 
 	raw, _ := io.ReadAll(resp.Body)
 	mailID, _ := strconv.Atoi(strings.TrimSpace(string(raw)))
 
-	writeJSON(w, http.StatusCreated, map[string]int{"mail_id": mailID})
+	// Mail ist raus: Einlösen auch dann, wenn der Client inzwischen abgebrochen hat
+	if promoCharID != 0 {
+		if err := db2.RedeemPromoCode(context.WithoutCancel(ctx), req.PromoCode, req.RouteID, promoCharID); err != nil {
+			log.Printf("RedeemPromoCode %q: %v", req.PromoCode, err)
+		}
+	}
+
+	writeJSON(w, r, http.StatusCreated, map[string]interface{}{
+		"mail_id":    mailID,
+		"dispatch":   dispatch,
+		"recipients": len(recipients),
+		"reward_isk": req.RewardISK,
+	})
 }
 
+// ExpressTokenStatusHandler godoc
+// @Summary      Token-Status des Service-Chars
+// @Tags         Mail
+// @Produce      json
+// @Success      200 {object} map[string]any
+// @Failure      500 {object} structs.ErrorResponse
+// @Router       /app/v1/express/token-status [get]
 func ExpressTokenStatusHandler(w http.ResponseWriter, r *http.Request) {
-	senderCharID := strings.TrimSpace(os.Getenv("EXPRESS_SENDER_CHAR_ID"))
-	if senderCharID == "" {
-		http.Error(w, "missing ENV EXPRESS_SENDER_CHAR_ID", http.StatusInternalServerError)
+	if !cfg.Express.Enabled {
+		jsonError(w, r, http.StatusServiceUnavailable, "Express dispatch is disabled")
 		return
 	}
+	senderCharID := strconv.FormatInt(cfg.Express.SenderCharID, 10)
 
 	// 1) Token aus Store laden (existiert?)
-	tok, ok := esiauth.LoadToken(senderCharID)
+	tok, ok := esiauth.LoadToken(r.Context(), senderCharID)
 
 	// 2) updated_at aus DB lesen
 	var updatedAt *time.Time
 	if db2.Pool != nil {
 		var ua time.Time
-		err := db2.Pool.QueryRow(context.Background(),
+		err := db2.Pool.QueryRow(r.Context(),
 			`SELECT updated_at FROM oauth_tokens WHERE char_id=$1`, senderCharID,
 		).Scan(&ua)
 		if err == nil {
 			updatedAt = &ua
 		} else if !errors.Is(err, pgx.ErrNoRows) {
-			http.Error(w, "db query error: "+err.Error(), http.StatusInternalServerError)
+			serverError(w, r, http.StatusInternalServerError, "db query error", err)
 			return
 		}
 	}
@@ -235,23 +337,22 @@ func ExpressTokenStatusHandler(w http.ResponseWriter, r *http.Request) {
 		resp["access_token_expired"] = *expired
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
+	writeJSON(w, r, http.StatusOK, resp)
 }
 
 // kleine Helfer aus deinem Original
-func validateRecipient(kind string, id int64) (bool, string, error) {
+func validateRecipient(ctx context.Context, kind string, id int64) (bool, string, error) {
 	var url string
 	switch kind {
 	case "corporation":
-		url = fmt.Sprintf("https://esi.evetech.net/latest/corporations/%d/?datasource=tranquility", id)
+		url = esi.URL("/latest/corporations/%d/?datasource=tranquility", id)
 	case "alliance":
-		url = fmt.Sprintf("https://esi.evetech.net/latest/alliances/%d/?datasource=tranquility", id)
+		url = esi.URL("/latest/alliances/%d/?datasource=tranquility", id)
 	default:
 		return false, "", fmt.Errorf("unsupported target kind: %s", kind)
 	}
 
-	req, _ := http.NewRequest("GET", url, nil)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	req.Header.Set("User-Agent", "speedliner-server/1.0 (validate)")
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"speedliner-server/src/utils/pricing"
+	"speedliner-server/src/utils/structs"
+	"strings"
+
+	db2 "speedliner-server/src/db"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// QuoteHandler godoc
+// @Summary      Preis berechnen
+// @Description  Preis für den Aufrufer inkl. Corp-/Alliance-Rate und Promo-Code
+// @Tags         Pricing
+// @Accept       json
+// @Produce      json
+// @Param        quote body structs.QuoteRequest true "Anfrage"
+// @Success      200 {object} structs.QuoteResponse
+// @Failure      400 {object} structs.ErrorResponse
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      404 {object} structs.ErrorResponse
+// @Failure      422 {object} structs.ErrorResponse "Promo code not valid"
+// @Failure      500 {object} structs.ErrorResponse
+// @Failure      429 {object} structs.ErrorResponse "Rate limit (RateLimit-*, Retry-After)"
+// @Router       /app/v1/quote [post]
+func (h *Handler) QuoteHandler(w http.ResponseWriter, r *http.Request) {
+	var req structs.QuoteRequest
+	if err := decodeJSON(r, &req); err != nil {
+		badJSON(w, r, err)
+		return
+	}
+	q, ok := h.quote(w, r, req)
+	if !ok {
+		return
+	}
+	writeJSON(w, r, http.StatusOK, q)
+}
+
+// quote prüft req und rechnet den Preis für den Aufrufer; bei Fehlern ist die Antwort bereits geschrieben.
+// Auch der Express-Versand nutzt das, damit der Reward nicht vom Client kommt.
+func (h *Handler) quote(w http.ResponseWriter, r *http.Request, req structs.QuoteRequest) (structs.QuoteResponse, bool) {
+	if req.RouteID == "" || req.VolumeM3 <= 0 || req.VolumeM3 > pricing.MaxVolumeM3 {
+		jsonError(w, r, http.StatusBadRequest, field(r, "routeId")+" and "+field(r, "volumeM3")+" (1..351000) required")
+		return structs.QuoteResponse{}, false
+	}
+	if req.CollateralISK < 0 || req.CollateralISK > pricing.MaxCollateral {
+		jsonError(w, r, http.StatusBadRequest, field(r, "collateralISK")+" out of range")
+		return structs.QuoteResponse{}, false
+	}
+
+	charID, perms := currentUser(r)
+	if !h.canSeeRoutes(r.Context(), charID) {
+		jsonError(w, r, http.StatusUnauthorized, "Login required")
+		return structs.QuoteResponse{}, false
+	}
+	route, err := h.routes.GetForUser(r.Context(), req.RouteID, charID, perms.HasAny(structs.PermRoutesViewAll))
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return structs.QuoteResponse{}, false
+	}
+	if route == nil {
+		jsonError(w, r, http.StatusNotFound, "Route not found")
+		return structs.QuoteResponse{}, false
+	}
+
+	var rate *structs.RouteRate
+	if charID != nil {
+		if rate, err = db2.GetEffectiveRate(r.Context(), route.ID, *charID); err != nil {
+			serverError(w, r, http.StatusInternalServerError, "DB error", err)
+			return structs.QuoteResponse{}, false
+		}
+	}
+
+	var promo *structs.PromoCode
+	if strings.TrimSpace(req.PromoCode) != "" {
+		promo, err = db2.GetUsablePromoCode(r.Context(), req.PromoCode, route.ID)
+		if errors.Is(err, db2.ErrPromoUnavailable) {
+			jsonError(w, r, http.StatusUnprocessableEntity, "Promo code not valid for this route")
+			return structs.QuoteResponse{}, false
+		}
+		if err != nil {
+			serverError(w, r, http.StatusInternalServerError, "DB error", err)
+			return structs.QuoteResponse{}, false
+		}
+	}
+
+	return pricing.Quote(*route, rate, promo, req), true
+}
+
+// ---- Raten (Provider/Admin) ----
+
+// ListRatesHandler godoc
+// @Summary      Raten auflisten
+// @Description  Corp-/Alliance-Raten (Festpreis pro m³ oder Rabatt), global oder je Route.
+// @Tags         Pricing
+// @Produce      json
+// @Success      200 {array}  structs.RouteRate
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/rates [get]
+func ListRatesHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := db2.ListRouteRates(r.Context())
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
+
+// UpsertRateHandler godoc
+// @Summary      Rate anlegen/ersetzen
+// @Description  Genau eine von corp_id/alliance_id; price_per_m3 und/oder discount_pct. Pro Ziel (Corp/Alliance, Route) gibt es eine Rate, eine bestehende wird ersetzt.
+// @Tags         Pricing
+// @Accept       json
+// @Produce      json
+// @Param        rate body structs.RouteRate true "Rate"
+// @Success      200 {object} structs.RouteRate
+// @Failure      400 {object} structs.ErrorResponse "Invalid rate"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/rates [post]
+func UpsertRateHandler(w http.ResponseWriter, r *http.Request) {
+	var rate structs.RouteRate
+	if err := decodeJSON(r, &rate); err != nil {
+		badJSON(w, r, err)
+		return
+	}
+	if (rate.CorpID == nil) == (rate.AllianceID == nil) {
+		jsonError(w, r, http.StatusBadRequest, "exactly one of "+field(r, "corpId")+" or "+field(r, "allianceId")+" required")
+		return
+	}
+	if rate.PricePerM3 == nil && rate.DiscountPct == nil {
+		jsonError(w, r, http.StatusBadRequest, field(r, "pricePerM3")+" or "+field(r, "discountPct")+" required")
+		return
+	}
+	if rate.DiscountPct != nil && (*rate.DiscountPct <= 0 || *rate.DiscountPct > 100) {
+		jsonError(w, r, http.StatusBadRequest, field(r, "discountPct")+" must be in (0,100]")
+		return
+	}
+	if (rate.PricePerM3 != nil && *rate.PricePerM3 < 0) || (rate.MinPrice != nil && *rate.MinPrice < 0) {
+		jsonError(w, r, http.StatusBadRequest, "prices must be >= 0")
+		return
+	}
+	if rate.RouteID != nil && *rate.RouteID == "" {
+		rate.RouteID = nil
+	}
+
+	out, err := db2.UpsertRouteRate(r.Context(), rate)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, out)
+}
+
+// DeleteRateHandler godoc
+// @Summary      Rate löschen
+// @Tags         Pricing
+// @Param        id path string true "Rate ID"
+// @Success      204 {string} string "No Content"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/rates/{id} [delete]
+func DeleteRateHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db2.DeleteRouteRate(r.Context(), chi.URLParam(r, "id")); err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB Delete error", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ---- Promo-Codes (Provider/Admin) ----
+
+// ListPromosHandler godoc
+// @Summary      Promo-Codes auflisten
+// @Tags         Pricing
+// @Produce      json
+// @Success      200 {array}  structs.PromoCode
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/promos [get]
+func ListPromosHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := db2.ListPromoCodes(r.Context())
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
+
+// UpsertPromoHandler godoc
+// @Summary      Promo-Code anlegen/ändern
+// @Description  POST mit code im Body, PUT mit code im Pfad (überschreibt den Body). Codes sind case-insensitiv.
+// @Tags         Pricing
+// @Accept       json
+// @Produce      json
+// @Param        code  path string false "Promo-Code (nur PUT)"
+// @Param        promo body structs.PromoCode true "Promo-Code"
+// @Success      200 {object} structs.PromoCode
+// @Failure      400 {object} structs.ErrorResponse "Invalid promo code"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/promos [post]
+// @Router       /app/v1/promos/{code} [put]
+func UpsertPromoHandler(w http.ResponseWriter, r *http.Request) {
+	var p structs.PromoCode
+	if err := decodeJSON(r, &p); err != nil {
+		badJSON(w, r, err)
+		return
+	}
+	if code := chi.URLParam(r, "code"); code != "" {
+		p.Code = code
+	}
+	if db2.NormalizePromoCode(p.Code) == "" {
+		jsonError(w, r, http.StatusBadRequest, "code required")
+		return
+	}
+	if p.DiscountPct <= 0 || p.DiscountPct > 100 {
+		jsonError(w, r, http.StatusBadRequest, field(r, "discountPct")+" must be in (0,100]")
+		return
+	}
+	if p.MaxUses != nil && *p.MaxUses <= 0 {
+		jsonError(w, r, http.StatusBadRequest, field(r, "maxUses")+" must be > 0")
+		return
+	}
+	if p.RouteID != nil && *p.RouteID == "" {
+		p.RouteID = nil
+	}
+
+	var createdBy int64
+	if charID, _ := currentUser(r); charID != nil {
+		createdBy = *charID
+	}
+	out, err := db2.UpsertPromoCode(r.Context(), p, createdBy)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, out)
+}
+
+// DeletePromoHandler godoc
+// @Summary      Promo-Code löschen
+// @Tags         Pricing
+// @Param        code path string true "Promo-Code"
+// @Success      204 {string} string "No Content"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/promos/{code} [delete]
+func DeletePromoHandler(w http.ResponseWriter, r *http.Request) {
+	if err := db2.DeletePromoCode(r.Context(), chi.URLParam(r, "code")); err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB Delete error", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
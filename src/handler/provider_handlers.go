@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"speedliner-server/src/utils/structs"
+	"strconv"
+	"strings"
+	"time"
+
+	db2 "speedliner-server/src/db"
+)
+
+// GetMyProviderProfileHandler godoc
+// @Summary      Eigenes Hauler-Profil
+// @Description  Ohne gespeichertes Profil kommen Defaults (freighter, UTC), damit das Frontend ein Formular füllen kann.
+// @Tags         Providers
+// @Produce      json
+// @Success      200 {object} structs.ProviderProfile
+// @Failure      401 {object} structs.ErrorResponse "Not logged in"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/providers/me [get]
+func GetMyProviderProfileHandler(w http.ResponseWriter, r *http.Request) {
+	charID, _ := currentUser(r)
+	if charID == nil {
+		jsonError(w, r, http.StatusUnauthorized, "Not logged in")
+		return
+	}
+	p, err := db2.GetProviderProfile(r.Context(), *charID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	if p == nil {
+		// noch kein Profil -> Defaults, damit das Frontend ein Formular füllen kann
+		p = &structs.ProviderProfile{CharID: *charID, ShipClass: "freighter", Timezone: "UTC", RouteIDs: []string{}}
+	}
+	writeJSON(w, r, http.StatusOK, p)
+}
+
+// UpdateMyProviderProfileHandler godoc
+// @Summary      Eigenes Hauler-Profil speichern
+// @Description  Schiffsklasse, Kapazität, Zeitzone, Notizen und Routen. Im Dienst nur mit max_m3 > 0.
+// @Tags         Providers
+// @Accept       json
+// @Produce      json
+// @Param        profile body structs.ProviderProfile true "Profil"
+// @Success      200 {object} structs.ProviderProfile
+// @Failure      400 {object} structs.ErrorResponse "Invalid profile"
+// @Failure      401 {object} structs.ErrorResponse "Not logged in"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/providers/me [put]
+func UpdateMyProviderProfileHandler(w http.ResponseWriter, r *http.Request) {
+	charID, _ := currentUser(r)
+	if charID == nil {
+		jsonError(w, r, http.StatusUnauthorized, "Not logged in")
+		return
+	}
+
+	var p structs.ProviderProfile
+	if err := decodeJSON(r, &p); err != nil {
+		badJSON(w, r, err)
+		return
+	}
+	p.CharID = *charID
+	p.Timezone = strings.TrimSpace(p.Timezone)
+	if p.Timezone == "" {
+		p.Timezone = "UTC"
+	}
+	if !structs.AllowedShipClasses[p.ShipClass] {
+		jsonError(w, r, http.StatusBadRequest, "Invalid "+field(r, "shipClass"))
+		return
+	}
+	if p.MaxM3 < 0 {
+		jsonError(w, r, http.StatusBadRequest, field(r, "maxM3")+" must be >= 0")
+		return
+	}
+	if p.OnDuty && p.MaxM3 == 0 {
+		jsonError(w, r, http.StatusBadRequest, field(r, "maxM3")+" must be > 0 to go on duty")
+		return
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid timezone")
+		return
+	}
+
+	if err := db2.UpsertProviderProfile(r.Context(), p); err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	out, err := db2.GetProviderProfile(r.Context(), p.CharID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, out)
+}
+
+// SetMyDutyHandler godoc
+// @Summary      Dienst an/aus
+// @Tags         Providers
+// @Accept       json
+// @Param        duty body structs.DutyReq true "Dienststatus"
+// @Success      204 {string} string "No Content"
+// @Failure      400 {object} structs.ErrorResponse "No capacity set"
+// @Failure      401 {object} structs.ErrorResponse "Not logged in"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      404 {object} structs.ErrorResponse "No provider profile"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/providers/me/duty [put]
+func SetMyDutyHandler(w http.ResponseWriter, r *http.Request) {
+	charID, _ := currentUser(r)
+	if charID == nil {
+		jsonError(w, r, http.StatusUnauthorized, "Not logged in")
+		return
+	}
+	var req structs.DutyReq
+	if err := decodeJSON(r, &req); err != nil {
+		badJSON(w, r, err)
+		return
+	}
+	ok, err := db2.SetProviderOnDuty(r.Context(), *charID, req.OnDuty)
+	if errors.Is(err, db2.ErrProviderNoCapacity) {
+		jsonError(w, r, http.StatusBadRequest, "Set "+field(r, "maxM3")+" in your provider profile before going on duty")
+		return
+	}
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	if !ok {
+		jsonError(w, r, http.StatusNotFound, "No provider profile, create one first")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListProvidersHandler godoc
+// @Summary      Hauler-Profile auflisten
+// @Tags         Providers
+// @Produce      json
+// @Success      200 {array}  structs.ProviderProfile
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/providers [get]
+func ListProvidersHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := db2.ListProviderProfiles(r.Context())
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
+
+// AvailableProvidersHandler godoc
+// @Summary      Verfügbare Hauler
+// @Description  Wer kann diese Lane gerade fliegen: im Dienst, Route im Profil, genug Kapazität, Recht providers.profile.
+// @Tags         Providers
+// @Produce      json
+// @Param        route_id query string true  "Route ID (unter /app/: routeId)"
+// @Param        volume   query int    false "Volumen in m³"
+// @Success      200 {array}  structs.ProviderProfile
+// @Failure      400 {object} structs.ErrorResponse "Invalid query"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/providers/available [get]
+func AvailableProvidersHandler(w http.ResponseWriter, r *http.Request) {
+	routeID := r.URL.Query().Get(field(r, "routeId"))
+	if routeID == "" {
+		jsonError(w, r, http.StatusBadRequest, field(r, "routeId")+" required")
+		return
+	}
+	var volume int64
+	if v := r.URL.Query().Get("volume"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			jsonError(w, r, http.StatusBadRequest, "Invalid volume")
+			return
+		}
+		volume = n
+	}
+	list, err := db2.FindAvailableProviders(r.Context(), routeID, volume)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
@@ -2,32 +2,96 @@ package handler
 
 import (
 	"speedliner-server/src/middleware"
+	"speedliner-server/src/utils/structs"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func DefineApiRoutes(r chi.Router) {
+func DefineApiRoutes(r chi.Router, h *Handler) {
 	// System/Auth
 	r.Get("/ping", PingHandler)
-	r.Get("/login", LoginHandler)
-	r.Get("/callback", CallbackHandler)
+	r.Get("/healthz", HealthzHandler)
+	r.Get("/readyz", ReadyzHandler)
+	r.With(middleware.RateLimitPolicy("login")).Get("/login", LoginHandler)
+	r.With(middleware.RateLimitPolicy("login")).Get("/callback", CallbackHandler)
 	r.Get("/me", MeHandler)
 	r.Get("/logout", LogoutHandler)
-	r.Get("/role", GetUserRoleHandler)
+	r.Get("/role", h.GetUserRoleHandler)
+
+	// Account (Main + Alts)
+	r.Get("/account", GetMyAccountHandler)
+	r.With(middleware.RateLimitPolicy("login")).Get("/account/link", LinkCharacterHandler)
+	r.Post("/account/active", SwitchActiveCharHandler)
+	r.Delete("/account/characters/{charID}", UnlinkCharacterHandler)
+
+	// Persönliche API-Tokens
+	r.Get("/tokens", ListMyAPITokensHandler)
+	r.Post("/tokens", CreateAPITokenHandler)
+	r.Delete("/tokens/{id}", RevokeAPITokenHandler)
 
 	// Routes
-	r.Get("/routes", RoutesHandler)
-	r.With(middleware.RoleMiddleware("admin", "provider")).Post("/routes", CreateRouteHandler)
-	r.With(middleware.RoleMiddleware("admin", "provider")).Put("/routes/{id}", UpdateRouteHandler)
-	r.With(middleware.RoleMiddleware("admin", "provider")).Delete("/routes/{id}", DeleteRouteHandler)
+	r.Get("/routes", h.RoutesHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRoutesEdit)).Post("/routes", h.CreateRouteHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRoutesEdit)).Get("/routes/export", ExportRoutesHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRoutesEdit)).Post("/routes/import", ImportRoutesHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRoutesEdit)).Put("/routes/{id}", h.UpdateRouteHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRoutesEdit)).Delete("/routes/{id}", h.DeleteRouteHandler)
+
+	// Pricing
+	r.With(middleware.RateLimitPolicy("quote")).Post("/quote", h.QuoteHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRoutesPricing)).Get("/rates", ListRatesHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRoutesPricing)).Post("/rates", UpsertRateHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRoutesPricing)).Delete("/rates/{id}", DeleteRateHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRoutesPricing)).Get("/promos", ListPromosHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRoutesPricing)).Post("/promos", UpsertPromoHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRoutesPricing)).Put("/promos/{code}", UpsertPromoHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRoutesPricing)).Delete("/promos/{code}", DeletePromoHandler)
+
+	// Provider (Hauler-Profile)
+	r.With(middleware.PermissionMiddleware(structs.PermProvidersRead)).Get("/providers", ListProvidersHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermProvidersRead)).Get("/providers/available", AvailableProvidersHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermProvidersProfile)).Get("/providers/me", GetMyProviderProfileHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermProvidersProfile)).Put("/providers/me", UpdateMyProviderProfileHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermProvidersProfile)).Put("/providers/me/duty", SetMyDutyHandler)
 
 	// Users/Corps
-	r.With(middleware.RoleMiddleware("admin")).Get("/users", ListUsersHandler)
-	r.With(middleware.RoleMiddleware("admin")).Put("/users/{charID}/role", UpdateUserRoleHandler)
-	r.With(middleware.RoleMiddleware("admin", "provider")).Get("/corps", ListCorpsHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage)).Get("/users", ListUsersHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage, structs.PermAuditRead)).Get("/users/inactive", ListInactiveUsersHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage)).Get("/users/{charID}", GetUserDetailHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage, structs.PermAuditRead)).Get("/users/{charID}/logins", ListLoginEventsHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage, structs.PermAuditRead)).Get("/logins/suspicious", SuspiciousLoginsHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage)).Post("/users/{charID}/ban", BanUserHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage)).Delete("/users/{charID}/ban", UnbanUserHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage)).Put("/users/{charID}/role", UpdateUserRoleHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage)).Put("/users/{charID}/roles", UpdateUserRolesHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage)).Get("/users/{charID}/roles", GetUserRoleAssignmentsHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage)).Delete("/users/{charID}/roles/manual", ClearManualRolesHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage, structs.PermAuditRead)).Get("/users/{charID}/role-changes", ListRoleChangesHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage)).Post("/users/{charID}/affiliation/refresh", RefreshUserAffiliationHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage)).Post("/affiliations/refresh", RefreshAllAffiliationsHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermCorpsRead)).Get("/corps", h.ListCorpsHandler)
+
+	// Zugang (Allow-List / Quarantäne)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage, structs.PermAuditRead)).Get("/access/policy", GetAccessPolicyHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage, structs.PermAuditRead)).Get("/access/denials", ListLoginDenialsHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage)).Post("/access/denials/{id}/review", ReviewLoginDenialHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermUsersManage)).Post("/users/{charID}/release", ReleaseUserHandler)
+
+	// Rollen & Rechte
+	r.With(middleware.PermissionMiddleware(structs.PermRolesManage, structs.PermUsersManage)).Get("/roles", ListRolesHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRolesManage)).Get("/permissions", ListPermissionsHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRolesManage)).Put("/roles/{name}", UpsertRoleHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRolesManage)).Delete("/roles/{name}", DeleteRoleHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRolesManage)).Get("/role-rules", ListRoleRulesHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRolesManage)).Post("/role-rules", CreateRoleRuleHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermRolesManage)).Delete("/role-rules/{id}", DeleteRoleRuleHandler)
 
 	// Mail
-	r.Post("/mail", SendMailHandler)
-	r.Post("/express/mail", SendExpressMailFromServiceHandler)
-	r.With(middleware.RoleMiddleware("admin")).Get("/express/token-status", ExpressTokenStatusHandler)
+	r.With(middleware.RateLimitPolicy("mail")).Post("/mail", SendMailHandler)
+	r.With(middleware.RateLimitPolicy("express")).Post("/express/mail", h.SendExpressMailFromServiceHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermExpressDispatch)).Get("/express/token-status", ExpressTokenStatusHandler)
+
+	// Backups (Einspielen nur per CLI)
+	r.With(middleware.PermissionMiddleware(structs.PermBackupManage)).Get("/backups", ListBackupsHandler)
+	r.With(middleware.PermissionMiddleware(structs.PermBackupManage)).Post("/backups", CreateBackupHandler)
 }
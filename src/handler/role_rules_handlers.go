@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"speedliner-server/src/utils/structs"
+	"speedliner-server/src/utils/users"
+	"strconv"
+	"sync/atomic"
+
+	db2 "speedliner-server/src/db"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListRoleRulesHandler godoc
+// @Summary      Rollen-Regeln auflisten
+// @Description  Regeln vergeben beim Login Rollen nach Corp, Alliance oder als Default.
+// @Tags         Roles
+// @Produce      json
+// @Success      200 {array}  structs.RoleRule
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/role-rules [get]
+func ListRoleRulesHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := db2.ListRoleRules(r.Context())
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
+
+// CreateRoleRuleHandler godoc
+// @Summary      Rollen-Regel anlegen
+// @Description  match_type corp/alliance (mit match_id) oder default. Nur Rollen, deren Rechte der Aufrufer selbst hat.
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Param        rule body structs.RoleRule true "Regel"
+// @Success      201 {object} structs.RoleRule
+// @Failure      400 {object} structs.ErrorResponse "Invalid rule or role"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Role grants permissions you do not have"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/role-rules [post]
+func CreateRoleRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var rule structs.RoleRule
+	if err := decodeJSON(r, &rule); err != nil {
+		badJSON(w, r, err)
+		return
+	}
+	switch rule.MatchType {
+	case "corp", "alliance":
+		if rule.MatchID == nil || *rule.MatchID <= 0 {
+			jsonError(w, r, http.StatusBadRequest, field(r, "matchId")+" required for corp/alliance rules")
+			return
+		}
+	case "default":
+		rule.MatchID = nil
+	default:
+		jsonError(w, r, http.StatusBadRequest, field(r, "matchType")+" must be corp, alliance or default")
+		return
+	}
+	// Regeln greifen beim nächsten Login: nur Rollen, deren Rechte der Aufrufer selbst hat
+	if !canGrantRoles(w, r, []string{rule.Role}) {
+		return
+	}
+
+	out, err := db2.InsertRoleRule(r.Context(), rule)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusCreated, out)
+}
+
+// DeleteRoleRuleHandler godoc
+// @Summary      Rollen-Regel löschen
+// @Tags         Roles
+// @Param        id path int true "Regel ID"
+// @Success      204 {string} string "No Content"
+// @Failure      400 {object} structs.ErrorResponse "Invalid id"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/role-rules/{id} [delete]
+func DeleteRoleRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid id")
+		return
+	}
+	if err := db2.DeleteRoleRule(r.Context(), id); err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB Delete error", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUserRoleAssignmentsHandler godoc
+// @Summary      Rollen-Zuweisungen eines Benutzers
+// @Description  Zuweisungen samt Herkunft (manual/rule/default).
+// @Tags         Roles
+// @Produce      json
+// @Param        charID path string true "Character ID"
+// @Success      200 {array}  structs.RoleAssignment
+// @Failure      400 {object} structs.ErrorResponse "Invalid charID"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/users/{charID}/roles [get]
+func GetUserRoleAssignmentsHandler(w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseInt(chi.URLParam(r, "charID"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid charID")
+		return
+	}
+	list, err := db2.GetUserRoleAssignments(r.Context(), charID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
+
+// ClearManualRolesHandler godoc
+// @Summary      Manuelle Rollen aufheben
+// @Description  Hebt die manuelle Überschreibung auf, danach greifen wieder die Regeln.
+// @Tags         Roles
+// @Param        charID path string true "Character ID"
+// @Success      204 {string} string "No Content"
+// @Failure      400 {object} structs.ErrorResponse "Invalid charID"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "User has permissions you do not have"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/users/{charID}/roles/manual [delete]
+func ClearManualRolesHandler(w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseInt(chi.URLParam(r, "charID"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid charID")
+		return
+	}
+	if !canAssignRoles(w, r, charID, nil) {
+		return
+	}
+	if err := db2.ClearManualRoles(r.Context(), charID, changedBy(r)); err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	if err := users.ApplyRoleRules(r.Context(), charID); err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Rule evaluation failed", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListRoleChangesHandler godoc
+// @Summary      Rollen-Änderungen eines Benutzers
+// @Tags         Roles
+// @Produce      json
+// @Param        charID path  string true  "Character ID"
+// @Param        limit  query int    false "max. Einträge"
+// @Success      200 {array}  structs.RoleChange
+// @Failure      400 {object} structs.ErrorResponse "Invalid charID"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "DB error"
+// @Router       /app/v1/users/{charID}/role-changes [get]
+func ListRoleChangesHandler(w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseInt(chi.URLParam(r, "charID"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid charID")
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	list, err := db2.ListRoleChanges(r.Context(), charID, limit)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB error", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, list)
+}
+
+// RefreshUserAffiliationHandler godoc
+// @Summary      Corp/Alliance eines Benutzers aktualisieren
+// @Description  Holt die Zugehörigkeit per ESI; verstößt sie gegen die Zugangs-Policy, geht der Char in Quarantäne.
+// @Tags         Admin
+// @Param        charID path string true "Character ID"
+// @Success      204 {string} string "No Content"
+// @Failure      400 {object} structs.ErrorResponse "Invalid charID"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      500 {object} structs.ErrorResponse "Refresh failed"
+// @Router       /app/v1/users/{charID}/affiliation/refresh [post]
+func RefreshUserAffiliationHandler(w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseInt(chi.URLParam(r, "charID"), 10, 64)
+	if err != nil {
+		jsonError(w, r, http.StatusBadRequest, "Invalid charID")
+		return
+	}
+	if err := users.RefreshAffiliation(r.Context(), charID); err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Refresh failed", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+var refreshRunning atomic.Bool
+
+// RefreshAllAffiliationsHandler godoc
+// @Summary      Alle Zugehörigkeiten aktualisieren
+// @Description  Läuft im Hintergrund; höchstens ein Lauf gleichzeitig.
+// @Tags         Admin
+// @Success      202 {string} string "Accepted"
+// @Failure      401 {object} structs.ErrorResponse "Unauthorized"
+// @Failure      403 {object} structs.ErrorResponse "Forbidden"
+// @Failure      409 {object} structs.ErrorResponse "Refresh already running"
+// @Router       /app/v1/affiliations/refresh [post]
+func RefreshAllAffiliationsHandler(w http.ResponseWriter, r *http.Request) {
+	if !refreshRunning.CompareAndSwap(false, true) {
+		jsonError(w, r, http.StatusConflict, "Refresh already running")
+		return
+	}
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		defer refreshRunning.Store(false)
+		if err := users.RefreshAllAffiliations(backgroundCtx); err != nil && backgroundCtx.Err() == nil {
+			log.Printf("RefreshAllAffiliations: %v", err)
+		}
+	}()
+	w.WriteHeader(http.StatusAccepted)
+}
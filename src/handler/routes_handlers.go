@@ -1,70 +1,225 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"speedliner-server/src/utils/apijson"
+	"speedliner-server/src/utils/routeio"
 	"speedliner-server/src/utils/structs"
 	"strconv"
+	"strings"
+	"time"
 
 	db2 "speedliner-server/src/db"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func RoutesHandler(w http.ResponseWriter, r *http.Request) {
-	var charID *int64
-	var role string
-
-	if c, err := r.Cookie("char"); err == nil && c.Value != "" {
-		if v, err2 := strconv.ParseInt(c.Value, 10, 64); err2 == nil {
-			charID = &v
-			if rr, err3 := db2.GetUserRoles(v); err3 == nil {
-				role = rr
-			}
-		}
+// RoutesHandler godoc
+// @Summary      Get all active routes
+// @Description  Gibt alle für den Aufrufer sichtbaren Transport-Routen zurück
+// @Tags         Routes
+// @Produce      json
+// @Success      200 {array} structs.Route
+// @Failure      401 {object} structs.ErrorResponse "Login required (ALLOW_ANONYMOUS_ROUTES=false)"
+// @Failure      500 {object} structs.ErrorResponse
+// @Router       /app/v1/routes [get]
+func (h *Handler) RoutesHandler(w http.ResponseWriter, r *http.Request) {
+	charID, perms := currentUser(r)
+	if !h.canSeeRoutes(r.Context(), charID) {
+		jsonError(w, r, http.StatusUnauthorized, "Login required")
+		return
 	}
 
-	routes, err := db2.GetAllRoutesForUser(charID, role)
+	routes, err := h.routes.ListForUser(r.Context(), charID, perms.HasAny(structs.PermRoutesViewAll))
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, "Failed to fetch routes: "+err.Error())
+		serverError(w, r, http.StatusInternalServerError, "Failed to fetch routes", err)
 		return
 	}
-	writeJSON(w, http.StatusOK, routes)
+	writeJSON(w, r, http.StatusOK, routes)
 }
 
-func CreateRouteHandler(w http.ResponseWriter, r *http.Request) {
+// CreateRouteHandler godoc
+// @Summary      Neue Route anlegen
+// @Description  Erstellt eine neue Transport-Route
+// @Tags         Routes
+// @Accept       json
+// @Produce      json
+// @Param        route body structs.Route true "Neue Route"
+// @Success      201 {object} structs.Route
+// @Failure      400 {object} structs.ErrorResponse
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      403 {object} structs.ErrorResponse
+// @Failure      500 {object} structs.ErrorResponse
+// @Router       /app/v1/routes [post]
+func (h *Handler) CreateRouteHandler(w http.ResponseWriter, r *http.Request) {
 	var route structs.Route // oder structs.Route – je nach deiner Definition
-	if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
-		jsonError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+	if err := decodeJSON(r, &route); err != nil {
+		badJSON(w, r, err)
 		return
 	}
-	if err := db2.InsertRoute(route); err != nil {
-		jsonError(w, http.StatusInternalServerError, "DB Insert error: "+err.Error())
+	if err := h.routes.Insert(r.Context(), route); errors.Is(err, db2.ErrInvalidRoute) {
+		apijson.Error(w, r, http.StatusBadRequest, "Invalid route", err.Error())
+		return
+	} else if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB Insert error", err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, route)
+	writeJSON(w, r, http.StatusCreated, route)
 }
 
-func UpdateRouteHandler(w http.ResponseWriter, r *http.Request) {
+// UpdateRouteHandler godoc
+// @Summary      Route aktualisieren
+// @Description  Aktualisiert eine bestehende Transport-Route anhand der ID
+// @Tags         Routes
+// @Accept       json
+// @Produce      json
+// @Param        id    path string        true "Route ID"
+// @Param        route body structs.Route true "Route"
+// @Success      200 {object} structs.Route
+// @Failure      400 {object} structs.ErrorResponse
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      403 {object} structs.ErrorResponse
+// @Failure      500 {object} structs.ErrorResponse
+// @Router       /app/v1/routes/{id} [put]
+func (h *Handler) UpdateRouteHandler(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	var route structs.Route // oder structs.Route
-	if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
-		jsonError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+	if err := decodeJSON(r, &route); err != nil {
+		badJSON(w, r, err)
 		return
 	}
 	route.ID = id
-	if err := db2.UpdateRoute(route); err != nil {
-		jsonError(w, http.StatusInternalServerError, "DB Update error: "+err.Error())
+	if err := h.routes.Update(r.Context(), route); errors.Is(err, db2.ErrInvalidRoute) {
+		apijson.Error(w, r, http.StatusBadRequest, "Invalid route", err.Error())
+		return
+	} else if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB Update error", err)
 		return
 	}
-	writeJSON(w, http.StatusOK, route)
+	writeJSON(w, r, http.StatusOK, route)
 }
 
-func DeleteRouteHandler(w http.ResponseWriter, r *http.Request) {
+// DeleteRouteHandler godoc
+// @Summary      Route löschen
+// @Description  Löscht eine Transport-Route anhand der ID
+// @Tags         Routes
+// @Param        id path string true "Route ID"
+// @Success      204 {string} string "Deleted"
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      403 {object} structs.ErrorResponse
+// @Failure      500 {object} structs.ErrorResponse
+// @Router       /app/v1/routes/{id} [delete]
+func (h *Handler) DeleteRouteHandler(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if err := db2.DeleteRoute(id); err != nil {
-		jsonError(w, http.StatusInternalServerError, "DB Delete error: "+err.Error())
+	if err := h.routes.Delete(r.Context(), id); err != nil {
+		serverError(w, r, http.StatusInternalServerError, "DB Delete error", err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// ExportRoutesHandler godoc
+// @Summary      Routen exportieren
+// @Description  Alle Routen inkl. Sichtbarkeit, Mindestpreis und Whitelist-Corps (IDs + Ticker) als JSON oder CSV
+// @Tags         Routes
+// @Produce      json
+// @Produce      text/csv
+// @Param        format query string false "json (Default) oder csv" Enums(json, csv)
+// @Success      200 {array} structs.RouteExport
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      403 {object} structs.ErrorResponse
+// @Failure      500 {object} structs.ErrorResponse
+// @Router       /app/v1/routes/export [get]
+func ExportRoutesHandler(w http.ResponseWriter, r *http.Request) {
+	routes, err := db2.ExportRoutes(r.Context())
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to export routes", err)
+		return
+	}
+	name := "routes-" + time.Now().UTC().Format("20060102")
+	if routeFormat(r) == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".csv"))
+		_ = routeio.WriteCSV(w, routes)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".json"))
+	writeJSON(w, r, http.StatusOK, routes)
+}
+
+// maxRouteImportBytes begrenzt die Größe einer Import-Datei.
+const maxRouteImportBytes = 2 << 20
+
+// ImportRoutesHandler godoc
+// @Summary      Routen importieren
+// @Description  Gleicht die Routen mit einer CSV- oder JSON-Datei (Format wie Export) ab. Zuordnung per ID, sonst per Von/Nach.
+// @Description  dry_run=true liefert nur den Plan (creates/updates/deletes); sonst wird alles in einer Transaktion angewendet.
+// @Description  prune=true löscht Routen, die in der Datei fehlen. Jede Zeile wird wie beim Anlegen/Ändern validiert;
+// @Description  bei Fehlern wird nichts geändert (422 mit Plan und Zeilenfehlern).
+// @Tags         Routes
+// @Accept       json
+// @Accept       text/csv
+// @Produce      json
+// @Param        format  query string false "json oder csv (Default: aus Content-Type)" Enums(json, csv)
+// @Param        dry_run query bool   false "Nur planen, nichts ändern"
+// @Param        prune   query bool   false "Fehlende Routen löschen"
+// @Param        routes  body  []structs.RouteExport true "Routen"
+// @Success      200 {object} structs.RouteImportPlan
+// @Failure      400 {object} structs.ErrorResponse
+// @Failure      401 {object} structs.ErrorResponse
+// @Failure      403 {object} structs.ErrorResponse
+// @Failure      422 {object} structs.RouteImportPlan
+// @Failure      500 {object} structs.ErrorResponse
+// @Router       /app/v1/routes/import [post]
+func ImportRoutesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	dryRun, _ := strconv.ParseBool(q.Get("dry_run"))
+	prune, _ := strconv.ParseBool(q.Get("prune"))
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRouteImportBytes)
+	var rows []structs.RouteImportRow
+	if routeFormat(r) == "csv" {
+		var err error
+		if rows, err = routeio.ReadCSV(r.Body); err != nil {
+			apijson.Error(w, r, http.StatusBadRequest, "Invalid CSV", err.Error())
+			return
+		}
+	} else {
+		var list []structs.RouteExport
+		if err := decodeJSON(r, &list); err != nil {
+			badJSON(w, r, err)
+			return
+		}
+		rows = routeio.Rows(list)
+	}
+
+	plan, err := db2.ImportRoutes(r.Context(), rows, prune, dryRun)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Route import failed", err)
+		return
+	}
+	if len(plan.Errors) > 0 {
+		writeJSON(w, r, http.StatusUnprocessableEntity, plan)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, plan)
+}
+
+// routeFormat: "csv" per ?format=csv, Content-Type (Import) oder Accept (Export), sonst "json".
+func routeFormat(r *http.Request) string {
+	if f := strings.ToLower(r.URL.Query().Get("format")); f != "" {
+		return f
+	}
+	if r.Method == http.MethodGet {
+		if strings.Contains(r.Header.Get("Accept"), "text/csv") {
+			return "csv"
+		}
+		return "json"
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		return "csv"
+	}
+	return "json"
+}
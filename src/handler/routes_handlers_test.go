@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"speedliner-server/src/middleware"
+	"speedliner-server/src/repo/memrepo"
+	"speedliner-server/src/utils/access"
+	"speedliner-server/src/utils/structs"
+	"speedliner-server/src/utils/users"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	memberCorp   = 98000001
+	outsiderCorp = 98000002
+
+	memberID   = 90000001
+	outsiderID = 90000002
+	providerID = 90000003
+	quarantID  = 90000004
+)
+
+// newRouteStore: memrepo mit einer öffentlichen und einer Whitelist-Route; routesRouter hängt die echte Auth-Middleware davor.
+func newRouteStore(t *testing.T) *memrepo.Store {
+	t.Helper()
+	s := memrepo.New()
+	s.PutUser(memrepo.User{CharID: memberID, Name: "Member", CorpID: memberCorp})
+	s.PutUser(memrepo.User{CharID: outsiderID, Name: "Outsider", CorpID: outsiderCorp})
+	s.PutUser(memrepo.User{CharID: providerID, Name: "Provider", CorpID: outsiderCorp, Roles: []string{"provider"}})
+	s.PutUser(memrepo.User{CharID: quarantID, Name: "Quarantined", CorpID: memberCorp, Quarantined: true})
+
+	s.PutRoute(structs.Route{ID: "public", From: "Amarr", To: "Jita", PricePerM3: 500, Visibility: "all"})
+	s.PutRoute(structs.Route{ID: "corp", From: "Jita", To: "K-6K16", PricePerM3: 900, Visibility: "whitelist", AllowedCorps: []int64{memberCorp}})
+
+	users.InitRepository(s.Repos().Users)
+	prev := access.Current()
+	t.Cleanup(func() { access.Set(prev) })
+	return s
+}
+
+func routesRouter(s *memrepo.Store) http.Handler {
+	h := New(s.Repos())
+	r := chi.NewRouter()
+	r.Use(middleware.AuthMiddleware)
+	r.Get("/app/routes", h.RoutesHandler)
+	r.Post("/app/quote", h.QuoteHandler)
+	return r
+}
+
+func request(t *testing.T, h http.Handler, method, target string, charID int64, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if charID != 0 {
+		req.AddCookie(&http.Cookie{Name: "char", Value: strconv.FormatInt(charID, 10)})
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func routeIDs(t *testing.T, rec *httptest.ResponseRecorder) []string {
+	t.Helper()
+	var list []structs.Route
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode routes: %v (%s)", err, rec.Body.String())
+	}
+	ids := make([]string, 0, len(list))
+	for _, r := range list {
+		ids = append(ids, r.ID)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestRoutesVisibility(t *testing.T) {
+	s := newRouteStore(t)
+	h := routesRouter(s)
+
+	cases := []struct {
+		name   string
+		charID int64
+		want   []string
+	}{
+		{"anonymous", 0, []string{"public"}},
+		{"outsider", outsiderID, []string{"public"}},
+		{"whitelisted member", memberID, []string{"corp", "public"}},
+		{"view_all", providerID, []string{"corp", "public"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := request(t, h, http.MethodGet, "/app/routes", c.charID, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d, want 200 (%s)", rec.Code, rec.Body.String())
+			}
+			if got := routeIDs(t, rec); !slices.Equal(got, c.want) {
+				t.Errorf("routes %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRoutesLoginRequired(t *testing.T) {
+	s := newRouteStore(t)
+	h := routesRouter(s)
+	p := access.Current()
+	p.AllowAnonymousRoutes = false
+	access.Set(p)
+
+	cases := []struct {
+		name   string
+		charID int64
+		want   int
+	}{
+		{"anonymous", 0, http.StatusUnauthorized},
+		{"quarantined", quarantID, http.StatusUnauthorized},
+		{"member", memberID, http.StatusOK},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if rec := request(t, h, http.MethodGet, "/app/routes", c.charID, ""); rec.Code != c.want {
+				t.Errorf("status %d, want %d (%s)", rec.Code, c.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestQuoteHiddenRoute(t *testing.T) {
+	s := newRouteStore(t)
+	h := routesRouter(s)
+	body := `{"routeId":"corp","volumeM3":1000,"collateralISK":0}`
+
+	// Whitelist-Route existiert für Außenstehende nicht
+	if rec := request(t, h, http.MethodPost, "/app/quote", 0, body); rec.Code != http.StatusNotFound {
+		t.Errorf("anonymous: status %d, want 404", rec.Code)
+	}
+	if rec := request(t, h, http.MethodPost, "/app/quote", outsiderID, body); rec.Code != http.StatusNotFound {
+		t.Errorf("outsider: status %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+	"speedliner-server/src/utils/reqctx"
+	"strings"
+)
+
+// APIV1Middleware markiert Requests unter /app/v1 (snake_case-Felder im JSON).
+func APIV1Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(reqctx.WithAPIV1(r.Context())))
+	})
+}
+
+// DeprecatedAPIMiddleware kennzeichnet die alten /app/-Pfade als veraltet und verweist auf /app/v1.
+func DeprecatedAPIMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		if rest, ok := strings.CutPrefix(r.URL.Path, "/app/"); ok {
+			w.Header().Set("Link", `</app/v1/`+rest+`>; rel="successor-version"`)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
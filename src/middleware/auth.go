@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"speedliner-server/src/utils/apijson"
+	"speedliner-server/src/utils/apitokens"
+	"speedliner-server/src/utils/metrics"
+	"speedliner-server/src/utils/structs"
+	"speedliner-server/src/utils/users"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Identity: wer stellt den Request – Browser-Session (Cookie) oder API-Token.
+type Identity struct {
+	CharID int64
+	Token  *structs.APIToken // nil = Cookie
+}
+
+// Permissions: Rechte des Chars, bei Tokens auf die Scopes beschränkt.
+func (id *Identity) Permissions(ctx context.Context) (structs.PermissionSet, error) {
+	perms, err := users.Permissions(ctx, id.CharID)
+	if err != nil {
+		return nil, err
+	}
+	if id.Token == nil {
+		return perms, nil
+	}
+	scoped := structs.PermissionSet{}
+	for _, s := range id.Token.Scopes {
+		if perms[s] {
+			scoped[s] = true
+		}
+	}
+	return scoped, nil
+}
+
+const identityKey = contextKey("identity")
+
+// IdentityFrom liefert die Identität aus dem Context (nil = anonym).
+func IdentityFrom(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey).(*Identity)
+	return id
+}
+
+var tokenLimiters sync.Map // Token-ID -> *visitor
+
+func tokenLimiter(t *structs.APIToken) *rate.Limiter {
+	now := time.Now()
+	if v, ok := tokenLimiters.Load(t.ID); ok {
+		vis := v.(*visitor)
+		vis.lastSeen.Store(now.UnixNano())
+		return vis.lim
+	}
+	perMin := apitokens.DefaultRatePerMin()
+	if t.RatePerMin != nil {
+		perMin = *t.RatePerMin
+	}
+	lim := rate.NewLimiter(rate.Limit(float64(perMin)/60), perMin)
+	vis := &visitor{lim: lim}
+	vis.lastSeen.Store(now.UnixNano())
+	v, _ := tokenLimiters.LoadOrStore(t.ID, vis)
+	return v.(*visitor).lim
+}
+
+// AuthMiddleware ermittelt die Identität: "Authorization: Bearer <token>" oder das char-Cookie.
+// Ein ungültiger Bearer-Token wird abgewiesen (kein Fallback aufs Cookie).
+func AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var id *Identity
+		if h := r.Header.Get("Authorization"); h != "" {
+			raw, ok := strings.CutPrefix(h, "Bearer ")
+			if !ok {
+				apijson.Error(w, r, http.StatusUnauthorized, "Unsupported authorization scheme", nil)
+				return
+			}
+			t, err := apitokens.Resolve(r.Context(), strings.TrimSpace(raw))
+			if err != nil {
+				apijson.Error(w, r, http.StatusInternalServerError, "Error checking API token", nil)
+				return
+			}
+			if t == nil {
+				apijson.Error(w, r, http.StatusUnauthorized, "Invalid API token", nil)
+				return
+			}
+			if !tokenLimiter(t).Allow() {
+				metrics.RateLimitRejections.WithLabelValues("api_token").Inc()
+				w.Header().Set("Retry-After", "1")
+				apijson.Error(w, r, http.StatusTooManyRequests, "Too many requests", nil)
+				return
+			}
+			id = &Identity{CharID: t.CharID, Token: t}
+		} else if c, err := r.Cookie("char"); err == nil && c.Value != "" {
+			if v, err := strconv.ParseInt(c.Value, 10, 64); err == nil {
+				id = &Identity{CharID: v}
+			}
+		}
+		if id != nil {
+			r = r.WithContext(context.WithValue(r.Context(), identityKey, id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -14,12 +14,14 @@ import (
 	"net/http"
 	"os"
 	"path"
-	"strconv"
+	"speedliner-server/src/config"
+	"speedliner-server/src/db"
+	"speedliner-server/src/utils/metrics"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"golang.org/x/time/rate"
 )
 
@@ -49,14 +51,9 @@ type loggingResponseWriter struct {
 }
 
 // InitializeLogger konfiguriert Stdlog + slog (JSON) auf Konsole + Datei.
-// ENV-Variablen (optional):
-//
-//	LOG_LEVEL=debug|info|warn|error
-//	LOG_ASSET_SKIP_FAST=true|false
-//	LOG_ASSET_SAMPLE_N=10           (jeden 10ten Asset-Request)
-//	LOG_2XX_SAMPLE_N=0              (Sampling für „langweilige“ 2xx)
-//	LOG_UA_REF_MAXLEN=120
-func InitializeLogger(logFile string) error {
+// Einstellungen aus config.Log (LOG_LEVEL, LOG_ASSET_SKIP_FAST, LOG_ASSET_SAMPLE_N,
+// LOG_2XX_SAMPLE_N, LOG_UA_REF_MAXLEN).
+func InitializeLogger(logFile string, c config.Log) error {
 	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
@@ -67,38 +64,25 @@ func InitializeLogger(logFile string) error {
 	log.SetOutput(multi)
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
-	// ENV einlesen
-	if v := strings.ToLower(os.Getenv("LOG_LEVEL")); v != "" {
-		switch v {
-		case "debug":
-			loggerLevel = slog.LevelDebug
-		case "info":
-			loggerLevel = slog.LevelInfo
-		case "warn", "warning":
-			loggerLevel = slog.LevelWarn
-		case "error":
-			loggerLevel = slog.LevelError
-		}
-	}
-	if v := os.Getenv("LOG_ASSET_SKIP_FAST"); v != "" {
-		if b, err := strconv.ParseBool(v); err == nil {
-			assetSkipFast = b
-		}
+	switch strings.ToLower(c.Level) {
+	case "debug":
+		loggerLevel = slog.LevelDebug
+	case "warn", "warning":
+		loggerLevel = slog.LevelWarn
+	case "error":
+		loggerLevel = slog.LevelError
+	default:
+		loggerLevel = slog.LevelInfo
 	}
-	if v := os.Getenv("LOG_ASSET_SAMPLE_N"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
-			assetSampleN = n
-		}
+	assetSkipFast = c.AssetSkipFast
+	if c.AssetSampleN >= 0 {
+		assetSampleN = c.AssetSampleN
 	}
-	if v := os.Getenv("LOG_2XX_SAMPLE_N"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
-			twoXXSampleN = n
-		}
+	if c.TwoXXSampleN >= 0 {
+		twoXXSampleN = c.TwoXXSampleN
 	}
-	if v := os.Getenv("LOG_UA_REF_MAXLEN"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			maxUARefLen = n
-		}
+	if c.UARefMaxLen > 0 {
+		maxUARefLen = c.UARefMaxLen
 	}
 
 	// slog → JSON auf multi
@@ -150,11 +134,10 @@ func (lw *loggingResponseWriter) Push(target string, opts *http.PushOptions) err
 
 // LoggerMiddleware protokolliert strukturiert + robust.
 // Features:
-//   - Request-ID
+//   - Request-ID (aus RequestIDMiddleware)
 //   - Debug-Start-Log
 //   - Asset-Filter/Sampling
 //   - Level nach Status/Latenz
-//   - Panic-Recovery
 type contextKey string
 
 func LoggerMiddleware(next http.Handler) http.Handler {
@@ -168,18 +151,13 @@ func LoggerMiddleware(next http.Handler) http.Handler {
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		reqID := ensureRequestID(r)
-		w.Header().Set(reqIDHeader, reqID)
-
-		clientIP := clientIPFromRequest(r)
+		clientIP := ClientIP(r)
 		ua := truncate(r.UserAgent(), maxUARefLen)
 		ref := truncate(r.Referer(), maxUARefLen)
 		cl := r.Header.Get("Content-Length")
 
-		ctx := context.WithValue(r.Context(), contextKey(reqIDHeader), reqID)
-		r = r.WithContext(ctx)
 		log := logger.With(
-			"req.id", reqID,
+			"req.id", RequestID(r),
 			"req.method", r.Method,
 			"req.path", r.URL.Path,
 			"req.query", r.URL.RawQuery,
@@ -195,20 +173,17 @@ func LoggerMiddleware(next http.Handler) http.Handler {
 		// Response wrappen
 		lw := &loggingResponseWriter{ResponseWriter: w, status: http.StatusOK}
 
-		// Panic-Recovery + Abschlusslog
+		// Abschlusslog (Panics fängt RecoverMiddleware innerhalb dieser Stufe)
 		defer func() {
-			if rec := recover(); rec != nil {
-				lw.WriteHeader(http.StatusInternalServerError)
-				log.Error("panic recovered",
-					"error", rec,
-					"duration_ms", durationMs(time.Since(start)),
-				)
-			}
-
 			d := time.Since(start)
 			status := lw.status
 			isAsset := isAssetPath(r.URL.Path)
 
+			// Route-Pattern aus dem chi-Context – die Pipeline hängt deshalb im Router (r.Use)
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				metrics.ObserveHTTP(r.Method, rctx.RoutePattern(), status, d)
+			}
+
 			// „Langweilige“ Assets ggf. komplett überspringen
 			if isAsset && status < 400 && d < slowThreshold && assetSkipFast {
 				// mit Sampling optional trotzdem ab und zu loggen
@@ -244,8 +219,9 @@ func LoggerMiddleware(next http.Handler) http.Handler {
 
 // ---- helpers ----
 
+// ensureRequestID übernimmt X-Request-ID vom Proxy, sofern harmlos (landet in Logs und Antworten).
 func ensureRequestID(r *http.Request) string {
-	if id := r.Header.Get(reqIDHeader); id != "" {
+	if id := r.Header.Get(reqIDHeader); validRequestID(id) {
 		return id
 	}
 	var b [16]byte
@@ -253,24 +229,6 @@ func ensureRequestID(r *http.Request) string {
 	return hex.EncodeToString(b[:])
 }
 
-func clientIPFromRequest(r *http.Request) string {
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		parts := strings.Split(xff, ",")
-		ip := strings.TrimSpace(parts[0])
-		if ip != "" {
-			return ip
-		}
-	}
-	if rip := r.Header.Get("X-Real-IP"); rip != "" {
-		return rip
-	}
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err == nil && host != "" {
-		return host
-	}
-	return r.RemoteAddr
-}
-
 func sanitizeHeaders(h http.Header) http.Header {
 	const maxVals = 3
 	clone := http.Header{}
@@ -305,73 +263,43 @@ func NoCacheMiddleware(next http.Handler) http.Handler {
 
 type visitor struct {
 	lim      *rate.Limiter
-	lastSeen time.Time
+	lastSeen atomic.Int64 // UnixNano; Requests schreiben, StartCleanup liest nebenläufig
 }
 
-var (
-	visitors sync.Map
-	ttl      = 10 * time.Minute // Besucher-Einträge nach Inaktivität aufräumen
-	rps      = rate.Limit(5)    // 5 Requests pro Sekunde
-	burst    = 10               // bis zu 10 auf einmal erlauben
-	ticker   = time.NewTicker(5 * time.Minute)
-)
+var ttl = 10 * time.Minute // Limiter-/Zähler-Einträge nach Inaktivität aufräumen
 
-func init() {
-	// Periodisches Cleanup
+// StartCleanup räumt periodisch Token-Limiter und Rate-Limit-Zähler auf; endet mit ctx.
+// wait blockiert, bis die Goroutine nach dem Ende von ctx beendet ist.
+func StartCleanup(ctx context.Context) (wait func()) {
+	done := make(chan struct{})
 	go func() {
-		for range ticker.C {
-			now := time.Now()
-			visitors.Range(func(k, v any) bool {
-				if now.Sub(v.(*visitor).lastSeen) > ttl {
-					visitors.Delete(k)
+		defer close(done)
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		pruneAt := time.Now().Add(time.Hour)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				tokenLimiters.Range(func(k, v any) bool {
+					if now.Sub(time.Unix(0, v.(*visitor).lastSeen.Load())) > ttl {
+						tokenLimiters.Delete(k)
+					}
+					return true
+				})
+				memStore.prune(now)
+				// Postgres-Zähler stündlich
+				if _, ok := rateStore.(pgRateStore); ok && now.After(pruneAt) {
+					pruneAt = now.Add(time.Hour)
+					if _, err := db.PruneRateLimits(ctx, 24*time.Hour); err != nil {
+						slog.Warn("rate limit prune failed", "error", err)
+					}
 				}
-				return true
-			})
+			}
 		}
 	}()
-}
-
-func keyFor(r *http.Request) string {
-	ip := clientIPFromRequest(r)
-	// ggf. Pfade grob gruppieren, damit Assets nicht pro Datei zählen:
-	path := r.URL.Path
-	switch {
-	case strings.HasPrefix(path, "/assets/"):
-		path = "/assets/*"
-	case strings.HasPrefix(path, "/app/login"):
-		path = "/app/login"
-	}
-	return ip + "|" + path
-}
-
-func getLimiter(key string) *rate.Limiter {
-	now := time.Now()
-	if v, ok := visitors.Load(key); ok {
-		vis := v.(*visitor)
-		vis.lastSeen = now
-		return vis.lim
-	}
-	lim := rate.NewLimiter(rps, burst)
-	visitors.Store(key, &visitor{lim: lim, lastSeen: now})
-	return lim
-}
-
-// RateLimit: sanftes Ratenlimit mit Burst; optional nur auf „write“-Methoden anwenden.
-func RateLimit(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Optional: nur auf sensible Methoden/Pfade limitieren
-		if r.Method == http.MethodGet || r.Method == http.MethodHead {
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		lim := getLimiter(keyFor(r))
-		if !lim.Allow() {
-			http.Error(w, "Too many requests", http.StatusTooManyRequests)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+	return func() { <-done }
 }
 
 // ---- kleine Utils ----
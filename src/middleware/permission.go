@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"speedliner-server/src/utils/apijson"
+	"speedliner-server/src/utils/users"
+	"strconv"
+)
+
+// PermissionMiddleware lässt nur Chars durch, die mindestens eins der Rechte besitzen
+// (bei API-Tokens zusätzlich durch die Scopes begrenzt). Setzt AuthMiddleware voraus.
+func PermissionMiddleware(perms ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := IdentityFrom(r.Context())
+			if id == nil {
+				apijson.Error(w, r, http.StatusUnauthorized, "Not authenticated", nil)
+				return
+			}
+
+			set, err := id.Permissions(r.Context())
+			if err != nil {
+				apijson.Error(w, r, http.StatusInternalServerError, "Error checking user permissions", nil)
+				return
+			}
+			if !set.HasAny(perms...) {
+				apijson.Error(w, r, http.StatusForbidden, "Forbidden", nil)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), contextKey("char"), strconv.FormatInt(id.CharID, 10))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// BanMiddleware weist Requests gesperrter Chars ab und löscht deren Cookie.
+func BanMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("char")
+		if err != nil || cookie.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		banned, err := users.IsBanned(r.Context(), cookie.Value)
+		if err != nil || !banned {
+			next.ServeHTTP(w, r)
+			return
+		}
+		SetCookie(w, &http.Cookie{Name: "char", Value: "", Path: "/", HttpOnly: true, MaxAge: -1})
+		apijson.Error(w, r, http.StatusForbidden, "Account banned", nil)
+	})
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"speedliner-server/src/repo/memrepo"
+	"speedliner-server/src/utils/structs"
+	"speedliner-server/src/utils/users"
+	"strconv"
+	"testing"
+)
+
+func TestPermissionMiddleware(t *testing.T) {
+	const (
+		userID     = 91000001
+		providerID = 91000002
+		altID      = 91000003
+		bannedID   = 91000004
+	)
+	s := memrepo.New()
+	s.PutUser(memrepo.User{CharID: userID, Name: "User"})
+	s.PutUser(memrepo.User{CharID: providerID, Name: "Provider", Roles: []string{"provider"}})
+	s.PutUser(memrepo.User{CharID: altID, Name: "Alt", MainCharID: providerID})
+	s.PutUser(memrepo.User{CharID: bannedID, Name: "Banned", Roles: []string{"admin"}, Banned: true})
+	users.InitRepository(s.Repos().Users)
+
+	var reached int64
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = IdentityFrom(r.Context()).CharID
+		w.WriteHeader(http.StatusNoContent)
+	})
+	h := AuthMiddleware(PermissionMiddleware(structs.PermRoutesEdit)(next))
+
+	cases := []struct {
+		name   string
+		cookie string
+		bearer string
+		want   int
+	}{
+		{"anonymous", "", "", http.StatusUnauthorized},
+		{"invalid cookie", "abc", "", http.StatusUnauthorized},
+		{"unknown api token", "", "nope", http.StatusUnauthorized},
+		{"without permission", strconv.Itoa(userID), "", http.StatusForbidden},
+		{"unknown char", "91999999", "", http.StatusForbidden},
+		{"banned admin", strconv.Itoa(bannedID), "", http.StatusForbidden},
+		{"provider", strconv.Itoa(providerID), "", http.StatusNoContent},
+		// Rollen gelten pro Account: der Alt erbt provider vom Main
+		{"alt of provider", strconv.Itoa(altID), "", http.StatusNoContent},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			reached = 0
+			req := httptest.NewRequest(http.MethodGet, "/app/routes/export", nil)
+			if c.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "char", Value: c.cookie})
+			}
+			if c.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+c.bearer)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != c.want {
+				t.Fatalf("status %d, want %d (%s)", rec.Code, c.want, rec.Body.String())
+			}
+			if ok := c.want == http.StatusNoContent; ok != (reached != 0) {
+				t.Errorf("next reached=%v, want %v", reached != 0, ok)
+			}
+		})
+	}
+}
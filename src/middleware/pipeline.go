@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"speedliner-server/src/config"
+	"speedliner-server/src/utils/apijson"
+	"speedliner-server/src/utils/reqctx"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// Pipeline liefert die globale Middleware-Kette (außen → innen) für router.NewRouter; abgeschaltete
+// Stufen (config.Middleware) fallen einfach weg. Recover sitzt innerhalb von Logging, damit eine
+// Panic als 500 im Log landet; RateLimit hinter CORS, damit auch 429 für Skripte lesbar ist.
+func Pipeline(c config.Middleware) []func(http.Handler) http.Handler {
+	stages := []struct {
+		on bool
+		mw func(http.Handler) http.Handler
+	}{
+		{c.RequestID, RequestIDMiddleware},
+		{c.RealIP, RealIPMiddleware},
+		{c.Logging, LoggerMiddleware},
+		{c.Recover, RecoverMiddleware},
+		{c.SecurityHeaders, SecurityHeadersMiddleware},
+		{c.CORS, CORSMiddleware},
+		{c.RateLimit, RateLimit},
+		{c.Compress, compress},
+	}
+	var out []func(http.Handler) http.Handler
+	for _, s := range stages {
+		if s.on {
+			out = append(out, s.mw)
+		}
+	}
+	return out
+}
+
+// RequestIDMiddleware vergibt die Request-ID (bzw. übernimmt X-Request-ID) und gibt sie in der Antwort zurück.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := ensureRequestID(r)
+		w.Header().Set(reqIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(reqctx.WithRequestID(r.Context(), id)))
+	})
+}
+
+// RequestID liefert die Request-ID des Requests ("" = RequestIDMiddleware abgeschaltet).
+func RequestID(r *http.Request) string {
+	return reqctx.RequestID(r.Context())
+}
+
+// validRequestID: höchstens 64 Zeichen aus [A-Za-z0-9._-].
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RecoverMiddleware fängt Panics im Handler ab: Stacktrace ins Log, 500 an den Client
+// (sofern noch nichts geschrieben wurde).
+func RecoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler { // gewollter Abbruch, net/http kümmert sich
+				panic(rec)
+			}
+			slog.Error("panic recovered", "error", rec, "req.id", RequestID(r), "req.path", r.URL.Path,
+				"stack", string(debug.Stack()))
+			if ww.Status() == 0 {
+				apijson.Error(ww, r, http.StatusInternalServerError, "Internal server error", nil)
+			}
+		}()
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// compress: gzip/deflate für API-Antworten; das Frontend komprimiert static.Handler selbst
+// (bereits gesetztes Content-Encoding bleibt unangetastet).
+var compress = chimw.Compress(5, "application/json", "text/csv", "text/plain")
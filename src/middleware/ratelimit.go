@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"speedliner-server/src/config"
+	"speedliner-server/src/db"
+	"speedliner-server/src/utils/apijson"
+	"speedliner-server/src/utils/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RatePolicy: höchstens Limit Requests pro Window (Fixed Window). Limit 0 = aus.
+type RatePolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// Standard-Policies; Override über config.RateLimit (RATE_LIMIT_<NAME>=<n>/<dauer>, "off" = aus).
+// "default" gilt global für schreibende Requests (pro IP + Pfad), die übrigen pro Route-Gruppe.
+var (
+	ratePolicies = map[string]RatePolicy{
+		"default": {Name: "default", Limit: 300, Window: time.Minute},
+		"login":   {Name: "login", Limit: 20, Window: time.Minute},
+		"mail":    {Name: "mail", Limit: 5, Window: 10 * time.Minute},
+		"express": {Name: "express", Limit: 3, Window: 10 * time.Minute},
+		"quote":   {Name: "quote", Limit: 60, Window: time.Minute},
+	}
+	ratePoliciesMu sync.RWMutex
+	rateStore      RateStore = memStore
+)
+
+// RateStore zählt Treffer pro Schlüssel im aktuellen Fenster.
+type RateStore interface {
+	Hit(ctx context.Context, key string, window time.Duration) (count int, windowStart time.Time, err error)
+}
+
+// ---- In-Memory (Standard, pro Instanz) ----
+
+type memWindow struct {
+	start time.Time
+	count int
+	until time.Time
+}
+
+type memoryRateStore struct {
+	mu sync.Mutex
+	m  map[string]*memWindow
+}
+
+var memStore = &memoryRateStore{m: map[string]*memWindow{}}
+
+func (s *memoryRateStore) Hit(_ context.Context, key string, window time.Duration) (int, time.Time, error) {
+	now := time.Now()
+	start := now.Truncate(window)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.m[key]
+	if !ok || !w.start.Equal(start) {
+		w = &memWindow{start: start}
+		s.m[key] = w
+	}
+	w.count++
+	w.until = start.Add(window)
+	return w.count, start, nil
+}
+
+func (s *memoryRateStore) prune(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, w := range s.m {
+		if now.After(w.until) {
+			delete(s.m, k)
+		}
+	}
+}
+
+// ---- Postgres (gemeinsame Zähler über mehrere Replicas) ----
+
+type pgRateStore struct{}
+
+func (pgRateStore) Hit(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	return db.RateLimitHit(ctx, key, window)
+}
+
+// ConfigureRateLimits übernimmt Store (memory|postgres) und Policies aus der Konfiguration.
+// Für postgres muss db.Pool bereits initialisiert sein.
+func ConfigureRateLimits(c config.RateLimit) error {
+	ratePoliciesMu.Lock()
+	defer ratePoliciesMu.Unlock()
+	for name, spec := range c.Policies() {
+		limit, window, err := config.ParseRateSpec(spec)
+		if err != nil {
+			return fmt.Errorf("RATE_LIMIT_%s: %w", strings.ToUpper(name), err)
+		}
+		ratePolicies[name] = RatePolicy{Name: name, Limit: limit, Window: window}
+	}
+
+	switch c.Store {
+	case "", "memory":
+		rateStore = memStore
+	case "postgres":
+		if db.Pool == nil {
+			return fmt.Errorf("RATE_LIMIT_STORE=postgres requires an initialized DB")
+		}
+		rateStore = pgRateStore{}
+	default:
+		return fmt.Errorf("RATE_LIMIT_STORE: unknown store %q (memory|postgres)", c.Store)
+	}
+	return nil
+}
+
+func policy(name string) RatePolicy {
+	ratePoliciesMu.RLock()
+	defer ratePoliciesMu.RUnlock()
+	return ratePolicies[name]
+}
+
+// allow zählt den Treffer und setzt die RateLimit-*-Header (IETF draft) bzw. Retry-After.
+// Fehler des Stores lassen den Request durch (fail open).
+func allow(w http.ResponseWriter, r *http.Request, p RatePolicy, key string) bool {
+	if p.Limit <= 0 {
+		return true
+	}
+	count, start, err := rateStore.Hit(r.Context(), p.Name+"|"+key, p.Window)
+	if err != nil {
+		slog.Warn("rate limit store error", "policy", p.Name, "error", err)
+		return true
+	}
+	reset := int(time.Until(start.Add(p.Window)).Seconds() + 0.999)
+	if reset < 1 {
+		reset = 1
+	}
+	remaining := p.Limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	h := w.Header()
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", p.Limit, int(p.Window.Seconds())))
+	h.Set("RateLimit-Limit", strconv.Itoa(p.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(reset))
+	if count > p.Limit {
+		metrics.RateLimitRejections.WithLabelValues(p.Name).Inc()
+		h.Set("Retry-After", strconv.Itoa(reset))
+		apijson.Error(w, r, http.StatusTooManyRequests, "Too many requests", map[string]any{
+			"policy": p.Name, "retry_after": reset,
+		})
+		return false
+	}
+	return true
+}
+
+// RateLimitPolicy begrenzt eine Route-Gruppe; Schlüssel ist der eingeloggte Char,
+// anonym die Client-IP. Hinter AuthMiddleware einhängen.
+func RateLimitPolicy(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := "ip:" + ClientIP(r)
+			if id := IdentityFrom(r.Context()); id != nil {
+				key = "char:" + strconv.FormatInt(id.CharID, 10)
+			}
+			if !allow(w, r, policy(name), key) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimit: globales Grundlimit (Policy "default") für schreibende Requests pro IP + Pfad.
+func RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !allow(w, r, policy("default"), "ip:"+ClientIP(r)+"|"+r.URL.Path) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"speedliner-server/src/utils/reqctx"
+	"strings"
+	"sync/atomic"
+)
+
+// Vertrauenswürdige Proxies (TRUSTED_PROXIES, CIDRs oder einzelne IPs; leer = keinem vertrauen). Nur wenn der
+// direkte Peer darin liegt, werden CF-Connecting-IP / X-Forwarded-For / X-Real-IP ausgewertet.
+var trustedProxies atomic.Pointer[[]netip.Prefix]
+
+// SetTrustedProxies setzt die vertrauenswürdigen Proxies (config.HTTP.TrustedProxyPrefixes).
+func SetTrustedProxies(prefixes []netip.Prefix) {
+	trustedProxies.Store(&prefixes)
+}
+
+func isTrustedProxy(a netip.Addr) bool {
+	p := trustedProxies.Load()
+	if p == nil || !a.IsValid() {
+		return false
+	}
+	a = a.Unmap()
+	for _, pr := range *p {
+		if pr.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseIP(s string) netip.Addr {
+	s = strings.TrimSpace(s)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	a, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	if err != nil {
+		return netip.Addr{}
+	}
+	return a.Unmap()
+}
+
+// resolveClientIP: Peer-Adresse, außer der Peer ist ein vertrauenswürdiger Proxy. Dann
+// CF-Connecting-IP, sonst X-Forwarded-For von rechts nach links bis zur ersten nicht
+// vertrauenswürdigen Adresse, sonst X-Real-IP.
+func resolveClientIP(r *http.Request) string {
+	peer := parseIP(r.RemoteAddr)
+	if !peer.IsValid() {
+		return r.RemoteAddr
+	}
+	if !isTrustedProxy(peer) {
+		return peer.String()
+	}
+	if cf := parseIP(r.Header.Get("CF-Connecting-IP")); cf.IsValid() {
+		return cf.String()
+	}
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			a := parseIP(hops[i])
+			if !a.IsValid() {
+				break // Müll im Header: nicht weiter nach links vertrauen
+			}
+			client = a
+			if !isTrustedProxy(a) {
+				break
+			}
+		}
+		return client.String()
+	}
+	if rip := parseIP(r.Header.Get("X-Real-IP")); rip.IsValid() {
+		return rip.String()
+	}
+	return peer.String()
+}
+
+// RealIPMiddleware löst die Client-IP einmal auf und legt sie in den Context (reqctx.ClientIP).
+func RealIPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if reqctx.ClientIP(r.Context()) == "" {
+			r = r.WithContext(reqctx.WithClientIP(r.Context(), resolveClientIP(r)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientIP liefert die Client-IP aus dem Context (RealIPMiddleware), sonst frisch aufgelöst.
+func ClientIP(r *http.Request) string {
+	if ip := reqctx.ClientIP(r.Context()); ip != "" {
+		return ip
+	}
+	return resolveClientIP(r)
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"speedliner-server/src/config"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// securityPolicy: vorbereitete Header aus config.Security/config.CORS (ConfigureSecurity).
+type securityPolicy struct {
+	csp, frameOptions, referrerPolicy, hsts string
+
+	anyOrigin     bool
+	origins       map[string]bool
+	corsMaxAge    string
+	secureCookies bool
+}
+
+var security atomic.Pointer[securityPolicy]
+
+// CORS: erlaubte Methoden/Header für Preflights und für Skripte lesbare Antwort-Header.
+const (
+	corsAllowMethods  = "GET, POST, PUT, PATCH, DELETE"
+	corsAllowHeaders  = "Authorization, Content-Type, X-Request-ID"
+	corsExposeHeaders = "X-Request-ID, RateLimit-Policy, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After, Deprecation, Link"
+)
+
+// ConfigureSecurity übernimmt Header-Policy und CORS-Allow-List; production schaltet HSTS und
+// Secure-Cookies ein (APP_ENV=production, also hinter TLS).
+func ConfigureSecurity(c config.Security, cors config.CORS, production bool) {
+	p := &securityPolicy{
+		csp:            c.CSP,
+		frameOptions:   c.FrameOptions,
+		referrerPolicy: c.ReferrerPolicy,
+		origins:        map[string]bool{},
+		corsMaxAge:     strconv.Itoa(int(cors.MaxAge.Std().Seconds())),
+		secureCookies:  production,
+	}
+	if production && c.HSTSMaxAge > 0 {
+		p.hsts = "max-age=" + strconv.Itoa(int(c.HSTSMaxAge.Std().Seconds())) + "; includeSubDomains"
+	}
+	for _, o := range cors.AllowedOrigins {
+		if o == "*" {
+			p.anyOrigin = true
+		}
+		p.origins[strings.ToLower(o)] = true
+	}
+	security.Store(p)
+}
+
+// SecurityHeadersMiddleware setzt CSP, X-Frame-Options, Referrer-Policy, HSTS und nosniff.
+// Swagger-UI (nur außerhalb von production) arbeitet mit Inline-Skripten und bekommt keine CSP.
+func SecurityHeadersMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		if p := security.Load(); p != nil {
+			if p.csp != "" && !strings.HasPrefix(r.URL.Path, "/swagger/") {
+				h.Set("Content-Security-Policy", p.csp)
+			}
+			if p.frameOptions != "" {
+				h.Set("X-Frame-Options", p.frameOptions)
+			}
+			if p.referrerPolicy != "" {
+				h.Set("Referrer-Policy", p.referrerPolicy)
+			}
+			if p.hsts != "" {
+				h.Set("Strict-Transport-Security", p.hsts)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CORSMiddleware erlaubt Cross-Origin-Zugriffe auf /app/ für Origins aus CORS_ALLOWED_ORIGINS und
+// beantwortet deren Preflights. Andere Origins bekommen keine CORS-Header (der Browser blockt).
+func CORSMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := security.Load()
+		if p == nil || len(p.origins) == 0 || !strings.HasPrefix(r.URL.Path, "/app/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		if origin == "" || !(p.anyOrigin || p.origins[strings.ToLower(origin)]) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if p.anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+			h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			h.Set("Access-Control-Max-Age", p.corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetCookie setzt c; mit APP_ENV=production nur über HTTPS (Secure).
+func SetCookie(w http.ResponseWriter, c *http.Cookie) {
+	if p := security.Load(); p != nil && p.secureCookies {
+		c.Secure = true
+	}
+	http.SetCookie(w, c)
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"net/http"
+	"speedliner-server/src/utils/users"
+)
+
+// LastSeenMiddleware merkt sich die letzte Aktivität eingeloggter Chars (gedrosselt).
+func LastSeenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("char"); err == nil && c.Value != "" {
+			users.TouchLastSeen(r.Context(), c.Value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,332 @@
+// Package memrepo hält Routen, User, Corps und Tokens im Speicher und bildet dabei die
+// Regeln der Postgres-Implementierung nach (Sichtbarkeit, Rollen am Main, Sperre/Quarantäne).
+// Gedacht für Tests von Routing, Rechteprüfung und Sichtbarkeit ohne Datenbank.
+package memrepo
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"slices"
+	"speedliner-server/src/db"
+	"speedliner-server/src/repo"
+	"speedliner-server/src/utils/structs"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// User: Stammdaten eines Chars. MainCharID 0 = eigener Main; Roles zählen nur am Main.
+type User struct {
+	CharID      int64
+	Name        string
+	CorpID      int64
+	MainCharID  int64
+	Role        string
+	Roles       []string
+	Banned      bool
+	Quarantined bool
+	LastSeenAt  time.Time
+}
+
+// Store: gemeinsamer Zustand aller Fakes.
+type Store struct {
+	mu        sync.RWMutex
+	routes    map[string]structs.Route
+	users     map[int64]*User
+	corps     map[int64]structs.CorpOption
+	rolePerms map[string][]string
+	tokens    map[string]*oauth2.Token
+}
+
+// New legt einen leeren Store mit den eingebauten Rollen (structs.BuiltinRoles) an.
+func New() *Store {
+	s := &Store{
+		routes:    map[string]structs.Route{},
+		users:     map[int64]*User{},
+		corps:     map[int64]structs.CorpOption{},
+		rolePerms: map[string][]string{},
+		tokens:    map[string]*oauth2.Token{},
+	}
+	for name, perms := range structs.BuiltinRoles {
+		s.rolePerms[name] = slices.Clone(perms)
+	}
+	return s
+}
+
+// Repos liefert alle Fakes auf diesem Store.
+func (s *Store) Repos() repo.Repos {
+	return repo.Repos{Routes: Routes{s}, Users: Users{s}, Corps: Corps{s}, Tokens: Tokens{s}}
+}
+
+// PutUser legt einen Char an oder ersetzt ihn. Ohne Rollen bekommt ein Main die Standardrolle "user".
+func (s *Store) PutUser(u User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(u.Roles) == 0 && u.MainCharID == 0 {
+		u.Roles = []string{"user"}
+	}
+	if u.Role == "" {
+		u.Role = "user"
+	}
+	s.users[u.CharID] = &u
+}
+
+// PutCorp legt eine Corp an oder ersetzt sie.
+func (s *Store) PutCorp(c structs.CorpOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.corps[c.CorpID] = c
+}
+
+// SetRole definiert eine Rolle mit ihren Rechten.
+func (s *Store) SetRole(name string, perms ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rolePerms[name] = perms
+}
+
+// PutRoute legt eine Route ohne Validierung an (ID wird bei Bedarf vergeben) und liefert sie zurück.
+func (s *Store) PutRoute(r structs.Route) structs.Route {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.ID == "" {
+		r.ID = newID()
+	}
+	r.AllowedCorps = slices.Clone(r.AllowedCorps)
+	s.routes[r.ID] = r
+	return r
+}
+
+// Route liefert eine Route inkl. Whitelist-Corps, unabhängig von der Sichtbarkeit.
+func (s *Store) Route(id string) (structs.Route, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.routes[id]
+	r.AllowedCorps = slices.Clone(r.AllowedCorps)
+	return r, ok
+}
+
+// User liefert eine Kopie des Chars.
+func (s *Store) User(charID int64) (User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.users[charID]
+	if !ok {
+		return User{}, false
+	}
+	return *u, true
+}
+
+// main: Main-Char des Accounts (der Char selbst, falls kein anderer gesetzt ist).
+func (s *Store) main(u *User) *User {
+	if u.MainCharID == 0 || u.MainCharID == u.CharID {
+		return u
+	}
+	if m, ok := s.users[u.MainCharID]; ok {
+		return m
+	}
+	return u
+}
+
+// visible: Sichtbarkeit wie in db.GetAllRoutesForUser / db.GetRouteForUser.
+func (s *Store) visible(r structs.Route, charID *int64, seeAll bool) bool {
+	if seeAll || r.Visibility == "all" {
+		return true
+	}
+	if charID == nil || r.Visibility != "whitelist" {
+		return false
+	}
+	u, ok := s.users[*charID]
+	return ok && slices.Contains(r.AllowedCorps, u.CorpID)
+}
+
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// Routes implementiert repo.RouteRepository.
+type Routes struct{ s *Store }
+
+func (r Routes) ListForUser(_ context.Context, charID *int64, seeAll bool) ([]structs.Route, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var list []structs.Route
+	for _, it := range r.s.routes {
+		if r.s.visible(it, charID, seeAll) {
+			it.AllowedCorps = nil // wie die SQL-Abfrage: ohne Whitelist
+			list = append(list, it)
+		}
+	}
+	slices.SortFunc(list, func(a, b structs.Route) int {
+		return cmp.Or(strings.Compare(a.From, b.From), strings.Compare(a.To, b.To))
+	})
+	return list, nil
+}
+
+func (r Routes) GetForUser(_ context.Context, id string, charID *int64, seeAll bool) (*structs.Route, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	it, ok := r.s.routes[id]
+	if !ok || !r.s.visible(it, charID, seeAll) {
+		return nil, nil
+	}
+	it.AllowedCorps = nil
+	return &it, nil
+}
+
+func (r Routes) Insert(_ context.Context, rt structs.Route) error {
+	if err := db.ValidateRoute(&rt); err != nil {
+		return err
+	}
+	rt.ID = ""
+	r.s.PutRoute(rt)
+	return nil
+}
+
+func (r Routes) Update(_ context.Context, rt structs.Route) error {
+	if err := db.ValidateRoute(&rt); err != nil {
+		return err
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	// wie UPDATE ... WHERE id: unbekannte IDs sind kein Fehler
+	if _, ok := r.s.routes[rt.ID]; ok {
+		rt.AllowedCorps = slices.Clone(rt.AllowedCorps)
+		r.s.routes[rt.ID] = rt
+	}
+	return nil
+}
+
+func (r Routes) Delete(_ context.Context, id string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.routes, id)
+	return nil
+}
+
+// Users implementiert repo.UserRepository.
+type Users struct{ s *Store }
+
+func (r Users) Permissions(_ context.Context, charID int64) ([]string, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	perms := []string{}
+	u, ok := r.s.users[charID]
+	if !ok {
+		return perms, nil
+	}
+	m := r.s.main(u)
+	if u.Quarantined || m.Quarantined || u.Banned || m.Banned {
+		return perms, nil
+	}
+	for _, role := range m.Roles {
+		perms = append(perms, r.s.rolePerms[role]...)
+	}
+	slices.Sort(perms)
+	return slices.Compact(perms), nil
+}
+
+func (r Users) PrimaryRole(_ context.Context, charID int64) (string, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	u, ok := r.s.users[charID]
+	if !ok {
+		return "", fmt.Errorf("PrimaryRole error: user %d not found", charID)
+	}
+	return u.Role, nil
+}
+
+func (r Users) RoleNames(_ context.Context, charID int64) ([]string, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	roles := []string{}
+	if u, ok := r.s.users[charID]; ok {
+		roles = append(roles, r.s.main(u).Roles...)
+	}
+	slices.Sort(roles)
+	return roles, nil
+}
+
+func (r Users) IsBanned(_ context.Context, charID int64) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	u, ok := r.s.users[charID]
+	return ok && (u.Banned || r.s.main(u).Banned), nil
+}
+
+func (r Users) IsQuarantined(_ context.Context, charID int64) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	u, ok := r.s.users[charID]
+	return ok && u.Quarantined, nil
+}
+
+func (r Users) TouchLastSeen(_ context.Context, charID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if u, ok := r.s.users[charID]; ok {
+		u.LastSeenAt = time.Now()
+	}
+	return nil
+}
+
+// Corps implementiert repo.CorpRepository.
+type Corps struct{ s *Store }
+
+func (r Corps) Search(_ context.Context, q string, limit int) ([]structs.CorpOption, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	q = strings.ToLower(q)
+	r.s.mu.RLock()
+	var list []structs.CorpOption
+	for _, c := range r.s.corps {
+		if q == "" || strings.Contains(strings.ToLower(c.Name), q) || strings.Contains(strings.ToLower(c.Ticker), q) {
+			list = append(list, c)
+		}
+	}
+	r.s.mu.RUnlock()
+	// ORDER BY ticker NULLS LAST, name
+	slices.SortFunc(list, func(a, b structs.CorpOption) int {
+		if (a.Ticker == "") != (b.Ticker == "") {
+			if a.Ticker == "" {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Or(strings.Compare(a.Ticker, b.Ticker), strings.Compare(a.Name, b.Name))
+	})
+	return list[:min(limit, len(list))], nil
+}
+
+// Tokens implementiert repo.TokenStore.
+type Tokens struct{ s *Store }
+
+func (r Tokens) Get(_ context.Context, charID string) (*oauth2.Token, bool) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	t, ok := r.s.tokens[charID]
+	return t, ok
+}
+
+func (r Tokens) Put(_ context.Context, charID string, tok *oauth2.Token) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.tokens[charID] = tok
+	return nil
+}
+
+func (r Tokens) Delete(_ context.Context, charID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.tokens, charID)
+	return nil
+}
@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"context"
+	"speedliner-server/src/db"
+	"speedliner-server/src/utils/esiauth"
+	"speedliner-server/src/utils/structs"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres: Implementierungen über das db-Paket (db.InitDB muss gelaufen sein).
+func Postgres(pool *pgxpool.Pool) Repos {
+	return Repos{
+		Routes: PGRoutes{},
+		Users:  PGUsers{},
+		Corps:  PGCorps{},
+		Tokens: esiauth.NewPGXTokenStore(pool),
+	}
+}
+
+type PGRoutes struct{}
+
+func (PGRoutes) ListForUser(ctx context.Context, charID *int64, seeAll bool) ([]structs.Route, error) {
+	return db.GetAllRoutesForUser(ctx, charID, seeAll)
+}
+
+func (PGRoutes) GetForUser(ctx context.Context, id string, charID *int64, seeAll bool) (*structs.Route, error) {
+	return db.GetRouteForUser(ctx, id, charID, seeAll)
+}
+
+func (PGRoutes) Insert(ctx context.Context, r structs.Route) error {
+	return db.InsertRoute(ctx, r)
+}
+
+func (PGRoutes) Update(ctx context.Context, r structs.Route) error {
+	return db.UpdateRoute(ctx, r)
+}
+
+func (PGRoutes) Delete(ctx context.Context, id string) error {
+	return db.DeleteRoute(ctx, id)
+}
+
+type PGUsers struct{}
+
+func (PGUsers) Permissions(ctx context.Context, charID int64) ([]string, error) {
+	return db.GetUserPermissions(ctx, charID)
+}
+
+func (PGUsers) PrimaryRole(ctx context.Context, charID int64) (string, error) {
+	return db.GetUserRoles(ctx, charID)
+}
+
+func (PGUsers) RoleNames(ctx context.Context, charID int64) ([]string, error) {
+	return db.GetUserRoleNames(ctx, charID)
+}
+
+func (PGUsers) IsBanned(ctx context.Context, charID int64) (bool, error) {
+	return db.IsUserBanned(ctx, charID)
+}
+
+func (PGUsers) IsQuarantined(ctx context.Context, charID int64) (bool, error) {
+	return db.IsUserQuarantined(ctx, charID)
+}
+
+func (PGUsers) TouchLastSeen(ctx context.Context, charID int64) error {
+	return db.TouchLastSeen(ctx, charID)
+}
+
+type PGCorps struct{}
+
+func (PGCorps) Search(ctx context.Context, q string, limit int) ([]structs.CorpOption, error) {
+	return db.SearchCorps(ctx, q, limit)
+}
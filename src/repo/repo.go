@@ -0,0 +1,51 @@
+// Package repo beschreibt Routen, Rollen/Rechte, Corp-Suche und ESI-Tokens als Schnittstellen.
+// Postgres liefert die Implementierung über das db-Paket, repo/memrepo In-Memory-Fakes für Tests.
+// Preise, Provider, Admin und Accounts laufen (noch) direkt über das db-Paket und brauchen Postgres.
+package repo
+
+import (
+	"context"
+	"speedliner-server/src/utils/esiauth"
+	"speedliner-server/src/utils/structs"
+)
+
+// RouteRepository: Routen inkl. Sichtbarkeit. seeAll entspricht dem Recht routes.view_all;
+// ohne charID sind nur Routen mit Sichtbarkeit "all" zu sehen, sonst zusätzlich
+// Whitelist-Routen der eigenen Corp.
+type RouteRepository interface {
+	ListForUser(ctx context.Context, charID *int64, seeAll bool) ([]structs.Route, error)
+	// GetForUser liefert nil, wenn die Route fehlt oder nicht sichtbar ist.
+	GetForUser(ctx context.Context, id string, charID *int64, seeAll bool) (*structs.Route, error)
+	// Insert/Update liefern db.ErrInvalidRoute bei ungültigen Routen (siehe db.ValidateRoute).
+	Insert(ctx context.Context, r structs.Route) error
+	Update(ctx context.Context, r structs.Route) error
+	Delete(ctx context.Context, id string) error
+}
+
+// UserRepository: Rollen, Rechte und Status eines Chars. Rollen und Sperren gelten pro Account,
+// d.h. Alts erben die ihres Mains.
+type UserRepository interface {
+	// Permissions: effektive Rechte; leer bei Quarantäne oder Sperre.
+	Permissions(ctx context.Context, charID int64) ([]string, error)
+	PrimaryRole(ctx context.Context, charID int64) (string, error)
+	RoleNames(ctx context.Context, charID int64) ([]string, error)
+	IsBanned(ctx context.Context, charID int64) (bool, error)
+	IsQuarantined(ctx context.Context, charID int64) (bool, error)
+	TouchLastSeen(ctx context.Context, charID int64) error
+}
+
+// CorpRepository: bekannte Corps (aus Logins/Affiliations).
+type CorpRepository interface {
+	Search(ctx context.Context, q string, limit int) ([]structs.CorpOption, error)
+}
+
+// TokenStore: ESI-OAuth-Tokens pro Char.
+type TokenStore = esiauth.TokenStore
+
+// Repos bündelt die Implementierungen, die beim Start injiziert werden.
+type Repos struct {
+	Routes RouteRepository
+	Users  UserRepository
+	Corps  CorpRepository
+	Tokens TokenStore
+}
@@ -2,39 +2,50 @@ package router
 
 import (
 	"net/http"
-	"path/filepath"
+	"speedliner-server/src/config"
 	"speedliner-server/src/handler"
 	"speedliner-server/src/middleware"
+	"speedliner-server/src/utils/apijson"
 
 	"github.com/go-chi/chi/v5"
 )
 
-// NewRouter erstellt einen neuen Router mit allen Routen und Middleware
-func NewRouter() *chi.Mux {
+// NewRouter erstellt einen neuen Router mit allen Routen und Middleware; h trägt die Datenhaltung,
+// frontend liefert alles außerhalb von /app/ aus (static.Handler), mw schaltet die Stufen der
+// globalen Middleware-Kette. Der Router ist der komplette Server-Handler – nichts drumherum wickeln.
+func NewRouter(h *handler.Handler, frontend http.Handler, mw config.Middleware) *chi.Mux {
 	r := chi.NewRouter()
 
-	// Middleware einhängen
-	r.Use(middleware.LoggerMiddleware)
-	r.Use(middleware.NoCacheMiddleware)
+	// Globale Middleware-Kette (Reihenfolge siehe middleware.Pipeline)
+	r.Use(middleware.Pipeline(mw)...)
 
-	// API-Routen
+	// API-Routen: /app/v1 (snake_case, aktuell) und /app/ als veralteter Alias
+	r.Route("/app/v1", func(sub chi.Router) {
+		sub.Use(middleware.APIV1Middleware)
+		apiRoutes(sub, h)
+	})
 	r.Route("/app/", func(sub chi.Router) {
-		handler.DefineApiRoutes(sub)
+		sub.Use(middleware.DeprecatedAPIMiddleware)
+		apiRoutes(sub, h)
 	})
 
-	// Dynamisch zusammengesetzter relativer Pfad
-	buildDir := filepath.Join("frontend")
-	fs := http.FileServer(http.Dir(buildDir))
-	r.Handle("/*", fs)
+	// Frontend inkl. SPA-Fallback; Caching über ETag statt no-store
+	r.Handle("/*", frontend)
 
-	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, filepath.Join(buildDir, "index.html"))
-	})
+	return r
+}
 
-	// Root-Route (z.B. für deine index.html)
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "frontend")
+func apiRoutes(sub chi.Router, h *handler.Handler) {
+	// API-Antworten nie cachen (Assets cacht static.Handler)
+	sub.Use(middleware.NoCacheMiddleware)
+	sub.Use(middleware.BanMiddleware)
+	sub.Use(middleware.LastSeenMiddleware)
+	sub.Use(middleware.AuthMiddleware)
+	sub.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		apijson.Error(w, r, http.StatusNotFound, "Endpoint not found", nil)
 	})
-
-	return r
+	sub.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		apijson.Error(w, r, http.StatusMethodNotAllowed, "Method not allowed", nil)
+	})
+	handler.DefineApiRoutes(sub, h)
 }
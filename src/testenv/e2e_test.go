@@ -0,0 +1,250 @@
+// Ende-zu-Ende gegen Wegwerf-Postgres und Fake-ESI: Login über den CallbackHandler, Rollen,
+// Routen-CRUD und Whitelist-Sichtbarkeit. Ohne Postgres-Binaries (initdb/pg_ctl, siehe pgtest)
+// werden die Tests übersprungen, mit REQUIRE_POSTGRES=1 schlagen sie fehl.
+//
+//	REQUIRE_POSTGRES=1 PG_BIN=/usr/lib/postgresql/16/bin go test ./src/testenv [-args -fixtures ../../backup.sql]
+package testenv_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"speedliner-server/src/testenv"
+	"speedliner-server/src/testenv/fakeesi"
+	"speedliner-server/src/testenv/pgtest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Chars aus backup.sql (Provider) bzw. neu angelegte Piloten
+const (
+	providerID = 92393462
+	memberID   = 2123452374
+	outsiderID = 923693091
+
+	homeCorp      = 98000001
+	whitelistCorp = 98000002
+	outsiderCorp  = 98000003
+)
+
+var fixtures = flag.String("fixtures", "../../backup.sql", "SQL-Fixtures, kommagetrennt (leer = keine)")
+
+// route: Routen-Antwort unter /app/v1 (snake_case)
+type route struct {
+	ID         string  `json:"id"`
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	PricePerM3 float64 `json:"price_per_m3"`
+	Visibility string  `json:"visibility"`
+}
+
+type roleResponse struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// Handler und db arbeiten mit Paket-Globals: ein Env für alle Tests
+var (
+	env    *testenv.Env
+	envErr error
+
+	loginOnce                  sync.Once
+	loginErr                   error
+	provider, member, outsider *testenv.Client
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	var files []string
+	if *fixtures != "" {
+		files = strings.Split(*fixtures, ",")
+	}
+	env, envErr = testenv.Start(ctx, testenv.Options{Fixtures: files})
+	if envErr == nil {
+		defer env.Close()
+		seedESI(env.ESI)
+	} else if !errors.Is(envErr, pgtest.ErrNoPostgres) {
+		fmt.Fprintf(os.Stderr, "setup: %v\n", envErr)
+		return 1
+	}
+	return m.Run()
+}
+
+func seedESI(f *fakeesi.Server) {
+	f.AddAlliance(fakeesi.Alliance{ID: 99000001, Name: "Test Alliance Please Ignore", Ticker: "TEST"})
+	f.AddCorp(fakeesi.Corp{ID: homeCorp, Name: "Speedliner Logistics", Ticker: "SPDL"})
+	f.AddCorp(fakeesi.Corp{ID: whitelistCorp, Name: "Whitelist Corp", Ticker: "WLC", AllianceID: 99000001})
+	f.AddCorp(fakeesi.Corp{ID: outsiderCorp, Name: "Outsider Inc", Ticker: "OUT"})
+	f.AddCharacter(fakeesi.Character{ID: providerID, Name: "Philippe Rochard", CorpID: homeCorp})
+	f.AddCharacter(fakeesi.Character{ID: memberID, Name: "Shirok Daasek", CorpID: whitelistCorp, AllianceID: 99000001})
+	f.AddCharacter(fakeesi.Character{ID: outsiderID, Name: "Korexx", CorpID: outsiderCorp})
+}
+
+// requireEnv überspringt den Test ohne Postgres.
+func requireEnv(t *testing.T) context.Context {
+	t.Helper()
+	if envErr != nil {
+		t.Skip(envErr)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+// login meldet Provider, Member und Outsider einmalig über den CallbackHandler an.
+func login(t *testing.T, ctx context.Context) {
+	t.Helper()
+	loginOnce.Do(func() {
+		if provider, loginErr = env.Login(ctx, providerID); loginErr != nil {
+			return
+		}
+		if member, loginErr = env.Login(ctx, memberID); loginErr != nil {
+			return
+		}
+		outsider, loginErr = env.Login(ctx, outsiderID)
+	})
+	if loginErr != nil {
+		t.Fatalf("login: %v", loginErr)
+	}
+}
+
+func routes(t *testing.T, ctx context.Context, c *testenv.Client) []route {
+	t.Helper()
+	var list []route
+	if err := c.JSON(ctx, http.MethodGet, "/app/v1/routes", nil, http.StatusOK, &list); err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func TestAnonymousRoutes(t *testing.T) {
+	ctx := requireEnv(t)
+	list := routes(t, ctx, env.Client())
+	if len(list) != 3 {
+		t.Fatalf("got %d routes, want 3 from fixtures", len(list))
+	}
+	if !slices.ContainsFunc(list, func(r route) bool { return r.From == "Jita" && r.To == "K-6K16" }) {
+		t.Errorf("fixture route Jita → K-6K16 missing: %+v", list)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	ctx := requireEnv(t)
+	login(t, ctx)
+	var me struct {
+		CharacterID int64 `json:"character_id"`
+	}
+	if err := member.JSON(ctx, http.MethodGet, "/app/v1/me", nil, http.StatusOK, &me); err != nil {
+		t.Fatal(err)
+	}
+	if me.CharacterID != memberID {
+		t.Errorf("/me: character %d, want %d", me.CharacterID, memberID)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	ctx := requireEnv(t)
+	login(t, ctx)
+	var p, m roleResponse
+	if err := provider.JSON(ctx, http.MethodGet, "/app/v1/role", nil, http.StatusOK, &p); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(p.Roles, "provider") || !slices.Contains(p.Permissions, "routes.edit") {
+		t.Errorf("provider: roles %v, permissions %v", p.Roles, p.Permissions)
+	}
+	if err := member.JSON(ctx, http.MethodGet, "/app/v1/role", nil, http.StatusOK, &m); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(m.Roles, []string{"user"}) || len(m.Permissions) != 0 {
+		t.Errorf("member: roles %v, permissions %v", m.Roles, m.Permissions)
+	}
+	if err := env.Client().JSON(ctx, http.MethodGet, "/app/v1/role", nil, http.StatusUnauthorized, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRouteEditForbidden(t *testing.T) {
+	ctx := requireEnv(t)
+	login(t, ctx)
+	body := map[string]any{"from": "Jita", "to": "Amarr", "price_per_m3": 100}
+	if err := outsider.JSON(ctx, http.MethodPost, "/app/v1/routes", body, http.StatusForbidden, nil); err != nil {
+		t.Error(err)
+	}
+	if err := env.Client().JSON(ctx, http.MethodPost, "/app/v1/routes", body, http.StatusUnauthorized, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWhitelistRouteCRUD(t *testing.T) {
+	ctx := requireEnv(t)
+	login(t, ctx)
+	body := map[string]any{
+		"from": "Amarr", "to": "Dodixie", "price_per_m3": 800,
+		"visibility": "whitelist", "allowed_corps": []int64{whitelistCorp},
+	}
+	if err := provider.JSON(ctx, http.MethodPost, "/app/v1/routes", body, http.StatusCreated, nil); err != nil {
+		t.Fatal(err)
+	}
+	all := routes(t, ctx, provider)
+	i := slices.IndexFunc(all, func(r route) bool { return r.From == "Amarr" && r.To == "Dodixie" })
+	if i < 0 {
+		t.Fatal("provider (routes.view_all) does not see new route")
+	}
+	id := all[i].ID
+
+	t.Run("after create", func(t *testing.T) {
+		expectVisible(t, ctx, id, map[string]bool{"member": true, "outsider": false, "anonymous": false})
+	})
+
+	body["price_per_m3"] = 900
+	body["allowed_corps"] = []int64{whitelistCorp, outsiderCorp}
+	if err := provider.JSON(ctx, http.MethodPut, "/app/v1/routes/"+id, body, http.StatusOK, nil); err != nil {
+		t.Fatal(err)
+	}
+	t.Run("after update", func(t *testing.T) {
+		expectVisible(t, ctx, id, map[string]bool{"member": true, "outsider": true, "anonymous": false})
+		list := routes(t, ctx, outsider)
+		if i := slices.IndexFunc(list, func(r route) bool { return r.ID == id }); i < 0 || list[i].PricePerM3 != 900 {
+			t.Errorf("price after update not visible to outsider: %+v", list)
+		}
+	})
+
+	invalid := map[string]any{"from": "Amarr", "to": "amarr", "price_per_m3": 1}
+	if err := provider.JSON(ctx, http.MethodPut, "/app/v1/routes/"+id, invalid, http.StatusBadRequest, nil); err != nil {
+		t.Error(err)
+	}
+
+	if err := provider.JSON(ctx, http.MethodDelete, "/app/v1/routes/"+id, nil, http.StatusNoContent, nil); err != nil {
+		t.Fatal(err)
+	}
+	t.Run("after delete", func(t *testing.T) {
+		expectVisible(t, ctx, id, map[string]bool{"provider": false, "member": false})
+	})
+}
+
+// expectVisible prüft je Client, ob Route id in GET /routes auftaucht.
+func expectVisible(t *testing.T, ctx context.Context, id string, want map[string]bool) {
+	t.Helper()
+	clients := map[string]*testenv.Client{
+		"provider": provider, "member": member, "outsider": outsider, "anonymous": env.Client(),
+	}
+	for name, visible := range want {
+		list := routes(t, ctx, clients[name])
+		if got := slices.ContainsFunc(list, func(r route) bool { return r.ID == id }); got != visible {
+			t.Errorf("%s sees route: %v, want %v", name, got, visible)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// Package fakeesi bildet EVE-SSO (Authorize/Token) und die genutzten ESI-Endpunkte
+// (verify, affiliation, corporations, alliances, mail) als lokalen HTTP-Server nach.
+// Die URLs kommen über OAuth() und ESI() in die Konfiguration (OAUTH_*_URL, ESI_BASE_URL).
+package fakeesi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"speedliner-server/src/config"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Character struct {
+	ID         int64
+	Name       string
+	CorpID     int64
+	AllianceID int64 // 0 = keine
+}
+
+type Corp struct {
+	ID         int64
+	Name       string
+	Ticker     string
+	AllianceID int64
+}
+
+type Alliance struct {
+	ID     int64
+	Name   string
+	Ticker string
+}
+
+// Mail: über /characters/{id}/mail/ gesendete Mail (Body wie an ESI geschickt).
+type Mail struct {
+	SenderID int64
+	Body     map[string]any
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	chars     map[int64]Character
+	corps     map[int64]Corp
+	alliances map[int64]Alliance
+	next      int64            // Char, der sich beim nächsten Authorize anmeldet
+	codes     map[string]int64 // Authorization Code → Char
+	tokens    map[string]int64 // Access/Refresh Token → Char
+	mails     []Mail
+}
+
+// New startet den Server; Close beendet ihn.
+func New() *Server {
+	s := &Server{
+		chars:     map[int64]Character{},
+		corps:     map[int64]Corp{},
+		alliances: map[int64]Alliance{},
+		codes:     map[string]int64{},
+		tokens:    map[string]int64{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/oauth/authorize", s.authorize)
+	mux.HandleFunc("POST /v2/oauth/token", s.token)
+	mux.HandleFunc("GET /verify", s.verify)
+	mux.HandleFunc("POST /v1/characters/affiliation/", s.affiliation)
+	mux.HandleFunc("GET /latest/corporations/{id}/", s.corporation)
+	mux.HandleFunc("GET /latest/alliances/{id}/", s.alliance)
+	mux.HandleFunc("POST /latest/characters/{id}/mail/", s.mail)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// OAuth: SSO-Konfiguration gegen diesen Server (Client-Daten werden nicht geprüft).
+func (s *Server) OAuth(redirectURL string) config.OAuth {
+	return config.OAuth{
+		ClientID:     "fake-client",
+		ClientSecret: "fake-secret",
+		RedirectURL:  redirectURL,
+		AuthURL:      s.URL + "/v2/oauth/authorize",
+		TokenURL:     s.URL + "/v2/oauth/token",
+	}
+}
+
+func (s *Server) ESI() config.ESI {
+	return config.ESI{BaseURL: s.URL}
+}
+
+func (s *Server) AddCharacter(c Character) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chars[c.ID] = c
+}
+
+func (s *Server) AddCorp(c Corp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.corps[c.ID] = c
+}
+
+func (s *Server) AddAlliance(a Alliance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alliances[a.ID] = a
+}
+
+// LoginAs legt fest, welcher Char sich beim nächsten Authorize anmeldet.
+func (s *Server) LoginAs(charID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next = charID
+}
+
+// Mails liefert alle bisher gesendeten Mails.
+func (s *Server) Mails() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mail(nil), s.mails...)
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	target, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || target.Scheme == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	charID := s.next
+	_, ok := s.chars[charID]
+	code := randomToken()
+	if ok {
+		s.codes[code] = charID
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "no character selected (LoginAs)", http.StatusBadRequest)
+		return
+	}
+	v := target.Query()
+	v.Set("code", code)
+	v.Set("state", q.Get("state"))
+	target.RawQuery = v.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	var charID int64
+	var ok bool
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := r.PostForm.Get("code")
+		charID, ok = s.codes[code]
+		delete(s.codes, code)
+	case "refresh_token":
+		charID, ok = s.tokens[r.PostForm.Get("refresh_token")]
+	}
+	access, refresh := randomToken(), randomToken()
+	if ok {
+		s.tokens[access] = charID
+		s.tokens[refresh] = charID
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  access,
+		"token_type":    "Bearer",
+		"expires_in":    1199,
+		"refresh_token": refresh,
+	})
+}
+
+// bearer: Char zum Access-Token aus dem Authorization-Header.
+func (s *Server) bearer(r *http.Request) (Character, bool) {
+	tok, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return Character{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.chars[s.tokens[tok]]
+	return c, ok
+}
+
+func (s *Server) verify(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.bearer(r)
+	if !ok {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"CharacterID": c.ID, "CharacterName": c.Name})
+}
+
+func (s *Server) affiliation(w http.ResponseWriter, r *http.Request) {
+	var ids []int64
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	out := []map[string]any{}
+	for _, id := range ids {
+		c, ok := s.chars[id]
+		if !ok {
+			continue
+		}
+		it := map[string]any{"character_id": c.ID, "corporation_id": c.CorpID}
+		if c.AllianceID != 0 {
+			it["alliance_id"] = c.AllianceID
+		}
+		out = append(out, it)
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) corporation(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	s.mu.Lock()
+	c, ok := s.corps[id]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, `{"error":"Corporation not found"}`, http.StatusNotFound)
+		return
+	}
+	it := map[string]any{"corporation_id": c.ID, "name": c.Name, "ticker": c.Ticker}
+	if c.AllianceID != 0 {
+		it["alliance_id"] = c.AllianceID
+	}
+	writeJSON(w, http.StatusOK, it)
+}
+
+func (s *Server) alliance(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	s.mu.Lock()
+	a, ok := s.alliances[id]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, `{"error":"Alliance not found"}`, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"alliance_id": a.ID, "name": a.Name, "ticker": a.Ticker})
+}
+
+func (s *Server) mail(w http.ResponseWriter, r *http.Request) {
+	c, ok := s.bearer(r)
+	if !ok || strconv.FormatInt(c.ID, 10) != r.PathValue("id") {
+		http.Error(w, `{"error":"token not valid for character"}`, http.StatusForbidden)
+		return
+	}
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.mails = append(s.mails, Mail{SenderID: c.ID, Body: body})
+	id := len(s.mails)
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte(strconv.Itoa(id)))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,176 @@
+// Package pgtest startet eine Wegwerf-Postgres-Instanz (initdb + pg_ctl) in einem temporären
+// Verzeichnis. Kein Netzwerk: der Server lauscht nur auf 127.0.0.1 und einem Unix-Socket im
+// Datenverzeichnis. Die Binaries kommen aus PG_BIN, PATH oder /usr/lib/postgresql/<version>/bin.
+// initdb verweigert den Start als root – Tests also als normaler User laufen lassen.
+//
+// Ohne Binaries liefert Start ErrNoPostgres und die Tests werden übersprungen. Mit
+// REQUIRE_POSTGRES=1 (CI) ist das ein Fehler, damit die DB-Tests nicht unbemerkt ausfallen:
+//
+//	REQUIRE_POSTGRES=1 PG_BIN=/usr/lib/postgresql/16/bin go test ./...
+package pgtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Database: Name der Datenbank, die Start anlegt.
+const Database = "speedliner"
+
+var ErrNoPostgres = errors.New("postgres binaries not found (set PG_BIN)")
+
+type Server struct {
+	Dir  string // Datenverzeichnis (wird von Stop gelöscht)
+	Port int
+	URL  string // DSN auf Database
+	bin  string
+}
+
+// Start legt ein Cluster an, startet es und erzeugt Database.
+func Start(ctx context.Context) (*Server, error) {
+	bin, err := findBin()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "speedliner-pg-")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{Dir: dir, bin: bin}
+	if err := s.start(ctx); err != nil {
+		_ = s.Stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Server) start(ctx context.Context) error {
+	data := filepath.Join(s.Dir, "data")
+	if err := s.run(ctx, "initdb", "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync"); err != nil {
+		return err
+	}
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+	s.Port = port
+	// -F: kein fsync, die Daten sind ohnehin weg
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -F", port, s.Dir)
+	if err := s.run(ctx, "pg_ctl", "-D", data, "-l", filepath.Join(s.Dir, "postgres.log"), "-o", opts, "-w", "start"); err != nil {
+		return err
+	}
+
+	admin := fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port)
+	conn, err := pgx.Connect(ctx, admin)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+	if _, err := conn.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{Database}.Sanitize()); err != nil {
+		return err
+	}
+	s.URL = fmt.Sprintf("postgres://postgres@127.0.0.1:%d/%s?sslmode=disable", port, Database)
+	return nil
+}
+
+// Stop beendet den Server sofort und löscht das Datenverzeichnis.
+func (s *Server) Stop() error {
+	var errs []error
+	data := filepath.Join(s.Dir, "data")
+	if _, err := os.Stat(filepath.Join(data, "postmaster.pid")); err == nil {
+		errs = append(errs, s.run(context.Background(), "pg_ctl", "-D", data, "-m", "immediate", "-w", "stop"))
+	}
+	errs = append(errs, os.RemoveAll(s.Dir))
+	return errors.Join(errs...)
+}
+
+// Log liefert das Server-Log (für Fehlermeldungen).
+func (s *Server) Log() string {
+	b, _ := os.ReadFile(filepath.Join(s.Dir, "postgres.log"))
+	return string(b)
+}
+
+// Seed führt SQL-Dateien (z.B. backup.sql) nacheinander aus; eine Datei darf mehrere Statements enthalten.
+func Seed(ctx context.Context, pool *pgxpool.Pool, files ...string) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	for _, f := range files {
+		sql, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		// Simple Protocol: mehrere Statements pro Aufruf
+		if _, err := conn.Conn().PgConn().Exec(ctx, string(sql)).ReadAll(); err != nil {
+			return fmt.Errorf("seed %s: %w", f, err)
+		}
+	}
+	return nil
+}
+
+func (s *Server) run(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, filepath.Join(s.bin, name), args...)
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w\n%s", name, err, out.String())
+	}
+	return nil
+}
+
+// findBin: Verzeichnis mit initdb und pg_ctl; bei mehreren installierten Versionen die neueste.
+func findBin() (string, error) {
+	var dirs []string
+	if d := os.Getenv("PG_BIN"); d != "" {
+		dirs = append(dirs, d)
+	}
+	if p, err := exec.LookPath("pg_ctl"); err == nil {
+		dirs = append(dirs, filepath.Dir(p))
+	}
+	versions, _ := filepath.Glob("/usr/lib/postgresql/*/bin")
+	sort.Slice(versions, func(i, j int) bool { return pgVersion(versions[i]) > pgVersion(versions[j]) })
+	dirs = append(dirs, versions...)
+	dirs = append(dirs, "/usr/local/pgsql/bin", "/opt/homebrew/bin", "/usr/local/bin")
+	for _, d := range dirs {
+		if isExec(filepath.Join(d, "initdb")) && isExec(filepath.Join(d, "pg_ctl")) {
+			return d, nil
+		}
+	}
+	if os.Getenv("REQUIRE_POSTGRES") != "" {
+		// bewusst ohne %w: errors.Is(err, ErrNoPostgres) darf nicht zum Überspringen führen
+		return "", fmt.Errorf("%v, but REQUIRE_POSTGRES is set", ErrNoPostgres)
+	}
+	return "", ErrNoPostgres
+}
+
+func pgVersion(binDir string) int {
+	v, _ := strconv.Atoi(filepath.Base(filepath.Dir(binDir)))
+	return v
+}
+
+func isExec(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir() && fi.Mode()&0o111 != 0
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,217 @@
+// Package testenv startet die komplette API für End-to-End-Tests: Wegwerf-Postgres (pgtest) mit
+// Schema und Fixtures, Fake-SSO/ESI (fakeesi) und den echten Router samt Middleware auf einem
+// lokalen HTTP-Server. Handler und db arbeiten mit Paket-Globals – pro Prozess nur ein Env.
+package testenv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"speedliner-server/frontend"
+	"speedliner-server/src/config"
+	"speedliner-server/src/db"
+	"speedliner-server/src/handler"
+	"speedliner-server/src/middleware"
+	"speedliner-server/src/repo"
+	"speedliner-server/src/router"
+	"speedliner-server/src/testenv/fakeesi"
+	"speedliner-server/src/testenv/pgtest"
+	"speedliner-server/src/utils/access"
+	"speedliner-server/src/utils/esi"
+	"speedliner-server/src/utils/esiauth"
+	"speedliner-server/src/utils/static"
+	"speedliner-server/src/utils/users"
+	"strings"
+)
+
+type Options struct {
+	// Fixtures: SQL-Dateien, die nach dem Schema eingespielt werden (z.B. backup.sql)
+	Fixtures []string
+	// Configure passt die Konfiguration vor dem Start an (Access, Rate-Limits, ...)
+	Configure func(*config.Config)
+}
+
+type Env struct {
+	PG     *pgtest.Server
+	ESI    *fakeesi.Server
+	API    *httptest.Server
+	Config config.Config
+}
+
+// Start baut die Umgebung auf; bei Fehlern ist bereits alles wieder abgeräumt.
+func Start(ctx context.Context, opts Options) (env *Env, err error) {
+	env = &Env{}
+	defer func() {
+		if err != nil {
+			env.Close()
+			env = nil
+		}
+	}()
+
+	if env.PG, err = pgtest.Start(ctx); err != nil {
+		return env, err
+	}
+	env.ESI = fakeesi.New()
+	env.API = httptest.NewUnstartedServer(nil)
+	apiURL := "http://" + env.API.Listener.Addr().String()
+
+	cfg := config.Default()
+	cfg.Database.URL = env.PG.URL
+	cfg.OAuth = env.ESI.OAuth(apiURL + "/app/v1/callback")
+	cfg.ESI = env.ESI.ESI()
+	cfg.Express.Enabled = false
+	if opts.Configure != nil {
+		opts.Configure(&cfg)
+	}
+	if err = cfg.Validate(); err != nil {
+		return env, err
+	}
+	env.Config = cfg
+
+	// Schema → Fixtures → Schema: der zweite Lauf zieht Accounts/Rollen aus Alt-Daten nach,
+	// wie bei einem Update einer bestehenden Installation
+	if err = db.InitDB(cfg.Database); err != nil {
+		return env, fmt.Errorf("%w\n%s", err, env.PG.Log())
+	}
+	if len(opts.Fixtures) > 0 {
+		if err = pgtest.Seed(ctx, db.Pool, opts.Fixtures...); err != nil {
+			return env, err
+		}
+		db.Close()
+		if err = db.InitDB(cfg.Database); err != nil {
+			return env, err
+		}
+	}
+
+	if err = access.Configure(cfg.Access); err != nil {
+		return env, err
+	}
+	if err = middleware.ConfigureRateLimits(cfg.RateLimit); err != nil {
+		return env, err
+	}
+	middleware.ConfigureSecurity(cfg.Security, cfg.CORS, cfg.App.Production())
+	esi.Configure(cfg.ESI)
+	esiauth.Configure(cfg.OAuth)
+	handler.SetConfig(&env.Config)
+	repos := repo.Postgres(db.Pool)
+	users.InitRepository(repos.Users)
+	esiauth.InitStore(repos.Tokens)
+
+	r := router.NewRouter(handler.New(repos), static.Handler(frontend.FS(), false), cfg.Middleware)
+	env.API.Config.Handler = r
+	env.API.Start()
+	return env, nil
+}
+
+func (e *Env) Close() {
+	if e.API != nil && e.API.URL != "" {
+		e.API.Close()
+	}
+	if e.ESI != nil {
+		e.ESI.Close()
+	}
+	if db.Pool != nil {
+		db.Close()
+	}
+	if e.PG != nil {
+		_ = e.PG.Stop()
+	}
+}
+
+// Client: HTTP-Client mit eigenem Cookie-Jar gegen die API.
+type Client struct {
+	HTTP *http.Client
+	base string
+}
+
+// Client liefert einen anonymen Client.
+func (e *Env) Client() *Client {
+	jar, _ := cookiejar.New(nil)
+	apiHost := strings.TrimPrefix(e.API.URL, "http://")
+	return &Client{
+		base: e.API.URL,
+		HTTP: &http.Client{
+			Jar: jar,
+			// SSO-Redirects folgen, aber nicht ins Frontend
+			CheckRedirect: func(req *http.Request, _ []*http.Request) error {
+				if req.URL.Host == apiHost && !strings.HasPrefix(req.URL.Path, "/app/") {
+					return http.ErrUseLastResponse
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Login meldet charID über /app/v1/login → Fake-SSO → CallbackHandler an.
+func (e *Env) Login(ctx context.Context, charID int64) (*Client, error) {
+	c := e.Client()
+	e.ESI.LoginAs(charID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+"/app/v1/login", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("login %d: status %d: %s", charID, resp.StatusCode, body)
+	}
+	if loc := resp.Header.Get("Location"); loc != "/" {
+		return nil, fmt.Errorf("login %d: redirected to %s", charID, loc)
+	}
+	return c, nil
+}
+
+// Do sendet body als JSON (nil = ohne Body) und liefert Status und Antwort.
+func (c *Client) Do(ctx context.Context, method, path string, body any) (int, []byte, error) {
+	var in io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		in = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, in)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, out, err
+}
+
+// JSON wie Do, erwartet want und dekodiert die Antwort nach out (falls nicht nil).
+func (c *Client) JSON(ctx context.Context, method, path string, body any, want int, out any) error {
+	status, raw, err := c.Do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if status != want {
+		return fmt.Errorf("%s %s: status %d, want %d: %s", method, path, status, want, raw)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return errors.Join(fmt.Errorf("%s %s: decode", method, path), err)
+	}
+	return nil
+}
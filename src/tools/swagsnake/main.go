@@ -0,0 +1,213 @@
+// swagsnake benennt die Modell-Felder im von swag erzeugten Spec nach snake_case um – so, wie
+// apijson sie unter /app/v1 ausliefert (die Structs tragen die camelCase-Tags der alten /app/-Pfade).
+// Nach jedem "swag init" im Repo-Root laufen lassen:
+//
+//	go run github.com/swaggo/swag/cmd/swag init && go run ./src/tools/swagsnake
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"speedliner-server/src/utils/apijson"
+
+	"sigs.k8s.io/yaml"
+)
+
+func main() {
+	dir := "docs"
+	if len(os.Args) > 1 {
+		dir = os.Args[1]
+	}
+	if err := run(dir); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dir string) error {
+	// docs.go enthält das JSON als Template; die Definitionen sind dort reines JSON
+	for _, name := range []string{"swagger.json", "docs.go"} {
+		if err := rewriteFile(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "swagger.json"))
+	if err != nil {
+		return err
+	}
+	y, err := yaml.JSONToYAML(b)
+	if err != nil {
+		return fmt.Errorf("swagger.yaml: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, "swagger.yaml"), y, 0o644)
+}
+
+// rewriteFile ersetzt den Wert von "definitions" (Einrückung wie swag: 4 Leerzeichen, Tiefe 1).
+func rewriteFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	const key = "\n    \"definitions\": "
+	i := bytes.Index(b, []byte(key))
+	if i < 0 {
+		return fmt.Errorf("%s: no definitions", path)
+	}
+	start := i + len(key)
+	dec := json.NewDecoder(bytes.NewReader(b[start:]))
+	dec.UseNumber()
+	defs, err := parse(dec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	end := start + int(dec.InputOffset())
+
+	obj, ok := defs.(object)
+	if !ok {
+		return fmt.Errorf("%s: definitions is not an object", path)
+	}
+	for _, d := range obj {
+		snakeSchema(d.val)
+	}
+	var out bytes.Buffer
+	out.Write(b[:start])
+	if err := write(&out, obj, "    "); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	out.Write(b[end:])
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
+
+// snakeSchema: Property-Namen und required-Einträge umbenennen, verschachtelte Schemas mit.
+// Schlüssel von additionalProperties (typisierte Maps) sind Daten und bleiben wie bei apijson.
+func snakeSchema(v any) {
+	schema, ok := v.(object)
+	if !ok {
+		return
+	}
+	for i := range schema {
+		switch schema[i].key {
+		case "properties":
+			props, _ := schema[i].val.(object)
+			for j := range props {
+				props[j].key = apijson.ToSnake(props[j].key)
+				snakeSchema(props[j].val)
+			}
+		case "required":
+			list, _ := schema[i].val.([]any)
+			for j, s := range list {
+				if s, ok := s.(string); ok {
+					list[j] = apijson.ToSnake(s)
+				}
+			}
+		case "items", "additionalProperties":
+			snakeSchema(schema[i].val)
+		case "allOf":
+			list, _ := schema[i].val.([]any)
+			for _, s := range list {
+				snakeSchema(s)
+			}
+		}
+	}
+}
+
+// object hält die Schlüssel in Originalreihenfolge, damit der Diff nach swag init klein bleibt.
+type object []member
+
+type member struct {
+	key string
+	val any
+}
+
+func parse(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := object{}
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, errors.New("object key is not a string")
+			}
+			v, err := parse(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key, v})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []any{}
+		for dec.More() {
+			v, err := parse(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+// write formatiert wie json.MarshalIndent(v, prefix, "    "), das auch swag benutzt.
+func write(b *bytes.Buffer, v any, prefix string) error {
+	inner := prefix + "    "
+	switch x := v.(type) {
+	case object:
+		if len(x) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		b.WriteString("{")
+		for i, m := range x {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n" + inner)
+			k, _ := json.Marshal(m.key)
+			b.Write(k)
+			b.WriteString(": ")
+			if err := write(b, m.val, inner); err != nil {
+				return err
+			}
+		}
+		b.WriteString("\n" + prefix + "}")
+	case []any:
+		if len(x) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteString("[")
+		for i, e := range x {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n" + inner)
+			if err := write(b, e, inner); err != nil {
+				return err
+			}
+		}
+		b.WriteString("\n" + prefix + "]")
+	default:
+		s, err := json.Marshal(x)
+		if err != nil {
+			return err
+		}
+		b.Write(s)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package access
+
+import (
+	"fmt"
+	"log"
+	"speedliner-server/src/config"
+	"strings"
+	"sync"
+)
+
+const (
+	ModeOpen      = "open"      // jeder EVE-Account darf rein (bisheriges Verhalten)
+	ModeAllowlist = "allowlist" // nur konfigurierte Corps/Alliances
+
+	ActionReject     = "reject"     // Login abweisen, kein User-Eintrag
+	ActionQuarantine = "quarantine" // User anlegen, aber ohne Rechte bis zur Freigabe
+)
+
+// Policy beschreibt den Zugangsmodus (private Deployments).
+type Policy struct {
+	Mode                 string         `json:"mode"`
+	DenyAction           string         `json:"denyAction"`
+	AllowedCorps         map[int64]bool `json:"-"`
+	AllowedAlliances     map[int64]bool `json:"-"`
+	AllowAnonymousRoutes bool           `json:"allowAnonymousRoutes"`
+	AllowedCorpIDs       []int64        `json:"allowedCorps"`
+	AllowedAllianceIDs   []int64        `json:"allowedAlliances"`
+}
+
+var (
+	mu      sync.RWMutex
+	current = Policy{Mode: ModeOpen, DenyAction: ActionReject, AllowAnonymousRoutes: true}
+)
+
+// Configure übernimmt die Policy aus der (bereits validierten) Konfiguration
+// (ACCESS_MODE, ACCESS_DENY_ACTION, ACCESS_ALLOWED_CORPS, ACCESS_ALLOWED_ALLIANCES, ALLOW_ANONYMOUS_ROUTES).
+func Configure(c config.Access) error {
+	p := Policy{
+		Mode:                 strings.ToLower(c.Mode),
+		DenyAction:           strings.ToLower(c.DenyAction),
+		AllowAnonymousRoutes: c.AllowAnonymousRoutes,
+		AllowedCorpIDs:       c.AllowedCorps,
+		AllowedAllianceIDs:   c.AllowedAlliances,
+	}
+	if p.Mode != ModeOpen && p.Mode != ModeAllowlist {
+		return fmt.Errorf("ACCESS_MODE must be %q or %q", ModeOpen, ModeAllowlist)
+	}
+	if p.DenyAction != ActionReject && p.DenyAction != ActionQuarantine {
+		return fmt.Errorf("ACCESS_DENY_ACTION must be %q or %q", ActionReject, ActionQuarantine)
+	}
+	if p.Mode == ModeAllowlist && len(p.AllowedCorpIDs) == 0 && len(p.AllowedAllianceIDs) == 0 {
+		log.Println("⚠️ ACCESS_MODE=allowlist ohne erlaubte Corps/Alliances – niemand kann sich einloggen")
+	}
+
+	Set(p)
+	return nil
+}
+
+// Set ersetzt die aktive Policy.
+func Set(p Policy) {
+	p.AllowedCorps = toSet(p.AllowedCorpIDs)
+	p.AllowedAlliances = toSet(p.AllowedAllianceIDs)
+	mu.Lock()
+	current = p
+	mu.Unlock()
+}
+
+func Current() Policy {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Check prüft die Zugehörigkeit eines Chars. reason ist bei allowed=false gesetzt.
+func (p Policy) Check(corpID int64, allianceID *int64) (allowed bool, reason string) {
+	if p.Mode != ModeAllowlist {
+		return true, ""
+	}
+	if corpID != 0 && p.AllowedCorps[corpID] {
+		return true, ""
+	}
+	if allianceID != nil && p.AllowedAlliances[*allianceID] {
+		return true, ""
+	}
+	if corpID == 0 {
+		return false, "affiliation could not be resolved"
+	}
+	return false, fmt.Sprintf("corp %d not on allow-list", corpID)
+}
+
+func toSet(ids []int64) map[int64]bool {
+	set := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
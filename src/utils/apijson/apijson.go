@@ -0,0 +1,254 @@
+// Package apijson: einheitliche JSON-Antworten, Fehler-Envelope und die
+// snake_case-Normalisierung der Feldnamen unter /app/v1.
+package apijson
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"speedliner-server/src/utils/reqctx"
+	"speedliner-server/src/utils/structs"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// CodeFor bildet den HTTP-Status auf einen maschinenlesbaren Fehlercode ab.
+func CodeFor(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusMethodNotAllowed:
+		return "method_not_allowed"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusUnprocessableEntity:
+		return "unprocessable"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	case http.StatusBadGateway:
+		return "upstream_error"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	}
+	if status >= 500 {
+		return "internal_error"
+	}
+	return "error"
+}
+
+// Error schreibt den Fehler-Envelope; die Request-ID kommt aus dem Context.
+func Error(w http.ResponseWriter, r *http.Request, status int, msg string, details any) {
+	body := structs.ErrorBody{Code: CodeFor(status), Message: msg, Details: details}
+	if r != nil {
+		body.RequestID = reqctx.RequestID(r.Context())
+	}
+	Write(w, r, status, structs.ErrorResponse{Error: body})
+}
+
+// Write kodiert v als JSON; unter /app/v1 werden Feldnamen nach snake_case normalisiert.
+func Write(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if r == nil || !reqctx.IsAPIV1(r.Context()) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+		return
+	}
+	b, err := marshalSnake(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(structs.ErrorResponse{Error: structs.ErrorBody{Code: "internal_error", Message: "encoding failed"}})
+		return
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(append(b, '\n'))
+}
+
+// Decode liest den Request-Body in v. Unter /app/v1 werden snake_case-Schlüssel
+// (und beliebige andere Schreibweisen) anhand der JSON-Tags von v zugeordnet.
+func Decode(r *http.Request, v any) error {
+	if !reqctx.IsAPIV1(r.Context()) {
+		return json.NewDecoder(r.Body).Decode(v)
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	b, err := json.Marshal(rekeyIn(raw, reflect.TypeOf(v)))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// ToSnake: "pricePerM3" -> "price_per_m3", "collateralISK" -> "collateral_isk", "char_id" bleibt.
+func ToSnake(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	for i, c := range rs {
+		if unicode.IsUpper(c) {
+			if i > 0 && rs[i-1] != '_' &&
+				(unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]) ||
+					(i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(c))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// ---- Typ-gesteuerte Umbenennung ----
+
+type field struct {
+	name string
+	typ  reflect.Type
+}
+
+var fieldCache sync.Map // reflect.Type -> map[string]field (Schlüssel: snake_case)
+
+// jsonFields liefert die JSON-Felder eines Structs (inkl. eingebetteter Structs), Schlüssel in snake_case.
+func jsonFields(t reflect.Type) map[string]field {
+	if v, ok := fieldCache.Load(t); ok {
+		return v.(map[string]field)
+	}
+	out := map[string]field{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for k, v := range jsonFields(ft) {
+				if _, exists := out[k]; !exists {
+					out[k] = v
+				}
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		out[ToSnake(name)] = field{name: name, typ: f.Type}
+	}
+	fieldCache.Store(t, out)
+	return out
+}
+
+func deref(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// rekeyIn ordnet eingehende Schlüssel den JSON-Tags des Zieltyps zu.
+func rekeyIn(val any, t reflect.Type) any {
+	t = deref(t)
+	if t == nil {
+		return val
+	}
+	switch x := val.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := jsonFields(t)
+			out := make(map[string]any, len(x))
+			for k, v := range x {
+				if f, ok := fields[ToSnake(k)]; ok {
+					out[f.name] = rekeyIn(v, f.typ)
+				} else {
+					out[k] = v
+				}
+			}
+			return out
+		case reflect.Map:
+			for k, v := range x {
+				x[k] = rekeyIn(v, t.Elem())
+			}
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i := range x {
+				x[i] = rekeyIn(x[i], t.Elem())
+			}
+		}
+	}
+	return val
+}
+
+func marshalSnake(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return json.Marshal(rekeyOut(raw, reflect.TypeOf(v)))
+}
+
+// rekeyOut benennt Struct-Felder nach snake_case um. Schlüssel typisierter Maps sind Daten
+// (IDs, Namen) und bleiben; ad-hoc-Objekte (map[string]any, interface) werden umbenannt.
+func rekeyOut(val any, t reflect.Type) any {
+	t = deref(t)
+	switch x := val.(type) {
+	case map[string]any:
+		switch {
+		case t != nil && t.Kind() == reflect.Struct:
+			fields := jsonFields(t)
+			byName := make(map[string]reflect.Type, len(fields))
+			for _, f := range fields {
+				byName[f.name] = f.typ
+			}
+			out := make(map[string]any, len(x))
+			for k, v := range x {
+				out[ToSnake(k)] = rekeyOut(v, byName[k])
+			}
+			return out
+		case t != nil && t.Kind() == reflect.Map && t.Elem().Kind() != reflect.Interface:
+			for k, v := range x {
+				x[k] = rekeyOut(v, t.Elem())
+			}
+			return x
+		default:
+			out := make(map[string]any, len(x))
+			for k, v := range x {
+				out[ToSnake(k)] = rekeyOut(v, nil)
+			}
+			return out
+		}
+	case []any:
+		var et reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			et = t.Elem()
+		}
+		for i := range x {
+			x[i] = rekeyOut(x[i], et)
+		}
+	}
+	return val
+}
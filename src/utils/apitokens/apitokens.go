@@ -0,0 +1,88 @@
+package apitokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log"
+	"speedliner-server/src/db"
+	"speedliner-server/src/utils/structs"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefix kennzeichnet Speedliner-Tokens (z.B. für Secret-Scanner).
+const Prefix = "slt_"
+
+// touchEvery drosselt die Updates von last_used_at pro Token.
+const touchEvery = time.Minute
+
+var (
+	touchMu sync.Mutex
+	touched = map[string]time.Time{}
+)
+
+// Generate erzeugt einen neuen Token. Gespeichert werden nur hash und der Anzeige-Prefix.
+func Generate() (raw, display string, hash []byte, err error) {
+	var b [32]byte
+	if _, err = rand.Read(b[:]); err != nil {
+		return "", "", nil, err
+	}
+	raw = Prefix + base64.RawURLEncoding.EncodeToString(b[:])
+	return raw, raw[:len(Prefix)+6], Hash(raw), nil
+}
+
+func Hash(raw string) []byte {
+	sum := sha256.Sum256([]byte(raw))
+	return sum[:]
+}
+
+// Resolve prüft einen Klartext-Token. nil = ungültig, widerrufen, abgelaufen oder Besitzer gesperrt.
+func Resolve(ctx context.Context, raw string) (*structs.APIToken, error) {
+	if !strings.HasPrefix(raw, Prefix) {
+		return nil, nil
+	}
+	t, err := db.GetActiveAPIToken(ctx, Hash(raw))
+	if err != nil || t == nil {
+		return nil, err
+	}
+	banned, err := db.IsUserBanned(ctx, t.CharID)
+	if err != nil {
+		return nil, err
+	}
+	if banned {
+		return nil, nil
+	}
+	touch(ctx, t.ID)
+	return t, nil
+}
+
+func touch(ctx context.Context, id string) {
+	now := time.Now()
+	touchMu.Lock()
+	if t, ok := touched[id]; ok && now.Sub(t) < touchEvery {
+		touchMu.Unlock()
+		return
+	}
+	touched[id] = now
+	touchMu.Unlock()
+	if err := db.TouchAPIToken(ctx, id); err != nil {
+		log.Printf("TouchAPIToken %s: %v", id, err)
+	}
+}
+
+var defaultRatePerMin = 60
+
+// SetDefaultRatePerMin setzt das Limit für Tokens ohne eigenes Limit (API_TOKEN_RATE_PER_MIN).
+func SetDefaultRatePerMin(n int) {
+	if n > 0 {
+		defaultRatePerMin = n
+	}
+}
+
+// DefaultRatePerMin gilt für Tokens ohne eigenes Limit (Standard 60).
+func DefaultRatePerMin() int {
+	return defaultRatePerMin
+}
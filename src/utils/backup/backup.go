@@ -0,0 +1,204 @@
+// Package backup schreibt logische Datenbank-Backups (db.DumpBackup) als gzip-komprimierte
+// JSON-Archive ins Backup-Verzeichnis, räumt alte Archive ab und liest sie für den Restore.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"speedliner-server/src/config"
+	"speedliner-server/src/db"
+	"speedliner-server/src/utils/structs"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	filePrefix = "speedliner-"
+	fileSuffix = ".json.gz"
+)
+
+var ErrInvalidName = errors.New("invalid backup name")
+
+// mu: nie zwei Backups gleichzeitig (Admin-Endpoint + Scheduler)
+var mu sync.Mutex
+
+// Create exportiert die Datenbank nach dir und behält danach nur die keep neuesten Archive (0 = alle).
+func Create(ctx context.Context, dir string, keep int) (structs.BackupInfo, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	a, err := db.DumpBackup(ctx)
+	if err != nil {
+		return structs.BackupInfo{}, err
+	}
+	info, err := write(dir, a)
+	if err != nil {
+		return structs.BackupInfo{}, err
+	}
+	if err := prune(dir, keep); err != nil {
+		log.Printf("backup prune: %v", err)
+	}
+	return info, nil
+}
+
+// write legt das Archiv atomar an (temporäre Datei + Rename), lesbar nur für den Server-User.
+func write(dir string, a *structs.BackupArchive) (structs.BackupInfo, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return structs.BackupInfo{}, err
+	}
+	name := fmt.Sprintf("%s%s-s%d%s", filePrefix, a.CreatedAt.UTC().Format("20060102T150405Z"), a.SchemaVersion, fileSuffix)
+	tmp, err := os.CreateTemp(dir, ".tmp-"+filePrefix+"*")
+	if err != nil {
+		return structs.BackupInfo{}, err
+	}
+	defer os.Remove(tmp.Name()) // nach erfolgreichem Rename wirkungslos
+
+	zw := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(zw).Encode(a); err != nil {
+		tmp.Close()
+		return structs.BackupInfo{}, err
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return structs.BackupInfo{}, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return structs.BackupInfo{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return structs.BackupInfo{}, err
+	}
+	path := filepath.Join(dir, name)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return structs.BackupInfo{}, err
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		return structs.BackupInfo{}, err
+	}
+	return structs.BackupInfo{Name: name, Size: st.Size(), CreatedAt: a.CreatedAt}, nil
+}
+
+// List liefert die Archive in dir, neueste zuerst.
+func List(dir string) ([]structs.BackupInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []structs.BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := []structs.BackupInfo{}
+	for _, e := range entries {
+		if e.IsDir() || !isArchiveName(e.Name()) {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		list = append(list, structs.BackupInfo{Name: e.Name(), Size: fi.Size(), CreatedAt: fi.ModTime().UTC()})
+	}
+	// Zeitstempel im Namen sortiert lexikographisch
+	sort.Slice(list, func(i, j int) bool { return list[i].Name > list[j].Name })
+	return list, nil
+}
+
+func prune(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	list, err := List(dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, b := range list[min(keep, len(list)):] {
+		if err := os.Remove(filepath.Join(dir, b.Name)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isArchiveName(name string) bool {
+	return strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, fileSuffix) &&
+		!strings.ContainsAny(name, `/\`)
+}
+
+// Path löst einen Archivnamen aus List sicher gegen dir auf.
+func Path(dir, name string) (string, error) {
+	if !isArchiveName(name) || name != filepath.Base(name) {
+		return "", ErrInvalidName
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Read liest ein Archiv (gzip oder unkomprimiertes JSON, "-" = stdin).
+func Read(path string) (*structs.BackupArchive, error) {
+	in := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+	br := bufio.NewReader(in)
+	r := io.Reader(br)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	var a structs.BackupArchive
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, fmt.Errorf("invalid backup archive: %w", err)
+	}
+	return &a, nil
+}
+
+// StartScheduler legt alle cfg.Interval ein Backup an (0 = aus), bis ctx endet.
+// wait blockiert, bis ein laufendes Backup nach dem Ende von ctx beendet ist.
+func StartScheduler(ctx context.Context, cfg config.Backup) (wait func()) {
+	if cfg.Interval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(cfg.Interval.Std())
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				info, err := Create(ctx, cfg.Dir, cfg.Keep)
+				if err != nil {
+					if ctx.Err() == nil {
+						log.Printf("scheduled backup: %v", err)
+					}
+					continue
+				}
+				log.Printf("💾 Backup %s (%d bytes)", info.Name, info.Size)
+			}
+		}
+	}()
+	return func() { <-done }
+}
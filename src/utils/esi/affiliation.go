@@ -3,9 +3,9 @@ package esi
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -32,9 +32,31 @@ type allianceResp struct {
 	Ticker     string `json:"ticker"`
 }
 
-// einfache ETag-Caches (pro Prozess)
-var etagCache = map[string]string{}
-var bodyCache = map[string][]byte{}
+// einfache ETag-Caches (pro Prozess); Zugriff nur über die Helfer, da Login und Refresh parallel laufen
+var (
+	cacheMu   sync.Mutex
+	etagCache = map[string]string{}
+	bodyCache = map[string][]byte{}
+)
+
+func cachedETag(url string) string {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	return etagCache[url]
+}
+
+func cachedBody(url string) []byte {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	return bodyCache[url]
+}
+
+func cachePut(url, etag string, body []byte) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	etagCache[url] = etag
+	bodyCache[url] = body
+}
 
 // FetchCorpAndAlliance holt "jetzt"-Zugehörigkeit via Affiliation (Cache ~1h),
 // und resolved Namen/Ticker. Kann sicher aus Callback aufgerufen werden.
@@ -64,12 +86,12 @@ func FetchCorpAndAlliance(characterID int) (corpID int64, corpName, corpTicker s
 }
 
 func fetchAffiliation(charID int64) *affiliationResp {
-	url := "https://esi.evetech.net/v1/characters/affiliation/?datasource=tranquility"
+	url := URL("/v1/characters/affiliation/?datasource=tranquility")
 	payload, _ := json.Marshal([]int64{charID})
 	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", ua)
-	if etag := etagCache[url]; etag != "" {
+	if etag := cachedETag(url); etag != "" {
 		req.Header.Set("If-None-Match", etag)
 	}
 
@@ -82,15 +104,14 @@ func fetchAffiliation(charID int64) *affiliationResp {
 	switch resp.StatusCode {
 	case http.StatusOK:
 		b, _ := io.ReadAll(resp.Body)
-		etagCache[url] = resp.Header.Get("ETag")
-		bodyCache[url] = b
+		cachePut(url, resp.Header.Get("ETag"), b)
 		var arr []affiliationResp
 		if err := json.Unmarshal(b, &arr); err == nil && len(arr) > 0 {
 			return &arr[0]
 		}
 		return nil
 	case http.StatusNotModified:
-		if b := bodyCache[url]; b != nil {
+		if b := cachedBody(url); b != nil {
 			var arr []affiliationResp
 			if err := json.Unmarshal(b, &arr); err == nil && len(arr) > 0 {
 				return &arr[0]
@@ -103,10 +124,10 @@ func fetchAffiliation(charID int64) *affiliationResp {
 }
 
 func fetchCorp(id int64) *corpResp {
-	url := fmt.Sprintf("https://esi.evetech.net/latest/corporations/%d/?datasource=tranquility", id)
+	url := URL("/latest/corporations/%d/?datasource=tranquility", id)
 	req, _ := http.NewRequest(http.MethodGet, url, nil)
 	req.Header.Set("User-Agent", ua)
-	if etag := etagCache[url]; etag != "" {
+	if etag := cachedETag(url); etag != "" {
 		req.Header.Set("If-None-Match", etag)
 	}
 	resp, err := httpClient.Do(req)
@@ -118,14 +139,13 @@ func fetchCorp(id int64) *corpResp {
 	switch resp.StatusCode {
 	case http.StatusOK:
 		b, _ := io.ReadAll(resp.Body)
-		etagCache[url] = resp.Header.Get("ETag")
-		bodyCache[url] = b
+		cachePut(url, resp.Header.Get("ETag"), b)
 		var out corpResp
 		if err := json.Unmarshal(b, &out); err == nil {
 			return &out
 		}
 	case http.StatusNotModified:
-		if b := bodyCache[url]; b != nil {
+		if b := cachedBody(url); b != nil {
 			var out corpResp
 			if err := json.Unmarshal(b, &out); err == nil {
 				return &out
@@ -136,10 +156,10 @@ func fetchCorp(id int64) *corpResp {
 }
 
 func fetchAlliance(id int64) *allianceResp {
-	url := fmt.Sprintf("https://esi.evetech.net/latest/alliances/%d/?datasource=tranquility", id)
+	url := URL("/latest/alliances/%d/?datasource=tranquility", id)
 	req, _ := http.NewRequest(http.MethodGet, url, nil)
 	req.Header.Set("User-Agent", ua)
-	if etag := etagCache[url]; etag != "" {
+	if etag := cachedETag(url); etag != "" {
 		req.Header.Set("If-None-Match", etag)
 	}
 	resp, err := httpClient.Do(req)
@@ -151,14 +171,13 @@ func fetchAlliance(id int64) *allianceResp {
 	switch resp.StatusCode {
 	case http.StatusOK:
 		b, _ := io.ReadAll(resp.Body)
-		etagCache[url] = resp.Header.Get("ETag")
-		bodyCache[url] = b
+		cachePut(url, resp.Header.Get("ETag"), b)
 		var out allianceResp
 		if err := json.Unmarshal(b, &out); err == nil {
 			return &out
 		}
 	case http.StatusNotModified:
-		if b := bodyCache[url]; b != nil {
+		if b := cachedBody(url); b != nil {
 			var out allianceResp
 			if err := json.Unmarshal(b, &out); err == nil {
 				return &out
@@ -0,0 +1,19 @@
+package esi
+
+import (
+	"fmt"
+	"speedliner-server/src/config"
+)
+
+// baseURL: ESI-Host (ESI_BASE_URL), in Tests ein Fake-Server.
+var baseURL = config.Default().ESI.BaseURL
+
+// Configure setzt die ESI-Basis-URL einmalig beim Start.
+func Configure(c config.ESI) {
+	baseURL = c.BaseURL
+}
+
+// URL baut eine ESI-URL aus Pfad (mit führendem Slash) und fmt-Argumenten.
+func URL(format string, a ...any) string {
+	return baseURL + fmt.Sprintf(format, a...)
+}
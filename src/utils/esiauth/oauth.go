@@ -1,7 +1,8 @@
 package esiauth
 
 import (
-	"os"
+	"context"
+	"speedliner-server/src/config"
 	"sync"
 
 	"golang.org/x/oauth2"
@@ -15,30 +16,37 @@ var (
 
 func InitStore(s TokenStore) { store = s }
 
+var oauthCfg = newOAuthConfig(config.Default().OAuth)
+
+// Configure setzt Client-Daten und Redirect-URL einmalig beim Start.
+func Configure(c config.OAuth) {
+	oauthCfg = newOAuthConfig(c)
+}
+
 func GetOAuthConfig() *oauth2.Config {
-	redirect := os.Getenv("OAUTH_REDIRECT_URL")
-	if redirect == "" {
-		redirect = "http://localhost:8080/app/callback"
-	}
+	return oauthCfg
+}
+
+func newOAuthConfig(c config.OAuth) *oauth2.Config {
 	return &oauth2.Config{
-		ClientID:     os.Getenv("OAUTH_CLIENT_ID"),
-		ClientSecret: os.Getenv("OAUTH_CLIENT_SECRET"),
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
 		Scopes: []string{
 			"esi-mail.send_mail.v1",
 			"publicData",
 		},
-		RedirectURL: redirect,
+		RedirectURL: c.RedirectURL,
 		Endpoint: oauth2.Endpoint{
-			AuthURL:  "https://login.eveonline.com/v2/oauth/authorize",
-			TokenURL: "https://login.eveonline.com/v2/oauth/token",
+			AuthURL:  c.AuthURL,
+			TokenURL: c.TokenURL,
 		},
 	}
 }
 
 // Persistiert wenn store != nil, sonst In-Memory.
-func SaveToken(charID string, token *oauth2.Token) error {
+func SaveToken(ctx context.Context, charID string, token *oauth2.Token) error {
 	if store != nil {
-		return store.Put(charID, token)
+		return store.Put(ctx, charID, token)
 	}
 	mu.Lock()
 	defer mu.Unlock()
@@ -46,9 +54,9 @@ func SaveToken(charID string, token *oauth2.Token) error {
 	return nil
 }
 
-func LoadToken(charID string) (*oauth2.Token, bool) {
+func LoadToken(ctx context.Context, charID string) (*oauth2.Token, bool) {
 	if store != nil {
-		return store.Get(charID)
+		return store.Get(ctx, charID)
 	}
 	mu.RLock()
 	defer mu.RUnlock()
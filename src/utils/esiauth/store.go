@@ -2,6 +2,7 @@
 package esiauth
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"time"
@@ -10,16 +11,16 @@ import (
 )
 
 type TokenStore interface {
-	Get(charID string) (*oauth2.Token, bool)
-	Put(charID string, tok *oauth2.Token) error
-	Delete(charID string) error
+	Get(ctx context.Context, charID string) (*oauth2.Token, bool)
+	Put(ctx context.Context, charID string, tok *oauth2.Token) error
+	Delete(ctx context.Context, charID string) error
 }
 
 type DBTokenStore struct{ DB *sql.DB }
 
-func (s *DBTokenStore) Get(charID string) (*oauth2.Token, bool) {
+func (s *DBTokenStore) Get(ctx context.Context, charID string) (*oauth2.Token, bool) {
 	var js string
-	err := s.DB.QueryRow(`SELECT token_json FROM oauth_tokens WHERE char_id=$1`, charID).Scan(&js)
+	err := s.DB.QueryRowContext(ctx, `SELECT token_json FROM oauth_tokens WHERE char_id=$1`, charID).Scan(&js)
 	if err != nil {
 		return nil, false
 	}
@@ -30,9 +31,9 @@ func (s *DBTokenStore) Get(charID string) (*oauth2.Token, bool) {
 	return &tok, true
 }
 
-func (s *DBTokenStore) Put(charID string, tok *oauth2.Token) error {
+func (s *DBTokenStore) Put(ctx context.Context, charID string, tok *oauth2.Token) error {
 	b, _ := json.Marshal(tok) // Optional: hier verschlüsseln (AES-GCM) mit KEY aus ENV
-	_, err := s.DB.Exec(`
+	_, err := s.DB.ExecContext(ctx, `
 		INSERT INTO oauth_tokens (char_id, token_json, updated_at)
 		VALUES ($1,$2,$3)
 		ON CONFLICT (char_id) DO UPDATE SET token_json=EXCLUDED.token_json, updated_at=EXCLUDED.updated_at
@@ -15,9 +15,9 @@ func NewPGXTokenStore(pool *pgxpool.Pool) *PGXTokenStore {
 	return &PGXTokenStore{Pool: pool}
 }
 
-func (s *PGXTokenStore) Get(charID string) (*oauth2.Token, bool) {
+func (s *PGXTokenStore) Get(ctx context.Context, charID string) (*oauth2.Token, bool) {
 	var js string
-	err := s.Pool.QueryRow(context.Background(),
+	err := s.Pool.QueryRow(ctx,
 		`SELECT token_json FROM oauth_tokens WHERE char_id=$1`, charID).
 		Scan(&js)
 	if err != nil {
@@ -30,9 +30,9 @@ func (s *PGXTokenStore) Get(charID string) (*oauth2.Token, bool) {
 	return &tok, true
 }
 
-func (s *PGXTokenStore) Put(charID string, tok *oauth2.Token) error {
+func (s *PGXTokenStore) Put(ctx context.Context, charID string, tok *oauth2.Token) error {
 	b, _ := json.Marshal(tok) // optional: verschlüsseln (AES-GCM)
-	_, err := s.Pool.Exec(context.Background(),
+	_, err := s.Pool.Exec(ctx,
 		`INSERT INTO oauth_tokens (char_id, token_json, updated_at)
 		 VALUES ($1,$2,$3)
 		 ON CONFLICT (char_id) DO UPDATE
@@ -41,8 +41,8 @@ func (s *PGXTokenStore) Put(charID string, tok *oauth2.Token) error {
 	return err
 }
 
-func (s *PGXTokenStore) Delete(charID string) error {
-	_, err := s.Pool.Exec(context.Background(),
+func (s *PGXTokenStore) Delete(ctx context.Context, charID string) error {
+	_, err := s.Pool.Exec(ctx,
 		`DELETE FROM oauth_tokens WHERE char_id=$1`, charID)
 	return err
 }
@@ -0,0 +1,181 @@
+// Package metrics: Prometheus-Metriken (HTTP, ESI, Mail, Rate-Limits, DB-Pool) und der /metrics-Handler.
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "speedliner"
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "http_requests_total",
+		Help: "HTTP-Requests nach Methode, Route-Pattern und Status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Name: "http_request_duration_seconds",
+		Help:    "Antwortzeit nach Methode, Route-Pattern und Status.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "route", "status"})
+
+	ESIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "esi_requests_total",
+		Help: "ESI-Aufrufe nach Methode, Endpoint und Status (error = Transportfehler).",
+	}, []string{"method", "endpoint", "status"})
+
+	ESIDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Name: "esi_request_duration_seconds",
+		Help:    "Dauer der ESI-Aufrufe.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "endpoint"})
+
+	ESIErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "esi_errors_total",
+		Help: "Fehlgeschlagene ESI-Aufrufe (Transportfehler oder Status >= 400).",
+	}, []string{"endpoint"})
+
+	ESIErrorLimitRemain = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Name: "esi_error_limit_remain",
+		Help: "Verbleibendes ESI-Fehlerbudget (X-ESI-Error-Limit-Remain der letzten Antwort).",
+	})
+
+	ESIErrorLimitReset = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Name: "esi_error_limit_reset_seconds",
+		Help: "Sekunden bis zum Reset des ESI-Fehlerbudgets (X-ESI-Error-Limit-Reset).",
+	})
+
+	MailSends = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "mail_sends_total",
+		Help: "EVE-Mail-Versand nach Art (mail|express) und Ergebnis (sent|esi_rejected|esi_error).",
+	}, []string{"kind", "outcome"})
+
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "rate_limit_rejections_total",
+		Help: "Mit 429 abgewiesene Requests nach Policy.",
+	}, []string{"policy"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPDuration,
+		ESIRequests, ESIDuration, ESIErrors, ESIErrorLimitRemain, ESIErrorLimitReset,
+		MailSends, RateLimitRejections,
+	)
+}
+
+// ObserveHTTP zählt einen abgeschlossenen Request; route ist das chi-Pattern (z.B. /app/v1/routes/{id}).
+func ObserveHTTP(method, route string, status int, d time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	s := strconv.Itoa(status)
+	HTTPRequests.WithLabelValues(method, route, s).Inc()
+	HTTPDuration.WithLabelValues(method, route, s).Observe(d.Seconds())
+}
+
+// MailSent zählt einen Versandversuch (kind mail|express).
+func MailSent(kind, outcome string) {
+	MailSends.WithLabelValues(kind, outcome).Inc()
+}
+
+// RegisterPool stellt die pgxpool-Statistik als Metriken bereit.
+func RegisterPool(pool *pgxpool.Pool) {
+	gauge := func(name, help string, f func(s *pgxpool.Stat) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{Namespace: namespace, Subsystem: "db_pool", Name: name, Help: help},
+			func() float64 { return f(pool.Stat()) })
+	}
+	counter := func(name, help string, f func(s *pgxpool.Stat) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{Namespace: namespace, Subsystem: "db_pool", Name: name, Help: help},
+			func() float64 { return f(pool.Stat()) })
+	}
+	Registry.MustRegister(
+		gauge("acquired_conns", "Ausgeliehene Verbindungen.", func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }),
+		gauge("idle_conns", "Freie Verbindungen.", func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }),
+		gauge("total_conns", "Verbindungen insgesamt.", func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }),
+		gauge("max_conns", "Maximale Poolgröße.", func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }),
+		counter("acquires_total", "Erfolgreiche Acquires.", func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }),
+		counter("empty_acquires_total", "Acquires, die auf eine Verbindung warten mussten.", func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }),
+		counter("canceled_acquires_total", "Abgebrochene Acquires.", func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) }),
+		counter("acquire_duration_seconds_total", "Summierte Wartezeit beim Acquire.", func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }),
+	)
+}
+
+// Handler liefert /metrics; mit token nur gegen "Authorization: Bearer <token>".
+func Handler(token string) http.Handler {
+	h := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// ---- ESI ----
+
+var idSegment = regexp.MustCompile(`/\d+(/|$)`)
+
+// esiEndpoint normalisiert den Pfad (IDs → {id}), damit die Label-Kardinalität klein bleibt.
+func esiEndpoint(path string) string {
+	for idSegment.MatchString(path) {
+		path = idSegment.ReplaceAllString(path, "/{id}$1")
+	}
+	return path
+}
+
+type esiTransport struct{ base http.RoundTripper }
+
+// Transport instrumentiert Aufrufe an esi.evetech.net; andere Hosts laufen unverändert durch.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return esiTransport{base: base}
+}
+
+func (t esiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Hostname() != "esi.evetech.net" {
+		return t.base.RoundTrip(req)
+	}
+	ep := esiEndpoint(req.URL.Path)
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	ESIDuration.WithLabelValues(req.Method, ep).Observe(time.Since(start).Seconds())
+	if err != nil {
+		ESIRequests.WithLabelValues(req.Method, ep, "error").Inc()
+		ESIErrors.WithLabelValues(ep).Inc()
+		return resp, err
+	}
+	ESIRequests.WithLabelValues(req.Method, ep, strconv.Itoa(resp.StatusCode)).Inc()
+	if resp.StatusCode >= 400 {
+		ESIErrors.WithLabelValues(ep).Inc()
+	}
+	if v, err := strconv.ParseFloat(resp.Header.Get("X-ESI-Error-Limit-Remain"), 64); err == nil {
+		ESIErrorLimitRemain.Set(v)
+	}
+	if v, err := strconv.ParseFloat(resp.Header.Get("X-ESI-Error-Limit-Reset"), 64); err == nil {
+		ESIErrorLimitReset.Set(v)
+	}
+	return resp, err
+}
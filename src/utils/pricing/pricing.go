@@ -0,0 +1,76 @@
+package pricing
+
+import (
+	"math"
+	"speedliner-server/src/utils/structs"
+)
+
+// gleiche Grenzen wie calculator.js im Frontend
+const (
+	MaxVolumeM3   = 351_000
+	MaxCollateral = 20_000_000_000
+)
+
+// Quote rechnet einen Preis analog zum Frontend-Rechner:
+// Volumen * ISK/m³ + Collateral-Fee (3% bis halbe Max-Ladung, sonst 1%),
+// Rabatte auf die Summe, danach Mindestpreis, Express verdoppelt.
+func Quote(route structs.Route, rate *structs.RouteRate, promo *structs.PromoCode, req structs.QuoteRequest) structs.QuoteResponse {
+	out := structs.QuoteResponse{
+		RouteID:        route.ID,
+		BasePricePerM3: route.PricePerM3,
+		PricePerM3:     route.PricePerM3,
+		MinPrice:       route.MinPrice,
+		Express:        req.Express,
+	}
+
+	if rate != nil {
+		if rate.CorpID != nil {
+			out.RateSource = "corp"
+		} else {
+			out.RateSource = "alliance"
+		}
+		if rate.PricePerM3 != nil {
+			out.PricePerM3 = *rate.PricePerM3
+		}
+		if rate.DiscountPct != nil {
+			out.RateDiscountPct = *rate.DiscountPct
+		}
+		if rate.MinPrice != nil {
+			out.MinPrice = *rate.MinPrice
+		}
+	}
+	if promo != nil {
+		out.PromoCode = promo.Code
+		out.PromoDiscountPct = promo.DiscountPct
+	}
+
+	collateral := req.CollateralISK
+	collateralPct := 0.0
+	if route.NoCollateral {
+		collateral = 0
+	} else if req.VolumeM3 <= MaxVolumeM3/2 {
+		collateralPct = 0.03
+	} else {
+		collateralPct = 0.01
+	}
+
+	volumeFee := float64(req.VolumeM3) * out.PricePerM3
+	collateralFee := float64(collateral) * collateralPct
+	out.VolumeFee = int64(math.Round(volumeFee))
+	out.CollateralFee = int64(math.Round(collateralFee))
+
+	total := volumeFee + collateralFee
+	total *= 1 - out.RateDiscountPct/100
+	total *= 1 - out.PromoDiscountPct/100
+	total = math.Round(total)
+
+	if total < out.MinPrice {
+		total = out.MinPrice
+		out.MinApplied = out.MinPrice > 0
+	}
+	if req.Express {
+		total *= 2
+	}
+	out.Total = int64(total)
+	return out
+}
@@ -0,0 +1,44 @@
+// Package reqctx hält Request-Metadaten im Context (Request-ID, API-Version, Client-IP).
+// Eigenes Blatt-Paket, damit middleware, handler und db es ohne Import-Zyklen nutzen können.
+package reqctx
+
+import "context"
+
+type key int
+
+const (
+	requestIDKey key = iota
+	apiV1Key
+	clientIPKey
+)
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID liefert die Request-ID ("" = keine).
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithAPIV1 markiert Requests unter /app/v1 (snake_case-Felder).
+func WithAPIV1(ctx context.Context) context.Context {
+	return context.WithValue(ctx, apiV1Key, true)
+}
+
+func IsAPIV1(ctx context.Context) bool {
+	v, _ := ctx.Value(apiV1Key).(bool)
+	return v
+}
+
+// WithClientIP hinterlegt die (über vertrauenswürdige Proxies aufgelöste) Client-IP.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// ClientIP liefert die aufgelöste Client-IP ("" = nicht gesetzt).
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
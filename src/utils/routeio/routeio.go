@@ -0,0 +1,149 @@
+// Package routeio liest und schreibt Routen-Exporte als CSV oder JSON.
+package routeio
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"speedliner-server/src/utils/structs"
+	"strconv"
+	"strings"
+)
+
+// Header: Spalten des CSV-Formats; allowed_corps enthält Ticker oder Corp-IDs, getrennt durch ";".
+var Header = []string{"id", "from", "to", "price_per_m3", "min_price", "no_collateral", "visibility", "allowed_corps"}
+
+// WriteCSV schreibt routes mit Header; Whitelist-Corps als Ticker (ID, falls kein Ticker bekannt).
+func WriteCSV(w io.Writer, routes []structs.RouteExport) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Header); err != nil {
+		return err
+	}
+	for _, r := range routes {
+		corps := make([]string, 0, len(r.AllowedCorps))
+		for i, cid := range r.AllowedCorps {
+			if i < len(r.AllowedCorpTickers) && r.AllowedCorpTickers[i] != "" {
+				corps = append(corps, r.AllowedCorpTickers[i])
+			} else {
+				corps = append(corps, strconv.FormatInt(cid, 10))
+			}
+		}
+		if err := cw.Write([]string{
+			r.ID,
+			r.From,
+			r.To,
+			strconv.FormatFloat(r.PricePerM3, 'f', -1, 64),
+			strconv.FormatFloat(r.MinPrice, 'f', -1, 64),
+			strconv.FormatBool(r.NoCollateral),
+			r.Visibility,
+			strings.Join(corps, ";"),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV liest Zeilen im Format von WriteCSV. Spalten werden über den Header zugeordnet,
+// Reihenfolge und fehlende optionale Spalten (id, min_price, no_collateral, visibility, allowed_corps) sind egal.
+func ReadCSV(r io.Reader) ([]structs.RouteImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	head, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("empty CSV")
+	}
+	if err != nil {
+		return nil, err
+	}
+	col := map[string]int{}
+	for i, h := range head {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		col[h] = i
+	}
+	for _, req := range []string{"from", "to", "price_per_m3"} {
+		if _, ok := col[req]; !ok {
+			return nil, fmt.Errorf("CSV header: missing column %q", req)
+		}
+	}
+
+	var rows []structs.RouteImportRow
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := col[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		if strings.Join(rec, "") == "" {
+			continue
+		}
+
+		var it structs.RouteExport
+		it.ID = field("id")
+		it.From = field("from")
+		it.To = field("to")
+		it.Visibility = field("visibility")
+		if it.PricePerM3, err = parseFloat(field("price_per_m3")); err != nil {
+			return nil, fmt.Errorf("line %d: price_per_m3: %w", line, err)
+		}
+		if it.MinPrice, err = parseFloat(field("min_price")); err != nil {
+			return nil, fmt.Errorf("line %d: min_price: %w", line, err)
+		}
+		if v := field("no_collateral"); v != "" {
+			if it.NoCollateral, err = strconv.ParseBool(v); err != nil {
+				return nil, fmt.Errorf("line %d: no_collateral: %w", line, err)
+			}
+		}
+		for _, c := range strings.Split(field("allowed_corps"), ";") {
+			if c = strings.TrimSpace(c); c == "" {
+				continue
+			}
+			if id, err := strconv.ParseInt(c, 10, 64); err == nil {
+				it.AllowedCorps = append(it.AllowedCorps, id)
+			} else {
+				it.AllowedCorpTickers = append(it.AllowedCorpTickers, c)
+			}
+		}
+		rows = append(rows, structs.RouteImportRow{Line: line, Route: it})
+	}
+}
+
+// ReadJSON liest ein Array im Format des JSON-Exports; Line ist die Position im Array (1-basiert).
+func ReadJSON(r io.Reader) ([]structs.RouteImportRow, error) {
+	var list []structs.RouteExport
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return nil, err
+	}
+	return Rows(list), nil
+}
+
+// Rows nummeriert bereits dekodierte Routen für den Import.
+func Rows(list []structs.RouteExport) []structs.RouteImportRow {
+	rows := make([]structs.RouteImportRow, len(list))
+	for i, it := range list {
+		rows[i] = structs.RouteImportRow{Line: i + 1, Route: it}
+	}
+	return rows
+}
+
+func parseFloat(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	// Tausendertrennzeichen aus Tabellenkalkulationen tolerieren
+	return strconv.ParseFloat(strings.NewReplacer("_", "", " ", "").Replace(s), 64)
+}
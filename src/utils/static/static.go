@@ -0,0 +1,218 @@
+// Package static liefert das Frontend aus: ETag aus dem Inhalt (If-None-Match → 304),
+// Cache-Control je Dateityp, Brotli/Gzip nach Accept-Encoding und SPA-Fallback auf index.html.
+package static
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache-Control: HTML/JS/CSS tragen keine Hashes im Namen → jedes Mal per ETag revalidieren;
+// Bilder/Fonts ändern sich praktisch nie.
+const (
+	cacheRevalidate = "no-cache"
+	cacheLong       = "public, max-age=604800"
+	cacheDev        = "no-store"
+)
+
+// gzipMin: kleinere Dateien lohnen die Kompression nicht.
+const gzipMin = 1024
+
+var compressible = map[string]bool{
+	".html": true, ".js": true, ".mjs": true, ".css": true, ".svg": true, ".json": true, ".map": true, ".txt": true,
+}
+
+type variant struct {
+	body []byte
+	etag string
+}
+
+// file: eine Datei samt vorbereiteter Kodierungen ("" = unkomprimiert, "br", "gzip").
+type file struct {
+	name     string
+	variants map[string]variant
+}
+
+type handler struct {
+	fsys fs.FS
+	dev  bool
+
+	files map[string]*file // nur eingebettet: beim Start komplett geladen (inkl. Kompression)
+}
+
+// Handler liefert fsys aus. dev: bei jedem Request frisch von fsys lesen (Live-Editing von der Platte)
+// und nichts cachen – weder im Server noch im Browser. Sonst gilt fsys als unveränderlich (embed.FS)
+// und wird einmal vorab geladen; unbekannte Pfade kosten danach nur einen Map-Zugriff.
+func Handler(fsys fs.FS, dev bool) http.Handler {
+	h := &handler{fsys: fsys, dev: dev}
+	if !dev {
+		h.files = preload(fsys)
+	}
+	return h
+}
+
+// preload lädt alle auslieferbaren Dateien; Lesefehler tauchen später als 404 auf.
+func preload(fsys fs.FS) map[string]*file {
+	files := map[string]*file{}
+	_ = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if f, err := load(fsys, name); err == nil && f != nil {
+			files[name] = f
+		}
+		return nil
+	})
+	return files
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" || strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+
+	f, err := h.lookup(name)
+	if f == nil && err == nil && path.Ext(name) == "" {
+		// SPA: unbekannte Seiten-Pfade bekommen die Startseite
+		f, err = h.lookup("index.html")
+	}
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if f == nil {
+		http.NotFound(w, r)
+		return
+	}
+	h.serve(w, r, f)
+}
+
+func (h *handler) serve(w http.ResponseWriter, r *http.Request, f *file) {
+	enc := negotiate(r.Header.Get("Accept-Encoding"), f.variants)
+	v := f.variants[enc]
+
+	hdr := w.Header()
+	if ct := mime.TypeByExtension(path.Ext(f.name)); ct != "" {
+		hdr.Set("Content-Type", ct)
+	}
+	if len(f.variants) > 1 {
+		hdr.Add("Vary", "Accept-Encoding")
+	}
+	if enc != "" {
+		hdr.Set("Content-Encoding", enc)
+	}
+	hdr.Set("ETag", v.etag)
+	switch {
+	case h.dev:
+		hdr.Set("Cache-Control", cacheDev)
+	case compressible[path.Ext(f.name)]:
+		hdr.Set("Cache-Control", cacheRevalidate)
+	default:
+		hdr.Set("Cache-Control", cacheLong)
+	}
+	// ServeContent übernimmt If-None-Match/If-Match, Range und HEAD
+	http.ServeContent(w, r, f.name, time.Time{}, bytes.NewReader(v.body))
+}
+
+// negotiate wählt br vor gzip, sofern vom Client akzeptiert und vorhanden.
+func negotiate(accept string, variants map[string]variant) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(v, 64); err == nil && q == 0 {
+				continue
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(coding))] = true
+	}
+	for _, enc := range []string{"br", "gzip"} {
+		if _, ok := variants[enc]; ok && accepted[enc] {
+			return enc
+		}
+	}
+	return ""
+}
+
+func (h *handler) lookup(name string) (*file, error) {
+	if h.dev {
+		return load(h.fsys, name)
+	}
+	return h.files[name], nil
+}
+
+// load liest name samt vorkomprimierten Varianten (name.br, name.gz); fehlt .gz, wird Text
+// einmalig hier komprimiert. Quellcode und die Varianten selbst werden nicht ausgeliefert.
+func load(fsys fs.FS, name string) (*file, error) {
+	if !fs.ValidPath(name) || hidden(name) {
+		return nil, nil
+	}
+	body, err := fs.ReadFile(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) || isDirErr(fsys, name, err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f := &file{name: name, variants: map[string]variant{"": newVariant(body, "")}}
+	for enc, ext := range map[string]string{"br": ".br", "gzip": ".gz"} {
+		if b, err := fs.ReadFile(fsys, name+ext); err == nil {
+			f.variants[enc] = newVariant(b, enc)
+		}
+	}
+	if _, ok := f.variants["gzip"]; !ok && compressible[path.Ext(name)] && len(body) >= gzipMin {
+		var buf bytes.Buffer
+		zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		_, _ = zw.Write(body)
+		if zw.Close() == nil && buf.Len() < len(body) {
+			f.variants["gzip"] = newVariant(buf.Bytes(), "gzip")
+		}
+	}
+	return f, nil
+}
+
+func newVariant(body []byte, enc string) variant {
+	sum := sha256.Sum256(body)
+	tag := hex.EncodeToString(sum[:8])
+	if enc != "" {
+		tag += "-" + enc
+	}
+	return variant{body: body, etag: `"` + tag + `"`}
+}
+
+func hidden(name string) bool {
+	switch path.Ext(name) {
+	case ".go", ".br", ".gz":
+		return true
+	}
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+func isDirErr(fsys fs.FS, name string, err error) bool {
+	if err == nil {
+		return false
+	}
+	fi, statErr := fs.Stat(fsys, name)
+	return statErr == nil && fi.IsDir()
+}
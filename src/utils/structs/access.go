@@ -0,0 +1,19 @@
+package structs
+
+import "time"
+
+// LoginDenial protokolliert abgewiesene oder in Quarantäne gesetzte Logins.
+type LoginDenial struct {
+	ID         int64      `json:"id"`
+	CharID     int64      `json:"charId"`
+	CharName   string     `json:"charName"`
+	CorpID     *int64     `json:"corpId,omitempty"`
+	AllianceID *int64     `json:"allianceId,omitempty"`
+	Action     string     `json:"action"` // "reject" | "quarantine"
+	Reason     string     `json:"reason"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"userAgent"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+	ReviewedBy *int64     `json:"reviewedBy,omitempty"`
+}
@@ -0,0 +1,21 @@
+package structs
+
+// Account: Main-Char plus verknüpfte Alts; ActiveCharID ist der Char aus dem Cookie.
+type Account struct {
+	ID           int64              `json:"id"`
+	MainCharID   int64              `json:"mainCharId"`
+	ActiveCharID int64              `json:"activeCharId"`
+	Characters   []AccountCharacter `json:"characters"`
+}
+
+type AccountCharacter struct {
+	CharID   int64   `json:"charId"`
+	Name     string  `json:"name"`
+	CorpName *string `json:"corpName,omitempty"`
+	IsMain   bool    `json:"isMain"`
+	HasToken bool    `json:"hasToken"`
+}
+
+type SwitchCharReq struct {
+	CharID int64 `json:"charId"`
+}
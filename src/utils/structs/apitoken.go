@@ -0,0 +1,31 @@
+package structs
+
+import "time"
+
+// APIToken: persönlicher Token für Bots/Skripte. Scopes begrenzen die Rechte des Besitzers
+// (effektiv = Rechte des Users ∩ Scopes); ohne Scopes nur Lesezugriff als eingeloggter Char.
+type APIToken struct {
+	ID         string     `json:"id"`
+	CharID     int64      `json:"charId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	RatePerMin *int       `json:"ratePerMin,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+type CreateAPITokenReq struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	RatePerMin    *int     `json:"ratePerMin,omitempty"`
+	ExpiresInDays *int     `json:"expiresInDays,omitempty"`
+}
+
+// CreatedAPIToken enthält den Klartext-Token – wird nur einmal beim Anlegen ausgeliefert.
+type CreatedAPIToken struct {
+	APIToken
+	Token string `json:"token"`
+}
@@ -0,0 +1,41 @@
+package structs
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BackupFormat kennzeichnet Backup-Archive; BackupFormatVersion bei Änderungen am Archiv-Aufbau erhöhen.
+const (
+	BackupFormat        = "speedliner-backup"
+	BackupFormatVersion = 1
+)
+
+// BackupArchive: logischer Export der Fachtabellen (Zeilen als row_to_json).
+type BackupArchive struct {
+	Format        string        `json:"format"`
+	Version       int           `json:"version"`
+	SchemaVersion int           `json:"schemaVersion"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	Tables        []BackupTable `json:"tables"`
+}
+
+// BackupTable: Tabelle in Wiederherstellungsreihenfolge (Fremdschlüssel zuerst).
+type BackupTable struct {
+	Name string          `json:"name"`
+	Rows json.RawMessage `json:"rows"`
+}
+
+// BackupInfo beschreibt eine Archiv-Datei im Backup-Verzeichnis.
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RestoreResult: Zeilen pro Tabelle nach dem Einspielen.
+type RestoreResult struct {
+	SchemaVersion int            `json:"schemaVersion"`
+	Tables        map[string]int `json:"tables"`
+	Pruned        bool           `json:"pruned"`
+}
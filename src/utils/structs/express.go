@@ -3,7 +3,8 @@ package structs
 // ExpressMailRequest wird vom Frontend geschickt
 type ExpressMailRequest struct {
 	Route     string `json:"route"`           // "Amarr ↔ K-6K16"
-	RewardISK int64  `json:"reward_isk"`      // z.B. 826500000
+	RouteID   string `json:"route_id"`        // Preis kommt aus der Route
+	RewardISK int64  `json:"reward_isk"`      // optional, muss sonst dem Server-Quote entsprechen
 	VolumeM3  int64  `json:"volume_m3"`       // z.B. 165000
 	CollatISK int64  `json:"collateral_isk"`  // 0..20B
 	Express   bool   `json:"express"`         // true
@@ -11,4 +12,6 @@ type ExpressMailRequest struct {
 	// optional: Wer hat ausgelöst?
 	CustomerCharID   int64  `json:"customer_char_id,omitempty"`
 	CustomerCharName string `json:"customer_char_name,omitempty"`
+	// optional: Promo-Code wird beim Senden eingelöst
+	PromoCode string `json:"promo_code,omitempty"`
 }
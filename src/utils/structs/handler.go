@@ -17,11 +17,16 @@ type SendMailRequest struct {
 type MailIDResponse struct {
 	MailID int `json:"mail_id" example:"399492427"`
 }
-type ErrorResponse struct {
-	Error string `json:"error" example:"Invalid JSON"`
+
+// ErrorBody ist der Inhalt des Fehler-Envelopes {"error": {...}}.
+type ErrorBody struct {
+	Code      string `json:"code"                 example:"bad_request"`
+	Message   string `json:"message"              example:"Invalid JSON"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty" example:"c0ffee-000042"`
 }
 
-// @Success 201 {object} handler.MailIDResponse
-// @Failure 400 {object} handler.ErrorResponse
-// @Failure 401 {object} handler.ErrorResponse
-// @Failure 502 {object} handler.ErrorResponse
+// ErrorResponse ist der einheitliche Fehler-Envelope aller API-Antworten.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
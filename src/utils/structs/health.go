@@ -0,0 +1,14 @@
+package structs
+
+// HealthCheck: Ergebnis einer Einzelprüfung (status ok|warn|fail|skipped).
+type HealthCheck struct {
+	Status    string `json:"status"`
+	Message   string `json:"message,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+	Critical  bool   `json:"critical"`
+}
+
+type HealthReport struct {
+	Status string                 `json:"status"` // ok | degraded | fail
+	Checks map[string]HealthCheck `json:"checks"`
+}
@@ -0,0 +1,126 @@
+package structs
+
+import "time"
+
+// Benannte Rechte. Rollen bündeln Rechte, ein Char kann mehrere Rollen haben.
+const (
+	PermRoutesEdit       = "routes.edit"       // Routen anlegen/ändern/löschen
+	PermRoutesViewAll    = "routes.view_all"   // auch fremde Whitelist-Routen sehen
+	PermRoutesPricing    = "routes.pricing"    // Corp-Raten und Promo-Codes pflegen
+	PermCorpsRead        = "corps.read"        // Corp-Suche (Whitelist-UI)
+	PermProvidersProfile = "providers.profile" // eigenes Hauler-Profil, Dispatch-Empfänger
+	PermProvidersRead    = "providers.read"    // Hauler-Profile/Verfügbarkeit einsehen
+	PermExpressDispatch  = "express.dispatch"  // Express-Service-Token überwachen
+	PermUsersManage      = "users.manage"      // User verwalten, Rollen zuweisen
+	PermRolesManage      = "roles.manage"      // Rollen definieren
+	PermAuditRead        = "audit.read"        // Protokolle einsehen
+	PermBackupManage     = "backup.manage"     // Datenbank-Backups anlegen/auflisten
+)
+
+var AllPermissions = []string{
+	PermRoutesEdit,
+	PermRoutesViewAll,
+	PermRoutesPricing,
+	PermCorpsRead,
+	PermProvidersProfile,
+	PermProvidersRead,
+	PermExpressDispatch,
+	PermUsersManage,
+	PermRolesManage,
+	PermAuditRead,
+	PermBackupManage,
+}
+
+func IsKnownPermission(p string) bool {
+	for _, it := range AllPermissions {
+		if it == p {
+			return true
+		}
+	}
+	return false
+}
+
+// BuiltinRoles werden beim Start angelegt; "admin" bekommt immer alle Rechte.
+var BuiltinRoles = map[string][]string{
+	"user": {},
+	"provider": {
+		PermRoutesEdit, PermRoutesViewAll, PermRoutesPricing, PermCorpsRead,
+		PermProvidersProfile, PermProvidersRead,
+	},
+	"admin": AllPermissions,
+}
+
+// PermissionSet ist die effektive Rechtemenge eines Chars.
+type PermissionSet map[string]bool
+
+func NewPermissionSet(perms []string) PermissionSet {
+	set := make(PermissionSet, len(perms))
+	for _, p := range perms {
+		set[p] = true
+	}
+	return set
+}
+
+// HasAny: true, wenn mindestens eins der Rechte vorhanden ist.
+func (s PermissionSet) HasAny(perms ...string) bool {
+	for _, p := range perms {
+		if s[p] {
+			return true
+		}
+	}
+	return false
+}
+
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Builtin     bool     `json:"builtin"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateUserRolesReq struct {
+	Roles []string `json:"roles"`
+}
+
+// RoleResponse für /role: primäre Rolle (Kompatibilität), alle Rollen, effektive Rechte und Quarantäne-Status.
+type RoleResponse struct {
+	Role        string   `json:"role"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+	Quarantined bool     `json:"quarantined"`
+}
+
+// Herkunft einer Rollenzuweisung
+const (
+	RoleSourceManual  = "manual"  // vom Admin gesetzt, hat Vorrang vor Regeln
+	RoleSourceRule    = "rule"    // aus Corp-/Alliance-Regel
+	RoleSourceDefault = "default" // Standardrolle ohne passende Regel
+)
+
+type RoleAssignment struct {
+	Role   string `json:"role"`
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
+// RoleRule weist Mitgliedern einer Corp/Alliance automatisch eine Rolle zu.
+// MatchType "default" greift, wenn keine Corp-/Alliance-Regel passt.
+type RoleRule struct {
+	ID        int64     `json:"id"`
+	MatchType string    `json:"matchType"` // "corp" | "alliance" | "default"
+	MatchID   *int64    `json:"matchId,omitempty"`
+	Role      string    `json:"role"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type RoleChange struct {
+	ID        int64     `json:"id"`
+	CharID    int64     `json:"charId"`
+	OldRoles  []string  `json:"oldRoles"`
+	NewRoles  []string  `json:"newRoles"`
+	Source    string    `json:"source"`
+	Reason    string    `json:"reason"`
+	ChangedBy *int64    `json:"changedBy,omitempty"`
+	ChangedAt time.Time `json:"changedAt"`
+}
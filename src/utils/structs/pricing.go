@@ -0,0 +1,53 @@
+package structs
+
+import "time"
+
+// RouteRate ist ein ausgehandelter Preis bzw. Rabatt für eine Corp oder Alliance.
+// RouteID == nil gilt für alle Routen; genau eins von CorpID/AllianceID ist gesetzt.
+type RouteRate struct {
+	ID          string    `json:"id"`
+	RouteID     *string   `json:"routeId,omitempty"`
+	CorpID      *int64    `json:"corpId,omitempty"`
+	AllianceID  *int64    `json:"allianceId,omitempty"`
+	PricePerM3  *float64  `json:"pricePerM3,omitempty"`  // Override des Routenpreises
+	DiscountPct *float64  `json:"discountPct,omitempty"` // prozentualer Rabatt (0..100]
+	MinPrice    *float64  `json:"minPrice,omitempty"`    // optional abweichender Mindestpreis
+	Note        string    `json:"note"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// PromoCode ist ein einlösbarer Rabattcode mit optionalem Nutzungslimit und Ablaufdatum.
+type PromoCode struct {
+	Code        string     `json:"code"`
+	DiscountPct float64    `json:"discountPct"`
+	RouteID     *string    `json:"routeId,omitempty"` // nil = alle Routen
+	MaxUses     *int       `json:"maxUses,omitempty"` // nil = unbegrenzt
+	Uses        int        `json:"uses"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	Active      bool       `json:"active"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+type QuoteRequest struct {
+	RouteID       string `json:"routeId"`
+	VolumeM3      int64  `json:"volumeM3"`
+	CollateralISK int64  `json:"collateralISK"`
+	Express       bool   `json:"express"`
+	PromoCode     string `json:"promoCode,omitempty"`
+}
+
+type QuoteResponse struct {
+	RouteID          string  `json:"routeId"`
+	BasePricePerM3   float64 `json:"basePricePerM3"`
+	PricePerM3       float64 `json:"pricePerM3"`
+	VolumeFee        int64   `json:"volumeFee"`
+	CollateralFee    int64   `json:"collateralFee"`
+	MinPrice         float64 `json:"minPrice"`
+	MinApplied       bool    `json:"minApplied"`
+	RateSource       string  `json:"rateSource,omitempty"` // "corp" | "alliance"
+	RateDiscountPct  float64 `json:"rateDiscountPct,omitempty"`
+	PromoCode        string  `json:"promoCode,omitempty"`
+	PromoDiscountPct float64 `json:"promoDiscountPct,omitempty"`
+	Express          bool    `json:"express"`
+	Total            int64   `json:"total"`
+}
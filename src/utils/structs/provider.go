@@ -0,0 +1,28 @@
+package structs
+
+import "time"
+
+// ProviderProfile beschreibt einen Hauler (Rolle "provider"): geflogene Routen, Schiff, Zeitzone, Dienststatus.
+type ProviderProfile struct {
+	CharID    int64     `json:"charId"`
+	Name      string    `json:"name"`
+	ShipClass string    `json:"shipClass"` // siehe AllowedShipClasses
+	MaxM3     int64     `json:"maxM3"`
+	Timezone  string    `json:"timezone"` // IANA, z.B. "Europe/Berlin"
+	OnDuty    bool      `json:"onDuty"`
+	Notes     string    `json:"notes"`
+	RouteIDs  []string  `json:"routeIds"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type DutyReq struct {
+	OnDuty bool `json:"onDuty"`
+}
+
+var AllowedShipClasses = map[string]bool{
+	"freighter":       true,
+	"jump_freighter":  true,
+	"blockade_runner": true,
+	"dst":             true,
+	"other":           true,
+}
@@ -10,3 +10,40 @@ type Route struct {
 	AllowedCorps []int64 `json:"allowedCorps,omitempty"`
 	MinPrice     float64 `json:"minPrice"`
 }
+
+// RouteExport: Route samt Whitelist-Corps als Ticker (Export/Import).
+type RouteExport struct {
+	Route
+	AllowedCorpTickers []string `json:"allowedCorpTickers,omitempty"`
+}
+
+// RouteImportRow: eine Zeile der Import-Datei (Line 1-basiert, bei CSV inkl. Header).
+type RouteImportRow struct {
+	Line  int
+	Route RouteExport
+}
+
+// RouteChange: geplante Änderung einer Route ("create" | "update" | "delete" | "unchanged").
+type RouteChange struct {
+	Action string       `json:"action"`
+	Line   int          `json:"line,omitempty"`
+	Route  RouteExport  `json:"route"`
+	Before *RouteExport `json:"before,omitempty"`
+}
+
+type RouteImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// RouteImportPlan: Ergebnis von Dry-Run bzw. Import.
+type RouteImportPlan struct {
+	DryRun    bool               `json:"dryRun"`
+	Applied   bool               `json:"applied"`
+	Creates   int                `json:"creates"`
+	Updates   int                `json:"updates"`
+	Deletes   int                `json:"deletes"`
+	Unchanged int                `json:"unchanged"`
+	Changes   []RouteChange      `json:"changes"`
+	Errors    []RouteImportError `json:"errors,omitempty"`
+}
@@ -1,19 +1,111 @@
 package structs
 
+import "time"
+
 // UserResponse godoc
 // @Description Darstellung eines Users für die Admin-API.
 type User struct {
-	CharID string `json:"char_id"`
-	Name   string `json:"name"`
-	Role   string `json:"role"`
+	CharID string   `json:"char_id"`
+	Name   string   `json:"name"`
+	Role   string   `json:"role"`
+	Roles  []string `json:"roles"`
+	// Main-Char des Accounts (bei Alts ungleich CharID)
+	MainCharID *int64 `json:"main_char_id,omitempty"`
 }
 
 type UpdateRoleReq struct {
 	Role string `json:"role"`
 }
 
-var AllowedRoles = map[string]bool{
-	"user":     true,
-	"provider": true,
-	"admin":    true,
+// UserQuery: Filter/Sortierung/Paging für GET /app/users
+type UserQuery struct {
+	Search  string // Name, Corp- oder Alliance-Ticker
+	Role    string
+	Banned  *bool
+	Sort    string // name | corp | alliance | role | last_login | last_seen | created
+	Desc    bool
+	Page    int
+	PerPage int
+}
+
+// UserListItem: User inkl. Corp/Alliance aus v_users_enriched
+type UserListItem struct {
+	User
+	CorpID         *int64     `json:"corp_id,omitempty"`
+	CorpName       *string    `json:"corp_name,omitempty"`
+	CorpTicker     *string    `json:"corp_ticker,omitempty"`
+	AllianceID     *int64     `json:"alliance_id,omitempty"`
+	AllianceName   *string    `json:"alliance_name,omitempty"`
+	AllianceTicker *string    `json:"alliance_ticker,omitempty"`
+	Quarantined    bool       `json:"quarantined"`
+	BannedAt       *time.Time `json:"banned_at,omitempty"`
+	BannedReason   string     `json:"banned_reason,omitempty"`
+	LastLoginAt    *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastSeenAt     *time.Time `json:"last_seen_at,omitempty"`
+}
+
+type UserPage struct {
+	Items   []UserListItem `json:"items"`
+	Total   int            `json:"total"`
+	Page    int            `json:"page"`
+	PerPage int            `json:"per_page"`
+}
+
+// TokenStatus: Zustand des gespeicherten ESI-Tokens (ohne Token-Inhalt)
+type TokenStatus struct {
+	HasToken    bool       `json:"has_token"`
+	Refreshable bool       `json:"refreshable"`
+	Expiry      *time.Time `json:"expiry,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// PromoRedemption: eingelöster Promo-Code (einzige gespeicherte Auftragsspur)
+type PromoRedemption struct {
+	Code       string    `json:"code"`
+	RouteID    *string   `json:"route_id,omitempty"`
+	Route      *string   `json:"route,omitempty"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+}
+
+// UserDetail für GET /app/users/{charID}
+type UserDetail struct {
+	UserListItem
+	BannedBy    *int64             `json:"banned_by,omitempty"`
+	Characters  []AccountCharacter `json:"characters"`
+	Token       TokenStatus        `json:"token"`
+	Redemptions []PromoRedemption  `json:"redemptions"`
+}
+
+type BanReq struct {
+	Reason string `json:"reason"`
+}
+
+type LoginEvent struct {
+	ID        int64     `json:"id"`
+	CharID    int64     `json:"char_id"`
+	Kind      string    `json:"kind"` // login | link
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SuspiciousLogin: auffälliges Muster im Zeitfenster.
+// kind "shared_ip": eine IP für mehrere Accounts; kind "many_ips": ein Char von vielen IPs.
+type SuspiciousLogin struct {
+	Kind      string    `json:"kind"`
+	IP        string    `json:"ip,omitempty"`
+	CharID    *int64    `json:"char_id,omitempty"`
+	Count     int       `json:"count"`
+	CharIDs   []int64   `json:"char_ids"`
+	IPs       []string  `json:"ips"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// CorpOption: Treffer der Corp-Suche (Whitelist-UI).
+type CorpOption struct {
+	CorpID int64  `json:"corpId"`
+	Ticker string `json:"ticker"`
+	Name   string `json:"name"`
 }
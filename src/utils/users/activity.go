@@ -0,0 +1,45 @@
+package users
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// lastSeenEvery drosselt die Schreibzugriffe auf users.last_seen_at pro Char.
+const lastSeenEvery = 5 * time.Minute
+
+var (
+	seenMu sync.Mutex
+	seenAt = map[int64]time.Time{}
+)
+
+// TouchLastSeen aktualisiert last_seen_at höchstens alle lastSeenEvery.
+func TouchLastSeen(ctx context.Context, charID string) {
+	id, err := strconv.ParseInt(charID, 10, 64)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	seenMu.Lock()
+	if t, ok := seenAt[id]; ok && now.Sub(t) < lastSeenEvery {
+		seenMu.Unlock()
+		return
+	}
+	seenAt[id] = now
+	// Map klein halten: abgelaufene Einträge wegräumen
+	if len(seenAt) > 10000 {
+		for k, t := range seenAt {
+			if now.Sub(t) >= lastSeenEvery {
+				delete(seenAt, k)
+			}
+		}
+	}
+	seenMu.Unlock()
+
+	if err := userRepo.TouchLastSeen(ctx, id); err != nil {
+		log.Printf("TouchLastSeen %d: %v", id, err)
+	}
+}
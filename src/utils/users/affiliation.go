@@ -0,0 +1,137 @@
+package users
+
+import (
+	"context"
+	"log"
+	"speedliner-server/src/db"
+	"speedliner-server/src/utils/access"
+	"speedliner-server/src/utils/esi"
+	"speedliner-server/src/utils/structs"
+	"time"
+)
+
+// Affiliation ist die aktuelle Corp/Alliance eines Chars laut ESI (CorpID 0 = unbekannt).
+type Affiliation struct {
+	CorpID         int64
+	CorpName       string
+	CorpTicker     string
+	AllianceID     *int64
+	AllianceName   *string
+	AllianceTicker *string
+}
+
+// ResolveAffiliation holt Corp/Alliance frisch von ESI (ohne zu speichern).
+func ResolveAffiliation(charID int64) Affiliation {
+	var a Affiliation
+	a.CorpID, a.CorpName, a.CorpTicker, a.AllianceID, a.AllianceName, a.AllianceTicker =
+		esi.FetchCorpAndAlliance(int(charID))
+	return a
+}
+
+// SaveAffiliation speichert Corp/Alliance am User und wertet danach die Rollen-Regeln aus.
+func SaveAffiliation(ctx context.Context, charID int64, aff Affiliation) error {
+	// Alliance optional
+	var alliPtr *int64
+	if aff.AllianceID != nil && *aff.AllianceID != 0 {
+		var aName, aTick string
+		if aff.AllianceName != nil {
+			aName = *aff.AllianceName
+		}
+		if aff.AllianceTicker != nil {
+			aTick = *aff.AllianceTicker
+		}
+		if err := db.UpsertAlliance(ctx, *aff.AllianceID, aName, aTick); err != nil {
+			log.Printf("UpsertAlliance: %v", err)
+		}
+		alliPtr = aff.AllianceID
+	}
+
+	// Corp + User setzen
+	if aff.CorpID != 0 {
+		if err := db.UpsertCorp(ctx, aff.CorpID, aff.CorpName, aff.CorpTicker, alliPtr); err != nil {
+			log.Printf("UpsertCorp: %v", err)
+		}
+		if err := db.UpdateUserCorp(ctx, charID, aff.CorpID); err != nil {
+			log.Printf("UpdateUserCorp: %v", err)
+		}
+	}
+
+	return ApplyRoleRules(ctx, charID)
+}
+
+// RefreshAffiliation: ESI abfragen, speichern, Regeln auswerten. Wer die erlaubten
+// Corps/Alliances verlassen hat, wird im Allow-List-Modus in Quarantäne gesetzt.
+func RefreshAffiliation(ctx context.Context, charID int64) error {
+	aff := ResolveAffiliation(charID)
+	if aff.CorpID != 0 {
+		if ok, reason := CheckAccess(ctx, charID, aff); !ok {
+			changed, err := db.SetUserQuarantined(ctx, charID, true)
+			if err != nil {
+				return err
+			}
+			if changed {
+				corpID := aff.CorpID
+				_ = db.RecordLoginDenial(ctx, structs.LoginDenial{
+					CharID: charID, CorpID: &corpID, AllianceID: aff.AllianceID,
+					Action: access.ActionQuarantine, Reason: "affiliation refresh: " + reason,
+				})
+			}
+		}
+	}
+	return SaveAffiliation(ctx, charID, aff)
+}
+
+// CheckAccess prüft die Zugangs-Policy; freigegebene Chars (access_exempt) sind immer erlaubt.
+func CheckAccess(ctx context.Context, charID int64, aff Affiliation) (bool, string) {
+	ok, reason := access.Current().Check(aff.CorpID, aff.AllianceID)
+	if ok {
+		return true, ""
+	}
+	if exempt, err := db.IsUserAccessExempt(ctx, charID); err == nil && exempt {
+		return true, ""
+	}
+	return false, reason
+}
+
+// RefreshAllAffiliations aktualisiert alle bekannten User nacheinander (ESI-schonend).
+func RefreshAllAffiliations(ctx context.Context) error {
+	ids, err := db.ListUserIDs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := RefreshAffiliation(ctx, id); err != nil {
+			log.Printf("RefreshAffiliation %d: %v", id, err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return nil
+}
+
+// StartAffiliationRefresher startet den periodischen Refresh; interval <= 0 schaltet ihn ab.
+// wait blockiert, bis ein laufender Refresh nach dem Ende von ctx beendet ist.
+func StartAffiliationRefresher(ctx context.Context, interval time.Duration) (wait func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := RefreshAllAffiliations(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("affiliation refresh: %v", err)
+				}
+			}
+		}
+	}()
+	return func() { <-done }
+}
@@ -0,0 +1,70 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"speedliner-server/src/db"
+	"speedliner-server/src/utils/structs"
+)
+
+// ApplyRoleRules wertet die Corp-/Alliance-Regeln für den Account eines Chars aus;
+// maßgeblich ist die Zugehörigkeit des Main-Chars.
+// Manuelle Zuweisungen haben Vorrang: existiert eine, bleibt alles unverändert.
+func ApplyRoleRules(ctx context.Context, charID int64) error {
+	charID, err := db.MainCharID(ctx, charID)
+	if err != nil {
+		return err
+	}
+	current, err := db.GetUserRoleAssignments(ctx, charID)
+	if err != nil {
+		return err
+	}
+	for _, a := range current {
+		if a.Source == structs.RoleSourceManual {
+			return nil
+		}
+	}
+
+	rules, err := db.GetMatchingRoleRules(ctx, charID)
+	if err != nil {
+		return err
+	}
+
+	var assign []structs.RoleAssignment
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		if seen[rule.Role] {
+			continue
+		}
+		seen[rule.Role] = true
+		reason := fmt.Sprintf("rule #%d (%s)", rule.ID, rule.MatchType)
+		if rule.MatchID != nil {
+			reason = fmt.Sprintf("rule #%d (%s %d)", rule.ID, rule.MatchType, *rule.MatchID)
+		}
+		assign = append(assign, structs.RoleAssignment{Role: rule.Role, Source: structs.RoleSourceRule, Reason: reason})
+	}
+	if len(assign) == 0 {
+		assign = []structs.RoleAssignment{{Role: "user", Source: structs.RoleSourceDefault, Reason: "no matching rule"}}
+	}
+
+	if sameAssignments(current, assign) {
+		return nil
+	}
+	return db.ApplyRuleRoles(ctx, charID, assign)
+}
+
+func sameAssignments(current, next []structs.RoleAssignment) bool {
+	if len(current) != len(next) {
+		return false
+	}
+	have := map[string]string{}
+	for _, a := range current {
+		have[a.Role] = a.Source
+	}
+	for _, a := range next {
+		if src, ok := have[a.Role]; !ok || src != a.Source {
+			return false
+		}
+	}
+	return true
+}
@@ -2,26 +2,44 @@ package users
 
 import (
 	"context"
-	"fmt"
-	"speedliner-server/src/db"
+	"speedliner-server/src/repo"
+	"speedliner-server/src/utils/structs"
+	"strconv"
 )
 
-func HasRole(charID string, allowedRoles ...string) (bool, error) {
-	query := `
-		SELECT role 
-		FROM users 
-		WHERE char_id = $1;`
+// userRepo: Quelle für Rechte, Sperren und last_seen (Standard Postgres, in Tests memrepo).
+var userRepo repo.UserRepository = repo.PGUsers{}
 
-	row := db.Pool.QueryRow(context.Background(), query, charID)
-	var userRole string
-	if err := row.Scan(&userRole); err != nil {
-		return false, fmt.Errorf("GetUserRoles error: %w", err)
+// InitRepository setzt die User-Datenhaltung für Rechteprüfung, Sperren und Aktivität.
+func InitRepository(r repo.UserRepository) { userRepo = r }
+
+// Permissions liefert die effektiven Rechte eines Chars (Vereinigung aller Rollen).
+func Permissions(ctx context.Context, charID int64) (structs.PermissionSet, error) {
+	perms, err := userRepo.Permissions(ctx, charID)
+	if err != nil {
+		return nil, err
+	}
+	return structs.NewPermissionSet(perms), nil
+}
+
+// HasPermission: true, wenn der Char mindestens eins der Rechte besitzt.
+func HasPermission(ctx context.Context, charID string, perms ...string) (bool, error) {
+	id, err := strconv.ParseInt(charID, 10, 64)
+	if err != nil {
+		return false, err
+	}
+	set, err := Permissions(ctx, id)
+	if err != nil {
+		return false, err
 	}
+	return set.HasAny(perms...), nil
+}
 
-	for _, role := range allowedRoles {
-		if userRole == role {
-			return true, nil
-		}
+// IsBanned: Char (oder Main seines Accounts) ist gesperrt.
+func IsBanned(ctx context.Context, charID string) (bool, error) {
+	id, err := strconv.ParseInt(charID, 10, 64)
+	if err != nil {
+		return false, err
 	}
-	return false, nil
+	return userRepo.IsBanned(ctx, id)
 }
@@ -1,14 +0,0 @@
-package utils
-
-import (
-	"log"
-
-	"github.com/joho/godotenv"
-)
-
-func LoadEnv() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatalf("Fehler beim Laden der .env-Datei: %v", err)
-	}
-}